@@ -177,7 +177,7 @@ var _ = BeforeSuite(func() {
 
 	By("Creating fly connection")
 	l := logger.NewLogger(sanitizer)
-	flyCommand = fly.NewCommand("concourse-pipeline-resource-target", l, inFlyPath)
+	flyCommand = fly.NewCommand("concourse-pipeline-resource-target", l, inFlyPath, fly.Options{})
 
 	By("Logging in with fly")
 	_, err = flyCommand.Login(target, teamName, username, password, insecure)
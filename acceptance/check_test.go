@@ -39,7 +39,7 @@ var _ = Describe("Check", func() {
 		checkRequest = concourse.CheckRequest{
 			Source: concourse.Source{
 				Target:   target,
-				Insecure: fmt.Sprintf("%t", insecure),
+				Insecure: concourse.Bool(fmt.Sprintf("%t", insecure)),
 				Teams: []concourse.Team{
 					{
 						Name:     teamName,
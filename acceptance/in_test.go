@@ -45,7 +45,7 @@ var _ = Describe("In", func() {
 		inRequest = concourse.InRequest{
 			Source: concourse.Source{
 				Target:   target,
-				Insecure: fmt.Sprintf("%t", insecure),
+				Insecure: concourse.Bool(fmt.Sprintf("%t", insecure)),
 				Teams: []concourse.Team{
 					{
 						Name:     teamName,
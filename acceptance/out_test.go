@@ -133,7 +133,7 @@ jobs:
 		outRequest = concourse.OutRequest{
 			Source: concourse.Source{
 				Target:   target,
-				Insecure: fmt.Sprintf("%t", insecure),
+				Insecure: concourse.Bool(fmt.Sprintf("%t", insecure)),
 				Teams: []concourse.Team{
 					{
 						Name:     teamName,
@@ -0,0 +1,183 @@
+// Package aws reads parameters from SSM Parameter Store and secrets from
+// Secrets Manager, signing requests to their APIs itself rather than through
+// an SDK.
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config configures a Client.
+type Config struct {
+	Region string
+
+	// Credentials are those given, if any. Otherwise those in the
+	// environment, or of the ECS task or EC2 instance, are used.
+	Credentials Credentials
+
+	// RoleARN is a role assumed with the credentials, if given.
+	RoleARN string
+
+	// Endpoint replaces the endpoint of every service in the region, e.g.
+	// with that of a fake.
+	Endpoint string
+
+	HTTPClient *http.Client
+}
+
+// Client reads parameters and secrets with the credentials it was created
+// with.
+type Client struct {
+	region      string
+	credentials Credentials
+	endpointURL string
+	httpClient  *http.Client
+}
+
+// NewClient returns a client for the region, finding its credentials and
+// assuming the role of the config, if any.
+func NewClient(config Config) (*Client, error) {
+	if config.Region == "" {
+		return nil, fmt.Errorf("the region of AWS must be given")
+	}
+
+	if (config.Credentials.AccessKeyID == "") != (config.Credentials.SecretAccessKey == "") {
+		return nil, fmt.Errorf("both the access key ID and the secret access key of AWS must be given")
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	c := &Client{
+		region:      config.Region,
+		credentials: config.Credentials,
+		endpointURL: strings.TrimSuffix(config.Endpoint, "/"),
+		httpClient:  httpClient,
+	}
+
+	if c.credentials.empty() {
+		var err error
+		c.credentials, err = defaultCredentials(httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.RoleARN != "" {
+		var err error
+		c.credentials, err = c.assumeRole(config.RoleARN, "concourse-pipeline-resource")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Parameter returns the value of the parameter of SSM Parameter Store,
+// decrypted if it is a SecureString.
+func (c *Client) Parameter(name string) (string, error) {
+	var output struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+
+	err := c.call("ssm", "AmazonSSM.GetParameter", map[string]interface{}{
+		"Name":           name,
+		"WithDecryption": true,
+	}, &output)
+	if err != nil {
+		return "", fmt.Errorf("reading parameter %s: %v", name, err)
+	}
+
+	return output.Parameter.Value, nil
+}
+
+// SecretValue returns the current value of the secret of Secrets Manager,
+// given by its name or ARN.
+func (c *Client) SecretValue(id string) (string, error) {
+	var output struct {
+		SecretString *string `json:"SecretString"`
+		SecretBinary []byte  `json:"SecretBinary"`
+	}
+
+	err := c.call("secretsmanager", "secretsmanager.GetSecretValue", map[string]interface{}{
+		"SecretId": id,
+	}, &output)
+	if err != nil {
+		return "", fmt.Errorf("reading secret %s: %v", id, err)
+	}
+
+	if output.SecretString != nil {
+		return *output.SecretString, nil
+	}
+
+	return string(output.SecretBinary), nil
+}
+
+// call calls the operation of the JSON API of the service, decoding its
+// output into v.
+func (c *Client) call(service string, target string, input interface{}, v interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.endpoint(service)+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	Sign(req, body, service, c.region, c.credentials, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	output, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Services differ in the case of the message of errors.
+		var e struct {
+			Type         string `json:"__type"`
+			Message      string `json:"message"`
+			MessageUpper string `json:"Message"`
+		}
+		json.Unmarshal(output, &e)
+
+		message := e.Message
+		if message == "" {
+			message = e.MessageUpper
+		}
+		if e.Type != "" {
+			message = e.Type[strings.LastIndex(e.Type, "#")+1:] + ": " + message
+		}
+
+		return fmt.Errorf("%s: %s", resp.Status, message)
+	}
+
+	return json.Unmarshal(output, v)
+}
+
+func (c *Client) endpoint(service string) string {
+	if c.endpointURL != "" {
+		return c.endpointURL
+	}
+
+	return fmt.Sprintf("https://%s.%s.amazonaws.com", service, c.region)
+}
@@ -0,0 +1,178 @@
+package aws_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/concourse/concourse-pipeline-resource/aws"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		inputs   []map[string]interface{}
+		status   int
+		body     string
+
+		config aws.Config
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		inputs = nil
+		status = http.StatusOK
+		body = `{"Parameter": {"Name": "/ci/password", "Value": "some-password"}}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			var input map[string]interface{}
+			json.Unmarshal(b, &input)
+
+			requests = append(requests, r)
+			inputs = append(inputs, input)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+
+		config = aws.Config{
+			Region:      "eu-west-1",
+			Credentials: aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+			Endpoint:    server.URL,
+			HTTPClient:  server.Client(),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reads parameters, decrypted", func() {
+		client, err := aws.NewClient(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		value, err := client.Parameter("/ci/password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("some-password"))
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Header.Get("X-Amz-Target")).To(Equal("AmazonSSM.GetParameter"))
+		Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKID/"))
+		Expect(requests[0].Header.Get("Authorization")).To(ContainSubstring("/eu-west-1/ssm/aws4_request"))
+		Expect(inputs[0]).To(Equal(map[string]interface{}{"Name": "/ci/password", "WithDecryption": true}))
+	})
+
+	It("reads secrets", func() {
+		body = `{"Name": "ci/token", "SecretString": "some-token"}`
+
+		client, err := aws.NewClient(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		value, err := client.SecretValue("ci/token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("some-token"))
+
+		Expect(requests[0].Header.Get("X-Amz-Target")).To(Equal("secretsmanager.GetSecretValue"))
+		Expect(inputs[0]).To(Equal(map[string]interface{}{"SecretId": "ci/token"}))
+	})
+
+	It("returns the errors of the API", func() {
+		status = http.StatusBadRequest
+		body = `{"__type": "com.amazonaws.ssm#ParameterNotFound", "message": "Parameter /ci/missing not found."}`
+
+		client, err := aws.NewClient(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Parameter("/ci/missing")
+		Expect(err).To(MatchError("reading parameter /ci/missing: 400 Bad Request: ParameterNotFound: Parameter /ci/missing not found."))
+	})
+
+	It("requires a region", func() {
+		config.Region = ""
+
+		_, err := aws.NewClient(config)
+		Expect(err).To(MatchError("the region of AWS must be given"))
+	})
+
+	Context("when a role is given", func() {
+		BeforeEach(func() {
+			config.RoleARN = "arn:aws:iam::123456789012:role/ci"
+
+			body = `<AssumeRoleResponse><AssumeRoleResult><Credentials>
+				<AccessKeyId>ASIA</AccessKeyId>
+				<SecretAccessKey>assumed-secret</SecretAccessKey>
+				<SessionToken>assumed-token</SessionToken>
+			</Credentials></AssumeRoleResult></AssumeRoleResponse>`
+		})
+
+		It("signs requests with the credentials of the role", func() {
+			client, err := aws.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].URL.Query().Get("Action")).To(Equal("AssumeRole"))
+			Expect(requests[0].URL.Query().Get("RoleArn")).To(Equal("arn:aws:iam::123456789012:role/ci"))
+			Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKID/"))
+
+			body = `{"Parameter": {"Value": "some-password"}}`
+			_, err = client.Parameter("/ci/password")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[1].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=ASIA/"))
+			Expect(requests[1].Header.Get("X-Amz-Security-Token")).To(Equal("assumed-token"))
+		})
+	})
+
+	Context("when no credentials are given", func() {
+		BeforeEach(func() {
+			config.Credentials = aws.Credentials{}
+
+			os.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/credentials")
+			os.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "some-authorization")
+
+			body = `{"AccessKeyId": "ASIATASK", "SecretAccessKey": "task-secret", "Token": "task-token"}`
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+			os.Unsetenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+		})
+
+		It("uses those of the container", func() {
+			client, err := aws.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[0].URL.Path).To(Equal("/credentials"))
+			Expect(requests[0].Header.Get("Authorization")).To(Equal("some-authorization"))
+
+			body = `{"Parameter": {"Value": "some-password"}}`
+			_, err = client.Parameter("/ci/password")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests[1].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=ASIATASK/"))
+			Expect(requests[1].Header.Get("X-Amz-Security-Token")).To(Equal("task-token"))
+		})
+
+		It("prefers those in the environment", func() {
+			os.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+			defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+			defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+			body = `{"Parameter": {"Value": "some-password"}}`
+			client, err := aws.NewClient(config)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = client.Parameter("/ci/password")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKIAENV/"))
+		})
+	})
+})
@@ -0,0 +1,218 @@
+package aws
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	containerCredentialsHost = "http://169.254.170.2"
+	instanceMetadataHost     = "http://169.254.169.254"
+)
+
+// Credentials are the keys with which requests are signed.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+func (c Credentials) empty() bool {
+	return c.AccessKeyID == "" && c.SecretAccessKey == ""
+}
+
+// defaultCredentials returns the credentials given in the environment, or
+// else those of the ECS task or EC2 instance the resource runs on, in the
+// order of the SDKs of AWS.
+func defaultCredentials(httpClient *http.Client) (Credentials, error) {
+	env := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if !env.empty() {
+		return env, nil
+	}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return containerCredentials(httpClient, containerCredentialsHost+uri, "")
+	}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		return containerCredentials(httpClient, uri, os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"))
+	}
+
+	credentials, err := instanceCredentials(httpClient)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("no credentials given, in the environment, or of the ECS task or EC2 instance: %v", err)
+	}
+
+	return credentials, nil
+}
+
+// temporaryCredentials is how temporary credentials are given by the
+// container and instance metadata endpoints.
+type temporaryCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+func containerCredentials(httpClient *http.Client, uri string, authorization string) (Credentials, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	var c temporaryCredentials
+	err = doJSON(httpClient, req, &c)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("fetching the credentials of the container: %v", err)
+	}
+
+	return Credentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.Token}, nil
+}
+
+// instanceCredentials returns the credentials of the role of the EC2
+// instance, fetched with IMDSv2.
+func instanceCredentials(httpClient *http.Client) (Credentials, error) {
+	client := *httpClient
+	client.Timeout = 2 * time.Second
+
+	req, err := http.NewRequest("PUT", instanceMetadataHost+"/latest/api/token", nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	token, err := doText(&client, req)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	rolesURL := instanceMetadataHost + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequest("GET", rolesURL, nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+
+	roles, err := doText(&client, req)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return Credentials{}, fmt.Errorf("the instance has no role")
+	}
+
+	req, err = http.NewRequest("GET", rolesURL+url.PathEscape(role), nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+
+	var c temporaryCredentials
+	err = doJSON(&client, req, &c)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.Token}, nil
+}
+
+// assumeRole returns the temporary credentials of the role, assumed with the
+// credentials through STS.
+func (c *Client) assumeRole(roleARN string, sessionName string) (Credentials, error) {
+	query := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {sessionName},
+	}
+
+	req, err := http.NewRequest("GET", c.endpoint("sts")+"/?"+query.Encode(), nil)
+	if err != nil {
+		return Credentials{}, err
+	}
+	Sign(req, nil, "sts", c.region, c.credentials, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("assuming role %s: %v", roleARN, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("assuming role %s: %v", roleARN, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error struct {
+				Message string `xml:"Message"`
+			} `xml:"Error"`
+		}
+		xml.Unmarshal(body, &e)
+
+		return Credentials{}, fmt.Errorf("assuming role %s: %s: %s", roleARN, resp.Status, e.Error.Message)
+	}
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string `xml:"SecretAccessKey"`
+			SessionToken    string `xml:"SessionToken"`
+		} `xml:"AssumeRoleResult>Credentials"`
+	}
+	err = xml.Unmarshal(body, &result)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("assuming role %s: %v", roleARN, err)
+	}
+
+	return Credentials{
+		AccessKeyID:     result.Credentials.AccessKeyID,
+		SecretAccessKey: result.Credentials.SecretAccessKey,
+		SessionToken:    result.Credentials.SessionToken,
+	}, nil
+}
+
+func doText(httpClient *http.Client, req *http.Request) (string, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status)
+	}
+
+	return string(body), nil
+}
+
+func doJSON(httpClient *http.Client, req *http.Request, v interface{}) error {
+	body, err := doText(httpClient, req)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(body), v)
+}
@@ -0,0 +1,42 @@
+package aws_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/aws"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sign", func() {
+	// The example of the Signature Version 4 documentation.
+	It("signs requests with Signature Version 4", func() {
+		req, err := http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+		credentials := aws.Credentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		}
+		aws.Sign(req, nil, "iam", "us-east-1", credentials, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+		Expect(req.Header.Get("X-Amz-Date")).To(Equal("20150830T123600Z"))
+		Expect(req.Header.Get("Authorization")).To(Equal("AWS4-HMAC-SHA256 " +
+			"Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+			"SignedHeaders=content-type;host;x-amz-date, " +
+			"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"))
+	})
+
+	It("sends the session token of temporary credentials", func() {
+		req, err := http.NewRequest("POST", "https://ssm.eu-west-1.amazonaws.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		credentials := aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "some-session-token"}
+		aws.Sign(req, nil, "ssm", "eu-west-1", credentials, time.Now())
+
+		Expect(req.Header.Get("X-Amz-Security-Token")).To(Equal("some-session-token"))
+		Expect(req.Header.Get("Authorization")).To(ContainSubstring("SignedHeaders=host;x-amz-date;x-amz-security-token,"))
+	})
+})
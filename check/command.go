@@ -1,11 +1,11 @@
 package check
 
 import (
-	"crypto/md5"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
+	"time"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
@@ -16,17 +16,23 @@ type Command struct {
 	logger      logger.Logger
 	logFilePath string
 	flyCommand  fly.Command
+	timings     *concourse.Timings
+	warnings    *concourse.Warnings
 }
 
 func NewCommand(
 	logger logger.Logger,
 	logFilePath string,
 	flyCommand fly.Command,
+	timings *concourse.Timings,
+	warnings *concourse.Warnings,
 ) *Command {
 	return &Command{
 		logger:      logger,
 		logFilePath: logFilePath,
 		flyCommand:  flyCommand,
+		timings:     timings,
+		warnings:    warnings,
 	}
 }
 
@@ -53,64 +59,166 @@ func (c *Command) Run(input concourse.CheckRequest) (concourse.CheckResponse, er
 
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
+	var pipelineVersions []concourse.PipelineVersion
+
+	for _, target := range input.Source.AllTargets() {
+		versions, err := c.checkTarget(input.Source, target)
 		if err != nil {
 			return concourse.CheckResponse{}, err
 		}
+		pipelineVersions = append(pipelineVersions, versions...)
+	}
+
+	version := input.Source.Emitted(concourse.NewVersion(pipelineVersions))
+
+	if len(input.Version) > 0 {
+		for _, e := range version.ChangesSince(input.Version).MetadataEntries() {
+			m := e.Metadata()
+			c.logger.Infof("%s: %s\n", m.Name, m.Value)
+		}
+	}
+
+	versions := []concourse.Version{version}
+	if input.Source.VersionMode == concourse.VersionModePipeline {
+		versions = version.PerPipeline(input.Version)
+	}
+
+	out := make(concourse.CheckResponse, 0, len(versions))
+	previous := input.Version
+	for _, v := range versions {
+		if input.Source.VersionSequence {
+			v = v.WithSequence(previous, time.Now())
+		}
+		out = append(out, v)
+		previous = v
+	}
+
+	// check cannot return metadata, so the warnings are only logged.
+	for _, w := range c.warnings.Messages() {
+		c.logger.Warnf("%s\n", w)
+	}
+
+	for _, e := range c.timings.MetadataEntries() {
+		m := e.Metadata()
+		c.logger.Debugf("%s: %s\n", m.Name, m.Value)
+	}
+
+	c.logger.Debugf("Returning output: %+v\n", out)
+
+	return out, nil
+}
+
+// checkTarget returns the versions of the pipelines of each team of the
+// target included by the source.
+func (c *Command) checkTarget(source concourse.Source, target concourse.Target) ([]concourse.PipelineVersion, error) {
+	insecure, err := target.InsecureSkipVerify()
+	if err != nil {
+		return nil, err
+	}
+
+	targetTeams := target.Teams
+	if target.TeamPattern != "" {
+		targetTeams, err = fly.ExpandTeamPattern(
+			c.flyCommand,
+			target.APIEndpoint(),
+			target.Teams,
+			target.TeamPattern,
+			insecure,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	configured := make(map[string]bool)
+	for _, team := range target.Teams {
+		configured[team.Name] = true
 	}
 
 	teams := make(map[string]concourse.Team)
 
-	for _, team := range input.Source.Teams {
+	for _, team := range targetTeams {
 		teams[team.Name] = team
 	}
 
-	pipelineVersions := make(map[string]string)
+	var versions []concourse.PipelineVersion
 
 	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
+		teamLogger := c.logger.With(logger.Fields{Team: teamName})
+		teamLogger.Debugf("Performing login\n")
+		_, err := fly.LoginToTeam(
+			c.flyCommand,
+			target.APIEndpoint(),
+			team,
 			insecure,
 		)
 		if err != nil {
-			return concourse.CheckResponse{}, err
+			return nil, err
 		}
 
-		c.logger.Debugf("Login successful\n")
+		teamLogger.Debugf("Login successful\n")
 
-		pipelines, err := c.flyCommand.Pipelines()
+		stopList := c.timings.Start(concourse.PhaseList)
+		pipelines, err := c.flyCommand.Pipelines(false)
+		stopList()
 		if err != nil {
-			return concourse.CheckResponse{}, err
+			return nil, concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
 		}
-		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
+		teamLogger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
 
-		for _, pipelineName := range pipelines {
-			c.logger.Debugf("Getting pipeline: %s\n", pipelineName)
-			outBytes, err := c.flyCommand.GetPipeline(pipelineName)
-			if err != nil {
-				return concourse.CheckResponse{}, err
+		if source.ExpectPipelines && len(pipelines) == 0 && configured[teamName] {
+			return nil, fmt.Errorf(
+				"no pipelines found for team (%s) of target (%s), but expect_pipelines is set: check that the credentials are for the intended team",
+				teamName,
+				target.Target,
+			)
+		}
+
+		var included []fly.Pipeline
+		for _, pipeline := range pipelines {
+			if !source.IncludesPipeline(pipeline.Name) || !team.IncludesPipeline(pipeline.Name) {
+				teamLogger.With(logger.Fields{Pipeline: pipeline.Name}).Debugf("Pipeline not included, skipping: %s\n", pipeline.Name)
+				continue
 			}
+			included = append(included, pipeline)
+		}
 
-			version := fmt.Sprintf(
-				"%x",
-				md5.Sum(outBytes),
-			)
-			pipelineVersions[pipelineName] = version
+		if skipped := len(pipelines) - len(included); skipped > 0 {
+			c.warnings.Add("%d pipelines of team %s not included by the source, skipped", skipped, teamName)
 		}
-	}
 
-	out := concourse.CheckResponse{
-		pipelineVersions,
-	}
+		progress := logger.NewProgress(teamLogger, "fetched", teamName, len(included), logger.ProgressInterval)
 
-	c.logger.Debugf("Returning output: %+v\n", out)
+		for _, pipeline := range included {
+			pipelineLogger := teamLogger.With(logger.Fields{Pipeline: pipeline.Name})
+			if source.Quiet {
+				pipelineLogger = logger.Demote(pipelineLogger)
+			}
+			pipelineName := pipeline.Name
+
+			pipelineLogger.Debugf("Getting pipeline: %s\n", pipelineName)
+			checksum, err := fly.PipelineChecksum(c.flyCommand, source, pipeline.Ref(), c.timings)
+			if errors.Is(err, fly.ErrNotFound) {
+				pipelineLogger.Infof("Pipeline deleted since listing, skipping: %s\n", pipelineName)
+				c.warnings.Add("pipeline %s of team %s deleted since listing, skipped", pipelineName, teamName)
+				progress.Add()
+				continue
+			}
+			if err != nil {
+				return nil, concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
+			}
 
-	return out, nil
+			versions = append(versions, concourse.PipelineVersion{
+				Target:       target.Name,
+				Team:         teamName,
+				Pipeline:     pipelineName,
+				InstanceVars: pipeline.InstanceVars,
+				Checksum:     checksum,
+			})
+
+			progress.Add()
+		}
+	}
+
+	return versions, nil
 }
@@ -1,14 +1,19 @@
 package check_test
 
 import (
+	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/concourse/concourse-pipeline-resource/check"
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 	. "github.com/onsi/ginkgo"
@@ -32,15 +37,16 @@ var _ = Describe("Check", func() {
 		command      *check.Command
 
 		pipelinesErr   error
-		pipelines      []string
+		pipelines      []fly.Pipeline
 		fakeFlyCommand *flyfakes.FakeCommand
 	)
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		fakeFlyCommand.UserInfoReturns(fly.UserInfo{IsAdmin: true}, nil)
 
 		pipelinesErr = nil
-		pipelines = []string{"pipeline 1", "pipeline 2"}
+		pipelines = []fly.Pipeline{{Name: "pipeline 1"}, {Name: "pipeline 2"}}
 
 		pipelineContents = make([]string, 2)
 
@@ -56,9 +62,9 @@ pipeline2: foo
 			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", name)
 
 			switch name {
-			case pipelines[0]:
+			case pipelines[0].Name:
 				return []byte(pipelineContents[0]), nil
-			case pipelines[1]:
+			case pipelines[1].Name:
 				return []byte(pipelineContents[1]), nil
 			default:
 				Fail("Unexpected invocation of flyCommand.GetPipeline")
@@ -97,8 +103,8 @@ pipeline2: foo
 
 		expectedResponse = []concourse.Version{
 			{
-				pipelines[0]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
-				pipelines[1]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+				"main/" + pipelines[0].Name: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
+				"main/" + pipelines[1].Name: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
 			},
 		}
 
@@ -106,6 +112,8 @@ pipeline2: foo
 			ginkgoLogger,
 			logFilePath,
 			fakeFlyCommand,
+			nil,
+			nil,
 		)
 	})
 
@@ -125,11 +133,364 @@ pipeline2: foo
 		Expect(response).To(Equal(expectedResponse))
 	})
 
+	It("does not include archived pipelines", func() {
+		_, err := command.Run(checkRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFlyCommand.PipelinesArgsForCall(0)).To(BeFalse())
+	})
+
+	Context("when pipelines are excluded", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Exclude = []string{pipelines[1].Name}
+		})
+
+		It("omits them from the version without getting them", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(HaveLen(1))
+			Expect(response[0]).To(HaveKey("main/" + pipelines[0].Name))
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when pipelines are included", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Include = []string{pipelines[1].Name}
+		})
+
+		It("omits the other pipelines from the version", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(HaveLen(1))
+			Expect(response[0]).To(HaveKey("main/" + pipelines[1].Name))
+		})
+	})
+
+	Context("when the pipelines of a team are given", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Teams[0].Pipelines = []string{pipelines[0].Name}
+		})
+
+		It("omits the other pipelines of the team from the version", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(HaveLen(1))
+			Expect(response[0]).To(HaveKey("main/" + pipelines[0].Name))
+		})
+	})
+
+	Context("when a team has no pipelines", func() {
+		BeforeEach(func() {
+			pipelines = []fly.Pipeline{}
+		})
+
+		It("returns an empty version", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{{}}))
+		})
+
+		Context("when pipelines are expected", func() {
+			BeforeEach(func() {
+				checkRequest.Source.ExpectPipelines = true
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(checkRequest)
+				Expect(err).To(MatchError(ContainSubstring("no pipelines found for team (main)")))
+			})
+		})
+	})
+
+	Context("when a team pattern is configured", func() {
+		BeforeEach(func() {
+			checkRequest.Source.TeamPattern = "^product-"
+			fakeFlyCommand.TeamsReturns([]string{"main", "product-a", "other"}, nil)
+		})
+
+		It("also checks the pipelines of matching teams", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			var teamNames []string
+			for i := 0; i < fakeFlyCommand.LoginCallCount(); i++ {
+				_, teamName, _, _, _ := fakeFlyCommand.LoginArgsForCall(i)
+				teamNames = append(teamNames, teamName)
+			}
+
+			Expect(teamNames).To(ContainElement("product-a"))
+			Expect(teamNames).NotTo(ContainElement("other"))
+			Expect(fakeFlyCommand.PipelinesCallCount()).To(Equal(2))
+		})
+
+		It("versions pipelines of the same name in each team separately", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(HaveLen(4))
+			Expect(response[0]).To(HaveKey("main/" + pipelines[0].Name))
+			Expect(response[0]).To(HaveKey("product-a/" + pipelines[0].Name))
+		})
+	})
+
+	Context("when a pipeline is instanced", func() {
+		BeforeEach(func() {
+			pipelines[0].InstanceVars = map[string]interface{}{"branch": "feature"}
+			fakeFlyCommand.GetPipelineReturns([]byte(pipelineContents[0]), nil)
+			fakeFlyCommand.GetPipelineStub = nil
+		})
+
+		It("gets and versions the instance", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.GetPipelineArgsForCall(0)).To(Equal(`pipeline 1/branch:"feature"`))
+			Expect(response[0]).To(HaveKey(`main/pipeline 1/branch:"feature"`))
+		})
+	})
+
+	Context("when multiple targets are configured", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Teams = nil
+			checkRequest.Source.Targets = []concourse.Target{
+				{
+					Name:   "eu",
+					Target: "some eu target",
+					Teams:  []concourse.Team{{Name: "main"}},
+				},
+				{
+					Name:     "us",
+					Target:   "some us target",
+					Insecure: "true",
+					Teams:    []concourse.Team{{Name: "other-team"}},
+				},
+			}
+		})
+
+		It("logs in to each target", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(2))
+
+			url, teamName, _, _, insecure := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(url).To(Equal("some eu target"))
+			Expect(teamName).To(Equal("main"))
+			Expect(insecure).To(BeFalse())
+
+			url, teamName, _, _, insecure = fakeFlyCommand.LoginArgsForCall(1)
+			Expect(url).To(Equal("some us target"))
+			Expect(teamName).To(Equal("other-team"))
+			Expect(insecure).To(BeTrue())
+		})
+
+		It("returns the versions of the pipelines of all targets, prefixed with the target name", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(HaveLen(1))
+			Expect(response[0]).To(HaveLen(4))
+			Expect(response[0]["eu/main/"+pipelines[0].Name]).To(Equal(expectedResponse[0]["main/"+pipelines[0].Name]))
+			Expect(response[0]["us/other-team/"+pipelines[1].Name]).To(Equal(expectedResponse[0]["main/"+pipelines[1].Name]))
+		})
+	})
+
+	Context("when the version mode is digest", func() {
+		BeforeEach(func() {
+			checkRequest.Source.VersionMode = "digest"
+		})
+
+		It("rolls the versions of the pipelines up into a digest", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{expectedResponse[0].Digest()}))
+			Expect(response[0]["count"]).To(Equal("2"))
+		})
+	})
+
+	Context("when the version mode is pipeline", func() {
+		BeforeEach(func() {
+			checkRequest.Source.VersionMode = "pipeline"
+		})
+
+		It("returns a version for each pipeline", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(HaveLen(2))
+			Expect(response[0][concourse.ChangedKey]).To(Equal("main/" + pipelines[0].Name))
+			Expect(response[1][concourse.ChangedKey]).To(Equal("main/" + pipelines[1].Name))
+			Expect(response[0]).To(Equal(concourse.Version{
+				concourse.ChangedKey:        "main/" + pipelines[0].Name,
+				"main/" + pipelines[0].Name: expectedResponse[0]["main/"+pipelines[0].Name],
+			}))
+			Expect(response[1]).To(HaveKeyWithValue("main/"+pipelines[0].Name, expectedResponse[0]["main/"+pipelines[0].Name]))
+		})
+
+		It("returns a version for each pipeline changed since the previous version", func() {
+			checkRequest.Version = concourse.Version{concourse.ChangedKey: "main/" + pipelines[1].Name}
+			for k, v := range expectedResponse[0] {
+				checkRequest.Version[k] = v
+			}
+			checkRequest.Version["main/"+pipelines[0].Name] = "some-old-checksum"
+
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(HaveLen(1))
+			Expect(response[0][concourse.ChangedKey]).To(Equal("main/" + pipelines[0].Name))
+		})
+
+		It("returns the previous version if no pipeline has changed", func() {
+			checkRequest.Version = concourse.Version{concourse.ChangedKey: "main/" + pipelines[1].Name}
+			for k, v := range expectedResponse[0] {
+				checkRequest.Version[k] = v
+			}
+
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{checkRequest.Version}))
+		})
+
+		Context("when version_sequence is set", func() {
+			BeforeEach(func() {
+				checkRequest.Source.VersionSequence = true
+			})
+
+			It("gives each version a greater sequence", func() {
+				response, err := command.Run(checkRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				first, err := strconv.ParseInt(response[0][concourse.SequenceKey], 10, 64)
+				Expect(err).NotTo(HaveOccurred())
+				second, err := strconv.ParseInt(response[1][concourse.SequenceKey], 10, 64)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(second).To(BeNumerically(">", first))
+			})
+		})
+	})
+
+	Context("when version_sequence is set", func() {
+		BeforeEach(func() {
+			checkRequest.Source.VersionSequence = true
+		})
+
+		It("adds a sequence to the version", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(HaveLen(3))
+			Expect(response[0]).To(HaveKey(concourse.SequenceKey))
+		})
+
+		It("keeps the sequence of the previous version if no pipeline has changed", func() {
+			checkRequest.Version = concourse.Version{concourse.SequenceKey: "42"}
+			for k, v := range expectedResponse[0] {
+				checkRequest.Version[k] = v
+			}
+
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]).To(Equal(checkRequest.Version))
+		})
+	})
+
+	Context("when the checksum algorithm is configured", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Checksum = "sha256"
+		})
+
+		It("returns the checksums computed with the algorithm", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response[0]["main/"+pipelines[0].Name]).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte(pipelineContents[0])))))
+		})
+	})
+
+	Context("when the version scheme is config_version", func() {
+		BeforeEach(func() {
+			checkRequest.Source.VersionScheme = "config_version"
+			fakeFlyCommand.GetPipelineConfigStub = func(ref fly.PipelineRef) (fly.PipelineConfig, string, error) {
+				if ref.Name == pipelines[0].Name {
+					return fly.PipelineConfig{}, "3", nil
+				}
+				return fly.PipelineConfig{}, "7", nil
+			}
+		})
+
+		It("returns the config versions of the pipelines", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(concourse.CheckResponse{{
+				"main/" + pipelines[0].Name: "3",
+				"main/" + pipelines[1].Name: "7",
+			}}))
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when logging as JSON", func() {
+		var logged *bytes.Buffer
+
+		BeforeEach(func() {
+			logged = &bytes.Buffer{}
+			command = check.NewCommand(
+				logger.NewJSONLogger(logged, "check"),
+				logFilePath,
+				fakeFlyCommand,
+				nil,
+				nil,
+			)
+		})
+
+		It("logs the team and pipeline each line is about", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logged.String()).To(ContainSubstring(`"message":"Login successful","team":"main"}`))
+			Expect(logged.String()).To(ContainSubstring(`"message":"Getting pipeline: pipeline 1","team":"main","pipeline":"pipeline 1"}`))
+		})
+
+		It("logs the changes since the previous version", func() {
+			checkRequest.Version = concourse.Version{"main/" + pipelines[0].Name: "some-old-checksum"}
+
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logged.String()).To(ContainSubstring(`"message":"pipelines added: main/pipeline 2"`))
+			Expect(logged.String()).To(ContainSubstring(`"message":"pipelines changed: main/pipeline 1"`))
+		})
+	})
+
+	Context("when an API URL is configured", func() {
+		BeforeEach(func() {
+			checkRequest.Source.APIURL = "http://web.internal:8080"
+		})
+
+		It("logs in at the API URL", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			url, _, _, _, _ := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(url).To(Equal("http://web.internal:8080"))
+		})
+	})
+
 	Context("when the most recent version is provided", func() {
 		BeforeEach(func() {
 			checkRequest.Version = concourse.Version{
-				pipelines[0]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
-				pipelines[1]: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+				pipelines[0].Name: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
+				pipelines[1].Name: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
 			}
 		})
 
@@ -202,6 +563,29 @@ pipeline2: foo
 		})
 	})
 
+	Context("when client credentials are provided for a team", func() {
+		BeforeEach(func() {
+			checkRequest.Source.Teams[0].Username = ""
+			checkRequest.Source.Teams[0].Password = ""
+			checkRequest.Source.Teams[0].ClientID = "some client id"
+			checkRequest.Source.Teams[0].ClientSecret = "some client secret"
+		})
+
+		It("logs in with the client credentials", func() {
+			_, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.LoginWithClientCredentialsCallCount()).To(Equal(1))
+
+			url, teamName, clientID, clientSecret, _ := fakeFlyCommand.LoginWithClientCredentialsArgsForCall(0)
+			Expect(url).To(Equal(target))
+			Expect(teamName).To(Equal("main"))
+			Expect(clientID).To(Equal("some client id"))
+			Expect(clientSecret).To(Equal("some client secret"))
+		})
+	})
+
 	Context("when insecure fails to parse into a boolean", func() {
 		BeforeEach(func() {
 			checkRequest.Source.Insecure = "unparsable"
@@ -240,7 +624,32 @@ pipeline2: foo
 			_, err := command.Run(checkRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err).To(Equal(pipelinesErr))
+			Expect(errors.Is(err, pipelinesErr)).To(BeTrue())
+			Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodePipelineFetchFailed))
+		})
+	})
+
+	Context("when a pipeline is deleted after being listed", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+				if name == pipelines[1].Name {
+					return nil, &fly.Error{Kind: fly.ErrNotFound, Err: fmt.Errorf("pipeline not found")}
+				}
+				return []byte(pipelineContents[0]), nil
+			}
+
+			expectedResponse = []concourse.Version{
+				{
+					"main/" + pipelines[0].Name: fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
+				},
+			}
+		})
+
+		It("omits the pipeline from the version", func() {
+			response, err := command.Run(checkRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response).To(Equal(expectedResponse))
 		})
 	})
 
@@ -259,7 +668,8 @@ pipeline2: foo
 			_, err := command.Run(checkRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err).To(Equal(expectedErr))
+			Expect(errors.Is(err, expectedErr)).To(BeTrue())
+			Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodePipelineFetchFailed))
 		})
 	})
 })
@@ -1,28 +1,38 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/in"
-	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/internal/resource"
+	"github.com/concourse/concourse-pipeline-resource/kubernetes"
+	"github.com/concourse/concourse-pipeline-resource/tracing"
 	"github.com/concourse/concourse-pipeline-resource/validator"
-	"github.com/robdimsdale/sanitizer"
 )
 
 const (
 	flyBinaryName        = "fly"
 	atcExternalURLEnvKey = "ATC_EXTERNAL_URL"
+	buildTeamNameEnvKey  = "BUILD_TEAM_NAME"
+	traceparentEnvKey    = "TRACEPARENT"
 )
 
 var (
-	l logger.Logger
+	l *resource.Logger
+
+	// version is set at build time with -ldflags "-X main.version=...".
+	version = "dev"
 )
 
 func main() {
@@ -53,10 +63,98 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	sanitized := concourse.SanitizedSource(input.Source)
-	sanitizer := sanitizer.NewSanitizer(sanitized, logFile)
+	// The log file of the step is opened before anything is logged, so that
+	// it holds the whole log, including why the input was rejected.
+	var logSink io.Writer = logFile
+	if input.Params.LogFile != "" {
+		err = validator.ValidateLogFile(input.Params.LogFile)
+		if err != nil {
+			fmt.Fprintf(logFile, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+
+		outputLogFile, err := resource.CreateStepFile(downloadDir, input.Params.LogFile)
+		if err != nil {
+			fmt.Fprintf(logFile, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+		defer outputLogFile.Close()
+
+		logSink = io.MultiWriter(logFile, outputLogFile)
+	}
+
+	var migrated []string
+	input.Source, migrated, err = input.Source.Migrated()
+	if err != nil {
+		fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+		log.Fatalln(err)
+	}
+	warnings := concourse.NewWarnings()
+	for _, m := range migrated {
+		fmt.Fprintf(os.Stderr, "WARNING: %s, set schema: %s to use the new fields only\n", m, concourse.SchemaV2)
+		warnings.Add("%s, set schema: %s to use the new fields only", m, concourse.SchemaV2)
+	}
+
+	if input.Source.Flyrc != "" {
+		input.Source, err = fly.WithFlyrcTarget(input.Source)
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+	}
+
+	input.Source = input.Source.WithSelfTarget(os.Getenv(atcExternalURLEnvKey), os.Getenv(buildTeamNameEnvKey))
+
+	var defaultTeam bool
+	input.Source, defaultTeam = input.Source.WithDefaultTeam()
+
+	input.Source = input.Source.WithTeamDefaults()
+
+	input.Source = input.Source.WithSkipSSLValidation()
+
+	if input.Source.ExpandEnv {
+		input.Source = input.Source.WithEnvExpanded()
+	}
+
+	input.Source, err = input.Source.WithCredentialFiles()
+	if err != nil {
+		fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+		log.Fatalln(err)
+	}
+
+	if input.Source.UsesKubernetesSecrets() {
+		client, err := kubernetes.NewInClusterClient()
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+
+		input.Source, err = input.Source.WithKubernetesSecrets(client.Secret)
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+	}
+
+	l = resource.NewLogger("in", input.Source, concourse.SanitizedSource(input.Source), logSink)
+
+	err = run(input, inDir, downloadDir, defaultTeam, warnings)
+	if err != nil {
+		code := concourse.ErrorCodeOf(err)
+		l.Errorf("Exiting with error (%s): %v\n", code, err)
+		fmt.Fprintf(os.Stderr, "error_code: %s\n", code)
+		l.Fatal(err)
+	}
+
+	l.Flush()
+}
 
-	l = logger.NewLogger(sanitizer)
+// run runs the get once the logger is set up, returning the error it failed
+// with rather than exiting, so that its deferred cleanup runs first.
+func run(input concourse.InRequest, inDir string, downloadDir string, defaultTeam bool, warnings *concourse.Warnings) error {
+	if defaultTeam {
+		l.Infof("No teams provided, defaulting to team: %s\n", concourse.DefaultTeamName)
+	}
 
 	flyBinaryPath := filepath.Join(inDir, flyBinaryName)
 
@@ -64,25 +162,104 @@ func main() {
 		input.Source.Target = os.Getenv(atcExternalURLEnvKey)
 	}
 
-	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath)
+	err := validator.ValidateIn(input)
+	if err != nil {
+		return err
+	}
+
+	if input.Source.WorkDir != "" {
+		err = resource.PrepareWorkDir(input.Source.WorkDir)
+		if err != nil {
+			return err
+		}
+
+		flyBinaryPath, err = fly.InstallBinary(flyBinaryPath, input.Source.WorkDir)
+		if err != nil {
+			return err
+		}
+
+		if input.Source.FlyHome == "" {
+			input.Source.FlyHome = input.Source.WorkDir
+		}
+	}
 
-	err = validator.ValidateIn(input)
+	requestTimeout, err := input.Source.RequestTimeoutDuration()
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
-		log.Fatalln(err)
+		return err
 	}
 
-	response, err := in.NewCommand(l, flyCommand, downloadDir).Run(input)
+	retryPolicy, err := input.Source.RetryPolicy()
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
-		log.Fatalln(err)
+		return err
 	}
 
-	l.Debugf("Returning output: %+v\n", response)
+	connectTimeout, err := input.Source.ConnectTimeoutDuration()
+	if err != nil {
+		return err
+	}
 
-	err = json.NewEncoder(os.Stdout).Encode(response)
+	idleTimeout, err := input.Source.IdleTimeoutDuration()
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
-		log.Fatalln(err)
+		return err
 	}
+
+	flyHome, err := fly.NewHome(input.Source.FlyHome)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(flyHome)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		l.Warnf("Received %v, cancelling\n", sig)
+		cancel()
+	}()
+
+	timings := concourse.NewTimings()
+
+	var tracer *tracing.Tracer
+	if endpoint, ok := input.Source.TracesEndpoint(); ok {
+		tracer = tracing.NewTracer("in", endpoint, input.Source.TracingHeaders(), os.Getenv(traceparentEnvKey), version)
+	}
+
+	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath, fly.Options{
+		Verbose:             input.Source.Verbose || input.Source.Debug,
+		Home:                flyHome,
+		SHA256:              input.Source.FlySHA256,
+		Context:             ctx,
+		RequestTimeout:      requestTimeout,
+		ConnectTimeout:      connectTimeout,
+		IdleTimeout:         idleTimeout,
+		Retries:             retryPolicy.Retries,
+		RetryBackoff:        retryPolicy.Backoff,
+		RetryMaxElapsedTime: retryPolicy.MaxElapsedTime,
+		RequestsPerSecond:   input.Source.RequestsPerSecond,
+		Version:             version,
+		CACert:              input.Source.CACert,
+		ClientCert:          input.Source.ClientCert,
+		ClientKey:           input.Source.ClientKey,
+		HTTPProxy:           input.Source.HTTPProxy,
+		HTTPSProxy:          input.Source.HTTPSProxy,
+		NoProxy:             input.Source.NoProxy,
+		Timings:             timings,
+		LogRequests:         input.Source.LogRequests || input.Source.Debug,
+		LogRequestBodies:    input.Source.LogRequestBodies,
+		Tracer:              tracer,
+		SecretVars:          input.Source.SecretVars,
+	})
+
+	response, err := in.NewCommand(l, flyCommand, downloadDir, version, timings, warnings).Run(input)
+	resource.ExportTraces(l, tracer, err)
+	if err != nil {
+		return err
+	}
+
+	l.Debugf("Returning output: %+v\n", response)
+
+	return json.NewEncoder(os.Stdout).Encode(response)
 }
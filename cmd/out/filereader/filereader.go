@@ -31,3 +31,22 @@ func PipelinesFromFile(pipelinesFilename string, sourcesDir string) ([]concourse
 
 	return []concourse.Pipeline{}, nil
 }
+
+// WithPipelinesFile returns the request with the pipelines read from its
+// pipelines file, if any, in place of the file, so that their vars are read
+// from AWS and sanitized as those of the pipelines param are.
+func WithPipelinesFile(input concourse.OutRequest, sourcesDir string) (concourse.OutRequest, error) {
+	if input.Params.PipelinesFile == "" {
+		return input, nil
+	}
+
+	pipelines, err := PipelinesFromFile(input.Params.PipelinesFile, sourcesDir)
+	if err != nil {
+		return concourse.OutRequest{}, err
+	}
+
+	input.Params.PipelinesFile = ""
+	input.Params.Pipelines = pipelines
+
+	return input, nil
+}
@@ -127,4 +127,71 @@ var _ = Describe("Filereader", func() {
 			Expect(returnedPipelines).To(BeEmpty())
 		})
 	})
+
+	Describe("WithPipelinesFile", func() {
+		var input concourse.OutRequest
+
+		BeforeEach(func() {
+			pipelines[1].Vars = map[string]interface{}{"deploy-key": "ssm:/ci/deploy-key"}
+
+			b, err := yaml.Marshal(concourse.OutParams{Pipelines: pipelines})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(sourcesDir, pipelinesFilename), b, os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			input = concourse.OutRequest{
+				Source: concourse.Source{AWS: &concourse.AWS{}},
+				Params: concourse.OutParams{PipelinesFile: pipelinesFilename},
+			}
+		})
+
+		It("replaces the pipelines file with its pipelines", func() {
+			returned, err := filereader.WithPipelinesFile(input, sourcesDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(returned.Params.PipelinesFile).To(BeEmpty())
+			Expect(returned.Params.Pipelines).To(Equal(pipelines))
+		})
+
+		It("lets the vars of its pipelines be read from AWS and sanitized", func() {
+			returned, err := filereader.WithPipelinesFile(input, sourcesDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(returned.UsesAWSVars()).To(BeTrue())
+
+			returned, err = returned.WithAWSVars(func(name string) (string, error) {
+				Expect(name).To(Equal("/ci/deploy-key"))
+				return "some-deploy-key", nil
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(returned.Params.Pipelines[1].Vars).To(Equal(map[string]interface{}{"deploy-key": "some-deploy-key"}))
+			Expect(concourse.SanitizedOutRequest(returned)).To(HaveKeyWithValue("some-deploy-key", "***REDACTED-VAR-deploy-key***"))
+		})
+
+		Context("when there is no pipelines file", func() {
+			BeforeEach(func() {
+				input.Params.PipelinesFile = ""
+				input.Params.Pipelines = pipelines
+			})
+
+			It("returns the request as it is", func() {
+				returned, err := filereader.WithPipelinesFile(input, sourcesDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(returned).To(Equal(input))
+			})
+		})
+
+		Context("when the pipelines file cannot be read", func() {
+			BeforeEach(func() {
+				input.Params.PipelinesFile = "pipelines-never-written.yml"
+			})
+
+			It("returns error", func() {
+				_, err := filereader.WithPipelinesFile(input, sourcesDir)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
 })
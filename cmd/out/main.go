@@ -1,29 +1,42 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
+	"github.com/concourse/concourse-pipeline-resource/aws"
 	"github.com/concourse/concourse-pipeline-resource/cmd/out/filereader"
 	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/fly"
-	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/internal/resource"
+	"github.com/concourse/concourse-pipeline-resource/kubernetes"
 	"github.com/concourse/concourse-pipeline-resource/out"
+	"github.com/concourse/concourse-pipeline-resource/tracing"
 	"github.com/concourse/concourse-pipeline-resource/validator"
-	"github.com/robdimsdale/sanitizer"
 )
 
 const (
-	flyBinaryName        = "fly"
-	atcExternalURLEnvKey = "ATC_EXTERNAL_URL"
+	flyBinaryName          = "fly"
+	atcExternalURLEnvKey   = "ATC_EXTERNAL_URL"
+	buildTeamNameEnvKey    = "BUILD_TEAM_NAME"
+	awsRegionEnvKey        = "AWS_REGION"
+	awsDefaultRegionEnvKey = "AWS_DEFAULT_REGION"
+	traceparentEnvKey      = "TRACEPARENT"
 )
 
 var (
-	l logger.Logger
+	l *resource.Logger
+
+	// version is set at build time with -ldflags "-X main.version=...".
+	version = "dev"
 )
 
 func main() {
@@ -54,54 +67,260 @@ func main() {
 		log.Fatalln(err)
 	}
 
-	sanitized := concourse.SanitizedSource(input.Source)
-	sanitizer := sanitizer.NewSanitizer(sanitized, logFile)
+	// The log file of the step is opened before anything is logged, so that
+	// it holds the whole log, including why the input was rejected.
+	var logSink io.Writer = logFile
+	if input.Params.LogFile != "" {
+		err = validator.ValidateLogFile(input.Params.LogFile)
+		if err != nil {
+			fmt.Fprintf(logFile, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
 
-	l = logger.NewLogger(sanitizer)
+		outputLogFile, err := resource.CreateStepFile(sourcesDir, input.Params.LogFile)
+		if err != nil {
+			fmt.Fprintf(logFile, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+		defer outputLogFile.Close()
 
-	flyBinaryPath := filepath.Join(outDir, flyBinaryName)
+		logSink = io.MultiWriter(logFile, outputLogFile)
+	}
 
-	if input.Source.Target == "" {
-		input.Source.Target = os.Getenv(atcExternalURLEnvKey)
+	var migrated []string
+	input.Source, migrated, err = input.Source.Migrated()
+	if err != nil {
+		fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+		log.Fatalln(err)
+	}
+	warnings := concourse.NewWarnings()
+	for _, m := range migrated {
+		fmt.Fprintf(os.Stderr, "WARNING: %s, set schema: %s to use the new fields only\n", m, concourse.SchemaV2)
+		warnings.Add("%s, set schema: %s to use the new fields only", m, concourse.SchemaV2)
+	}
+
+	if input.Source.Flyrc != "" {
+		input.Source, err = fly.WithFlyrcTarget(input.Source)
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
 	}
 
-	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath)
+	input.Source = input.Source.WithSelfTarget(os.Getenv(atcExternalURLEnvKey), os.Getenv(buildTeamNameEnvKey))
+
+	var defaultTeam bool
+	input.Source, defaultTeam = input.Source.WithDefaultTeam()
+
+	input.Source = input.Source.WithTeamDefaults()
+
+	input.Source = input.Source.WithSkipSSLValidation()
+
+	if input.Source.ExpandEnv {
+		input.Source = input.Source.WithEnvExpanded()
+	}
 
-	err = validator.ValidateOut(input)
+	input.Source, err = input.Source.WithCredentialFiles()
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
+		fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
 		log.Fatalln(err)
 	}
 
-	if input.Params.PipelinesFile != "" {
-		pipelinesFromFile, err := filereader.PipelinesFromFile(input.Params.PipelinesFile, sourcesDir)
+	if input.Source.UsesKubernetesSecrets() {
+		client, err := kubernetes.NewInClusterClient()
 		if err != nil {
-			l.Debugf("Exiting with error: %v\n", err)
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
 			log.Fatalln(err)
 		}
 
-		input.Params.PipelinesFile = ""
-		input.Params.Pipelines = pipelinesFromFile
+		input.Source, err = input.Source.WithKubernetesSecrets(client.Secret)
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
 	}
 
-	// Validate contents of pipelines file
-	err = validator.ValidateOut(input)
+	// Read before the AWS vars and the logger, so that the vars of the
+	// pipelines of the file are read from AWS and sanitized.
+	input, err = filereader.WithPipelinesFile(input, sourcesDir)
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
+		fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
 		log.Fatalln(err)
 	}
 
-	response, err := out.NewCommand(l, flyCommand, sourcesDir).Run(input)
+	if input.UsesAWSVars() {
+		input, err = withAWSVars(input)
+		if err != nil {
+			fmt.Fprintf(logSink, "Exiting with error: %v\n", err)
+			log.Fatalln(err)
+		}
+	}
+
+	l = resource.NewLogger("out", input.Source, concourse.SanitizedOutRequest(input), logSink)
+
+	err = run(input, outDir, sourcesDir, defaultTeam, warnings)
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
-		log.Fatalln(err)
+		code := concourse.ErrorCodeOf(err)
+		l.Errorf("Exiting with error (%s): %v\n", code, err)
+		fmt.Fprintf(os.Stderr, "error_code: %s\n", code)
+		l.Fatal(err)
+	}
+
+	l.Flush()
+}
+
+// run runs the put once the logger is set up, returning the error it failed
+// with rather than exiting, so that its deferred cleanup runs first.
+func run(input concourse.OutRequest, outDir string, sourcesDir string, defaultTeam bool, warnings *concourse.Warnings) error {
+	if defaultTeam {
+		l.Infof("No teams provided, defaulting to team: %s\n", concourse.DefaultTeamName)
+	}
+
+	flyBinaryPath := filepath.Join(outDir, flyBinaryName)
+
+	if input.Source.Target == "" {
+		input.Source.Target = os.Getenv(atcExternalURLEnvKey)
+	}
+
+	err := validator.ValidateOut(input)
+	if err != nil {
+		return err
+	}
+
+	if input.Source.WorkDir != "" {
+		err = resource.PrepareWorkDir(input.Source.WorkDir)
+		if err != nil {
+			return err
+		}
+
+		flyBinaryPath, err = fly.InstallBinary(flyBinaryPath, input.Source.WorkDir)
+		if err != nil {
+			return err
+		}
+
+		if input.Source.FlyHome == "" {
+			input.Source.FlyHome = input.Source.WorkDir
+		}
+	}
+
+	requestTimeout, err := input.Source.RequestTimeoutDuration()
+	if err != nil {
+		return err
+	}
+
+	retryPolicy, err := input.Source.RetryPolicy()
+	if err != nil {
+		return err
+	}
+
+	connectTimeout, err := input.Source.ConnectTimeoutDuration()
+	if err != nil {
+		return err
+	}
+
+	idleTimeout, err := input.Source.IdleTimeoutDuration()
+	if err != nil {
+		return err
+	}
+
+	flyHome, err := fly.NewHome(input.Source.FlyHome)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(flyHome)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-signals
+		l.Warnf("Received %v, cancelling\n", sig)
+		cancel()
+	}()
+
+	timings := concourse.NewTimings()
+
+	var tracer *tracing.Tracer
+	if endpoint, ok := input.Source.TracesEndpoint(); ok {
+		tracer = tracing.NewTracer("out", endpoint, input.Source.TracingHeaders(), os.Getenv(traceparentEnvKey), version)
+	}
+
+	flyCommand := fly.NewCommand(input.Source.Target, l, flyBinaryPath, fly.Options{
+		Verbose:             input.Source.Verbose || input.Source.Debug,
+		Home:                flyHome,
+		SHA256:              input.Source.FlySHA256,
+		Context:             ctx,
+		RequestTimeout:      requestTimeout,
+		ConnectTimeout:      connectTimeout,
+		IdleTimeout:         idleTimeout,
+		Retries:             retryPolicy.Retries,
+		RetryBackoff:        retryPolicy.Backoff,
+		RetryMaxElapsedTime: retryPolicy.MaxElapsedTime,
+		RequestsPerSecond:   input.Source.RequestsPerSecond,
+		Version:             version,
+		CACert:              input.Source.CACert,
+		ClientCert:          input.Source.ClientCert,
+		ClientKey:           input.Source.ClientKey,
+		HTTPProxy:           input.Source.HTTPProxy,
+		HTTPSProxy:          input.Source.HTTPSProxy,
+		NoProxy:             input.Source.NoProxy,
+		Timings:             timings,
+		LogRequests:         input.Source.LogRequests || input.Source.Debug,
+		LogRequestBodies:    input.Source.LogRequestBodies,
+		Tracer:              tracer,
+		SecretVars:          input.Source.SecretVars,
+	})
+
+	// Left nil unless set, as the entries are also logged
+	var audit io.Writer
+	if input.Params.AuditFile != "" {
+		auditFile, err := resource.CreateStepFile(sourcesDir, input.Params.AuditFile)
+		if err != nil {
+			return err
+		}
+		defer auditFile.Close()
+
+		audit = auditFile
+	}
+
+	response, err := out.NewCommand(l, flyCommand, sourcesDir, version, timings, warnings, audit).Run(input)
+	resource.ExportTraces(l, tracer, err)
+	if err != nil {
+		return err
 	}
 
 	l.Debugf("Returning output: %+v\n", response)
 
-	err = json.NewEncoder(os.Stdout).Encode(response)
+	return json.NewEncoder(os.Stdout).Encode(response)
+}
+
+// withAWSVars returns the request with the vars which refer to AWS read
+// from it, in the region of the source or else that of the environment.
+func withAWSVars(input concourse.OutRequest) (concourse.OutRequest, error) {
+	config := input.Source.AWS
+
+	region := config.Region
+	if region == "" {
+		region = os.Getenv(awsRegionEnvKey)
+	}
+	if region == "" {
+		region = os.Getenv(awsDefaultRegionEnvKey)
+	}
+
+	client, err := aws.NewClient(aws.Config{
+		Region: region,
+		Credentials: aws.Credentials{
+			AccessKeyID:     config.AccessKeyID,
+			SecretAccessKey: config.SecretAccessKey,
+			SessionToken:    config.SessionToken,
+		},
+		RoleARN: config.RoleARN,
+	})
 	if err != nil {
-		l.Debugf("Exiting with error: %v\n", err)
-		log.Fatalln(err)
+		return concourse.OutRequest{}, err
 	}
+
+	return input.WithAWSVars(client.Parameter, client.SecretValue)
 }
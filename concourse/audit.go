@@ -0,0 +1,24 @@
+package concourse
+
+import "time"
+
+// Actions recorded in the audit log of out.
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionExpose  = "expose"
+	AuditActionUnpause = "unpause"
+)
+
+// AuditEntry records an action which changed a pipeline, for change
+// management. Checksum is that of the config of the pipeline after the
+// action, and PreviousChecksum that before it was updated.
+type AuditEntry struct {
+	Time             time.Time `json:"time"`
+	Action           string    `json:"action"`
+	Target           string    `json:"target,omitempty"`
+	Team             string    `json:"team"`
+	Pipeline         string    `json:"pipeline"`
+	Checksum         string    `json:"checksum,omitempty"`
+	PreviousChecksum string    `json:"previous_checksum,omitempty"`
+}
@@ -0,0 +1,175 @@
+package concourse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Prefixes of the values of vars which refer to a parameter of SSM Parameter
+// Store or a secret of Secrets Manager.
+const (
+	AWSParameterVarPrefix = "ssm:"
+	AWSSecretVarPrefix    = "secretsmanager:"
+)
+
+// AWS configures how vars are read from AWS. Without keys, those in the
+// environment, or of the ECS task or EC2 instance, are used.
+type AWS struct {
+	Region          string `json:"region"`
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	RoleARN         string `json:"role_arn"`
+}
+
+// UsesAWSVars tells whether AWS is configured and any var of the teams of
+// the source, or of the pipelines put, refers to a parameter or secret.
+func (r OutRequest) UsesAWSVars() bool {
+	if r.Source.AWS == nil {
+		return false
+	}
+
+	for _, vars := range r.allVars() {
+		for _, v := range vars {
+			if _, _, ok := awsVarRef(v); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WithAWSVars returns a copy of the request in which vars of teams and
+// pipelines whose values are of the form ssm:<parameter name> or
+// secretsmanager:<secret name or ARN> are replaced with the value read with
+// readParameter or readSecret. The names of the vars replaced are added to
+// SecretVars, so that their values are redacted from the log.
+func (r OutRequest) WithAWSVars(readParameter func(name string) (string, error), readSecret func(id string) (string, error)) (OutRequest, error) {
+	read := make(map[string]string)
+	secretVars := make(map[string]bool)
+
+	resolve := func(vars map[string]interface{}) (map[string]interface{}, error) {
+		if vars == nil {
+			return nil, nil
+		}
+
+		resolved := make(map[string]interface{}, len(vars))
+		for name, v := range vars {
+			prefix, id, ok := awsVarRef(v)
+			if !ok {
+				resolved[name] = v
+				continue
+			}
+
+			value, found := read[prefix+id]
+			if !found {
+				var err error
+				if prefix == AWSParameterVarPrefix {
+					value, err = readParameter(id)
+				} else {
+					value, err = readSecret(id)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("failed to read var %s: %v", name, err)
+				}
+				read[prefix+id] = value
+			}
+
+			resolved[name] = value
+			secretVars[name] = true
+		}
+
+		return resolved, nil
+	}
+
+	resolveTeams := func(teams []Team) ([]Team, error) {
+		if teams == nil {
+			return nil, nil
+		}
+
+		resolved := make([]Team, len(teams))
+		for i, t := range teams {
+			var err error
+			t.Vars, err = resolve(t.Vars)
+			if err != nil {
+				return nil, fmt.Errorf("team %s: %v", t.Name, err)
+			}
+			resolved[i] = t
+		}
+
+		return resolved, nil
+	}
+
+	var err error
+
+	r.Source.Teams, err = resolveTeams(r.Source.Teams)
+	if err != nil {
+		return OutRequest{}, err
+	}
+
+	if r.Source.Targets != nil {
+		targets := make([]Target, len(r.Source.Targets))
+		for i, t := range r.Source.Targets {
+			t.Teams, err = resolveTeams(t.Teams)
+			if err != nil {
+				return OutRequest{}, fmt.Errorf("target %s: %v", t.Name, err)
+			}
+			targets[i] = t
+		}
+		r.Source.Targets = targets
+	}
+
+	if r.Params.Pipelines != nil {
+		pipelines := make([]Pipeline, len(r.Params.Pipelines))
+		for i, p := range r.Params.Pipelines {
+			p.Vars, err = resolve(p.Vars)
+			if err != nil {
+				return OutRequest{}, fmt.Errorf("pipeline %s: %v", p.Name, err)
+			}
+			pipelines[i] = p
+		}
+		r.Params.Pipelines = pipelines
+	}
+
+	names := make([]string, 0, len(secretVars))
+	for name := range secretVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	r.Source.SecretVars = append(append([]string{}, r.Source.SecretVars...), names...)
+
+	return r, nil
+}
+
+func (r OutRequest) allVars() []map[string]interface{} {
+	var all []map[string]interface{}
+	for _, t := range r.Source.AllTargets() {
+		for _, team := range t.Teams {
+			all = append(all, team.Vars)
+		}
+	}
+	for _, p := range r.Params.Pipelines {
+		all = append(all, p.Vars)
+	}
+
+	return all
+}
+
+// awsVarRef returns the prefix and the name or ID of the parameter or secret
+// which the value of a var refers to, if it does.
+func awsVarRef(v interface{}) (string, string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", "", false
+	}
+
+	for _, prefix := range []string{AWSParameterVarPrefix, AWSSecretVarPrefix} {
+		if strings.HasPrefix(s, prefix) && len(s) > len(prefix) {
+			return prefix, strings.TrimPrefix(s, prefix), true
+		}
+	}
+
+	return "", "", false
+}
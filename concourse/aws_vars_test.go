@@ -0,0 +1,88 @@
+package concourse_test
+
+import (
+	"errors"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithAWSVars", func() {
+	var (
+		request concourse.OutRequest
+		read    []string
+	)
+
+	readParameter := func(name string) (string, error) {
+		read = append(read, "ssm:"+name)
+		if name == "/ci/missing" {
+			return "", errors.New("not found")
+		}
+		return "parameter " + name, nil
+	}
+
+	readSecret := func(id string) (string, error) {
+		read = append(read, "secretsmanager:"+id)
+		return "secret " + id, nil
+	}
+
+	BeforeEach(func() {
+		read = nil
+		request = concourse.OutRequest{
+			Source: concourse.Source{
+				AWS:        &concourse.AWS{Region: "eu-west-1"},
+				SecretVars: []string{"webhook"},
+				Teams: []concourse.Team{
+					{Name: "main", Vars: map[string]interface{}{"db_password": "ssm:/ci/db-password", "region": "eu-west-1"}},
+				},
+				Targets: []concourse.Target{
+					{Name: "eu", Teams: []concourse.Team{{Name: "main", Vars: map[string]interface{}{"db_password": "ssm:/ci/db-password"}}}},
+				},
+			},
+			Params: concourse.OutParams{
+				Pipelines: []concourse.Pipeline{
+					{Name: "deploy", Vars: map[string]interface{}{"token": "secretsmanager:ci/token", "replicas": 3}},
+				},
+			},
+		}
+	})
+
+	It("replaces vars referring to parameters and secrets with their values, redacting them", func() {
+		Expect(request.UsesAWSVars()).To(BeTrue())
+
+		resolved, err := request.WithAWSVars(readParameter, readSecret)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resolved.Source.Teams[0].Vars).To(Equal(map[string]interface{}{"db_password": "parameter /ci/db-password", "region": "eu-west-1"}))
+		Expect(resolved.Source.Targets[0].Teams[0].Vars["db_password"]).To(Equal("parameter /ci/db-password"))
+		Expect(resolved.Params.Pipelines[0].Vars).To(Equal(map[string]interface{}{"token": "secret ci/token", "replicas": 3}))
+		Expect(resolved.Source.SecretVars).To(Equal([]string{"webhook", "db_password", "token"}))
+
+		Expect(read).To(Equal([]string{"ssm:/ci/db-password", "secretsmanager:ci/token"}))
+
+		Expect(request.Source.Teams[0].Vars["db_password"]).To(Equal("ssm:/ci/db-password"))
+		Expect(request.Source.SecretVars).To(Equal([]string{"webhook"}))
+	})
+
+	It("names the var which cannot be read", func() {
+		request.Params.Pipelines[0].Vars["other"] = "ssm:/ci/missing"
+
+		_, err := request.WithAWSVars(readParameter, readSecret)
+		Expect(err).To(MatchError("pipeline deploy: failed to read var other: not found"))
+	})
+
+	It("is not used unless AWS is configured", func() {
+		request.Source.AWS = nil
+
+		Expect(request.UsesAWSVars()).To(BeFalse())
+	})
+
+	It("is not used without vars referring to AWS", func() {
+		request.Source.Teams = nil
+		request.Source.Targets = nil
+		request.Params.Pipelines[0].Vars = map[string]interface{}{"token": "ssm:"}
+
+		Expect(request.UsesAWSVars()).To(BeFalse())
+	})
+})
@@ -0,0 +1,50 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Bool is a boolean which may be given either as a JSON boolean or, for
+// compatibility with earlier versions, as a string such as "true". It is
+// empty if it was not given.
+type Bool string
+
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		*b = ""
+	case bool:
+		*b = Bool(strconv.FormatBool(v))
+	case string:
+		*b = Bool(v)
+	default:
+		return fmt.Errorf("%s is not a boolean - use true or false", string(data))
+	}
+
+	return nil
+}
+
+// Parse returns the value of b, or false if it was not given.
+func (b Bool) Parse() (bool, error) {
+	if b == "" {
+		return false, nil
+	}
+
+	v, err := strconv.ParseBool(string(b))
+	if err != nil {
+		return false, fmt.Errorf(
+			"%q is not a boolean - use true or false (or one of 1, t, T, TRUE, True, 0, f, F, FALSE, False)",
+			string(b),
+		)
+	}
+
+	return v, nil
+}
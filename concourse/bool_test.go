@@ -0,0 +1,40 @@
+package concourse_test
+
+import (
+	"encoding/json"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bool", func() {
+	DescribeTable("unmarshalling and parsing",
+		func(source string, expected bool) {
+			var s concourse.Source
+			err := json.Unmarshal([]byte(source), &s)
+			Expect(err).NotTo(HaveOccurred())
+
+			insecure, err := s.Insecure.Parse()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insecure).To(Equal(expected))
+		},
+		Entry("not given", `{}`, false),
+		Entry("null", `{"insecure":null}`, false),
+		Entry("a boolean", `{"insecure":true}`, true),
+		Entry("a string", `{"insecure":"true"}`, true),
+		Entry("a short string", `{"insecure":"f"}`, false),
+	)
+
+	It("fails to unmarshal other types", func() {
+		var s concourse.Source
+		err := json.Unmarshal([]byte(`{"insecure":1}`), &s)
+		Expect(err).To(MatchError(ContainSubstring("1 is not a boolean")))
+	})
+
+	It("fails to parse unparseable strings, listing the accepted values", func() {
+		_, err := concourse.Bool("yes").Parse()
+		Expect(err).To(MatchError(ContainSubstring(`"yes" is not a boolean - use true or false`)))
+	})
+})
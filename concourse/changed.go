@@ -0,0 +1,64 @@
+package concourse
+
+import "sort"
+
+// ChangedKey is the key of the entry of versions emitted in pipeline mode
+// holding the key of the pipeline whose change they are for, which cannot
+// collide with the key of a pipeline as it has no team.
+const ChangedKey = "changed"
+
+// PerPipeline returns a version for each pipeline which was added, removed or
+// changed since the previous version, sorted by the key of the pipeline, so
+// that jobs triggered by the resource run once per changed pipeline. Each
+// holds a changed entry with the key of its pipeline and the entry of the
+// pipeline, unless it was removed. Only the last holds the entries of every
+// pipeline, as the next check only needs the latest version to tell what
+// changed since, so that the versions do not grow with the number of
+// pipelines. The previous version is returned as it is if nothing changed,
+// and a version is returned for every pipeline if it is empty.
+func (v Version) PerPipeline(previous Version) []Version {
+	var keys []string
+
+	if len(previous) == 0 {
+		keys = v.pipelineEntries().Keys()
+	} else {
+		changes := v.ChangesSince(previous)
+		if changes.Empty() {
+			return []Version{previous}
+		}
+
+		keys = append(keys, changes.Added...)
+		keys = append(keys, changes.Removed...)
+		keys = append(keys, changes.Changed...)
+	}
+	sort.Strings(keys)
+
+	versions := make([]Version, 0, len(keys))
+	for i, k := range keys {
+		version := Version{ChangedKey: k}
+		if i == len(keys)-1 {
+			for key, value := range v {
+				version[key] = value
+			}
+		} else if value, ok := v[k]; ok {
+			version[k] = value
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions
+}
+
+// pipelineEntries returns the entries of the version which are of pipelines,
+// without any sequence or changed entry.
+func (v Version) pipelineEntries() Version {
+	pipelines := make(Version, len(v))
+	for k, c := range v {
+		if k != SequenceKey && k != ChangedKey {
+			pipelines[k] = c
+		}
+	}
+
+	return pipelines
+}
@@ -0,0 +1,48 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PerPipeline", func() {
+	var version concourse.Version
+
+	BeforeEach(func() {
+		version = concourse.Version{"main/a": "1", "main/b": "4", "main/d": "5"}
+	})
+
+	It("returns a version for each pipeline without a previous version", func() {
+		Expect(version.PerPipeline(nil)).To(Equal([]concourse.Version{
+			{"main/a": "1", "changed": "main/a"},
+			{"main/b": "4", "changed": "main/b"},
+			{"main/a": "1", "main/b": "4", "main/d": "5", "changed": "main/d"},
+		}))
+	})
+
+	It("returns a version for each pipeline added, removed or changed", func() {
+		previous := concourse.Version{"main/a": "1", "main/b": "2", "main/c": "3", "changed": "main/a"}
+
+		Expect(version.PerPipeline(previous)).To(Equal([]concourse.Version{
+			{"main/b": "4", "changed": "main/b"},
+			{"changed": "main/c"},
+			{"main/a": "1", "main/b": "4", "main/d": "5", "changed": "main/d"},
+		}))
+	})
+
+	It("tells what changed since the last version", func() {
+		versions := version.PerPipeline(nil)
+		next := concourse.Version{"main/a": "1", "main/b": "6", "main/d": "5"}
+
+		Expect(next.PerPipeline(versions[len(versions)-1])).To(Equal([]concourse.Version{
+			{"main/a": "1", "main/b": "6", "main/d": "5", "changed": "main/b"},
+		}))
+	})
+
+	It("returns the previous version if no pipeline has changed", func() {
+		previous := concourse.Version{"main/a": "1", "main/b": "4", "main/d": "5", "changed": "main/b", "sequence": "10"}
+
+		Expect(version.PerPipeline(previous)).To(Equal([]concourse.Version{previous}))
+	})
+})
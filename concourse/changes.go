@@ -0,0 +1,75 @@
+package concourse
+
+import "sort"
+
+// VersionChanges are the keys of the pipelines which differ between two
+// versions, each sorted.
+type VersionChanges struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty tells whether no pipelines differ.
+func (c VersionChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// MetadataEntries returns an entry listing the pipelines for each kind of
+// change, omitting kinds without any.
+func (c VersionChanges) MetadataEntries() []MetadataEntry {
+	var entries []MetadataEntry
+
+	for _, kind := range []struct {
+		name string
+		keys []string
+	}{
+		{"pipelines added", c.Added},
+		{"pipelines removed", c.Removed},
+		{"pipelines changed", c.Changed},
+	} {
+		if len(kind.keys) > 0 {
+			entries = append(entries, NewMetadataEntry(kind.name, kind.keys))
+		}
+	}
+
+	return entries
+}
+
+// ChangesSince returns the pipelines which were added, removed or changed
+// since the previous version, ignoring any sequence or changed entry. Versions in digest mode
+// hold no pipelines, so have no changes.
+func (v Version) ChangesSince(previous Version) VersionChanges {
+	var changes VersionChanges
+
+	if _, ok := v[DigestKey]; ok {
+		return changes
+	}
+	if _, ok := previous[DigestKey]; ok {
+		return changes
+	}
+
+	current := v.pipelineEntries()
+	before := previous.pipelineEntries()
+
+	for k, c := range current {
+		p, ok := before[k]
+		if !ok {
+			changes.Added = append(changes.Added, k)
+		} else if p != c {
+			changes.Changed = append(changes.Changed, k)
+		}
+	}
+
+	for k := range before {
+		if _, ok := current[k]; !ok {
+			changes.Removed = append(changes.Removed, k)
+		}
+	}
+
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Changed)
+
+	return changes
+}
@@ -0,0 +1,41 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangesSince", func() {
+	It("lists the pipelines added, removed and changed", func() {
+		previous := concourse.Version{"main/a": "1", "main/b": "2", "main/c": "3", "sequence": "10"}
+		current := concourse.Version{"main/a": "1", "main/b": "4", "main/d": "5", "main/e": "6", "sequence": "20"}
+
+		changes := current.ChangesSince(previous)
+		Expect(changes).To(Equal(concourse.VersionChanges{
+			Added:   []string{"main/d", "main/e"},
+			Removed: []string{"main/c"},
+			Changed: []string{"main/b"},
+		}))
+		Expect(changes.Empty()).To(BeFalse())
+
+		Expect(concourse.MetadataOf(changes.MetadataEntries())).To(Equal([]concourse.Metadata{
+			{Name: "pipelines added", Value: "main/d, main/e"},
+			{Name: "pipelines removed", Value: "main/c"},
+			{Name: "pipelines changed", Value: "main/b"},
+		}))
+	})
+
+	It("has no changes between equal versions", func() {
+		version := concourse.Version{"main/a": "1"}
+
+		Expect(version.ChangesSince(version).Empty()).To(BeTrue())
+		Expect(version.ChangesSince(version).MetadataEntries()).To(BeEmpty())
+	})
+
+	It("has no changes for versions in digest mode", func() {
+		previous := concourse.Version{"main/a": "1"}
+
+		Expect(previous.Digest().ChangesSince(previous).Empty()).To(BeTrue())
+	})
+})
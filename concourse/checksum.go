@@ -0,0 +1,95 @@
+package concourse
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Version schemes, which set what the version of each pipeline is: the
+// checksum of its config, or the config version which the ATC increments
+// whenever it is set.
+const (
+	VersionSchemeChecksum      = "checksum"
+	VersionSchemeConfigVersion = "config_version"
+)
+
+// DefaultChecksum is the algorithm used for versions when Checksum is not
+// set. It is kept as MD5 so that existing versions do not change.
+const DefaultChecksum = "md5"
+
+// PipelineChecksum returns the hex-encoded checksum of the pipeline config
+// used as its version, computed with the Checksum algorithm. If
+// CanonicalChecksum is set, it is computed over the canonical form of the
+// config, so that it does not change with the order of keys or formatting.
+func (s Source) PipelineChecksum(config []byte) (string, error) {
+	if s.CanonicalChecksum {
+		config = canonicalConfig(config)
+	}
+
+	switch s.Checksum {
+	case "", DefaultChecksum:
+		return fmt.Sprintf("%x", md5.Sum(config)), nil
+	case "sha1":
+		return fmt.Sprintf("%x", sha1.Sum(config)), nil
+	case "sha256":
+		return fmt.Sprintf("%x", sha256.Sum256(config)), nil
+	default:
+		return "", fmt.Errorf("checksum must be one of sha256, sha1 or md5: %s", s.Checksum)
+	}
+}
+
+// canonicalConfig returns the config as JSON, whose object keys are sorted.
+// Configs which are not valid YAML are returned as they are.
+func canonicalConfig(config []byte) []byte {
+	var v interface{}
+	err := yaml.Unmarshal(config, &v)
+	if err != nil {
+		return config
+	}
+
+	canonical, err := json.Marshal(jsonCompatible(v))
+	if err != nil {
+		return config
+	}
+
+	return canonical
+}
+
+// CanonicalYAML returns the config as YAML with its keys sorted, indented by
+// two spaces and ending in a newline, so that configs differing only in
+// formatting are byte-identical.
+func CanonicalYAML(config []byte) ([]byte, error) {
+	var v interface{}
+	err := yaml.Unmarshal(config, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+// jsonCompatible converts the maps decoded from YAML, whose keys may be of
+// any type, to maps with string keys, which JSON can encode.
+func jsonCompatible(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonCompatible(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = jsonCompatible(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,71 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PipelineChecksum", func() {
+	DescribeTable("computing the checksum",
+		func(algorithm string, expected string) {
+			s := concourse.Source{Checksum: algorithm}
+
+			checksum, err := s.PipelineChecksum([]byte("jobs: []\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checksum).To(Equal(expected))
+		},
+		Entry("by default", "", "ca6c682c2da471ab8aafdf678451f7b9"),
+		Entry("md5", "md5", "ca6c682c2da471ab8aafdf678451f7b9"),
+		Entry("sha1", "sha1", "1e1ca8462d0a6d06f0fb761852da41952daad4ac"),
+		Entry("sha256", "sha256", "15b0264ec26fd5e1528b7c0e2e248a0d33e090989563b9e065da626d1c4cc44d"),
+	)
+
+	It("fails for other algorithms, listing the accepted ones", func() {
+		s := concourse.Source{Checksum: "crc32"}
+
+		_, err := s.PipelineChecksum([]byte("jobs: []\n"))
+		Expect(err).To(MatchError("checksum must be one of sha256, sha1 or md5: crc32"))
+	})
+
+	Context("when the checksum is canonical", func() {
+		It("does not change with the order of keys or formatting", func() {
+			s := concourse.Source{CanonicalChecksum: true}
+
+			a, err := s.PipelineChecksum([]byte("jobs:\n- name: a\n  plan: []\nresources: []\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := s.PipelineChecksum([]byte("---\nresources: [ ]\njobs:\n  - plan: []\n    name: a\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(a).To(Equal(b))
+		})
+
+		It("changes with the order of lists", func() {
+			s := concourse.Source{CanonicalChecksum: true}
+
+			a, err := s.PipelineChecksum([]byte("jobs: [{name: a}, {name: b}]\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := s.PipelineChecksum([]byte("jobs: [{name: b}, {name: a}]\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(a).NotTo(Equal(b))
+		})
+	})
+})
+
+var _ = Describe("CanonicalYAML", func() {
+	It("sorts keys, normalizes indentation and ends in a newline", func() {
+		canonical, err := concourse.CanonicalYAML([]byte("---\nresources: [ ]\njobs:\n    - plan: []\n      name: a"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(canonical)).To(Equal("jobs:\n- name: a\n  plan: []\nresources: []\n"))
+	})
+
+	It("fails for configs which are not YAML", func() {
+		_, err := concourse.CanonicalYAML([]byte("jobs: ["))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,68 @@
+package concourse
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+const credentialFilePrefix = "file:"
+
+// WithCredentialFiles returns a copy of the source in which team credentials
+// of the form file:<path> are replaced with the contents of the file at path,
+// such as a mounted secret, without any trailing newline.
+func (s Source) WithCredentialFiles() (Source, error) {
+	var err error
+
+	s.Teams, err = readTeamsCredentialFiles(s.Teams)
+	if err != nil {
+		return Source{}, err
+	}
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Teams, err = readTeamsCredentialFiles(t.Teams)
+			if err != nil {
+				return Source{}, err
+			}
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s, nil
+}
+
+func readTeamsCredentialFiles(teams []Team) ([]Team, error) {
+	if teams == nil {
+		return nil, nil
+	}
+
+	read := make([]Team, len(teams))
+	for i, t := range teams {
+		for _, field := range []*string{&t.Username, &t.Password, &t.ClientID, &t.ClientSecret, &t.Token} {
+			value, err := readCredentialFile(*field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read credential for team %s: %v", t.Name, err)
+			}
+			*field = value
+		}
+		read[i] = t
+	}
+
+	return read, nil
+}
+
+func readCredentialFile(credential string) (string, error) {
+	if !strings.HasPrefix(credential, credentialFilePrefix) {
+		return credential, nil
+	}
+
+	b, err := ioutil.ReadFile(strings.TrimPrefix(credential, credentialFilePrefix))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
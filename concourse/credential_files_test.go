@@ -0,0 +1,62 @@
+package concourse_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithCredentialFiles", func() {
+	var (
+		tempDir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tempDir, "password"), []byte("some-password\n"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("replaces credentials given as files with their contents", func() {
+		source := concourse.Source{
+			Teams: []concourse.Team{
+				{Name: "main", Username: "admin", Password: "file:" + filepath.Join(tempDir, "password")},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", Teams: []concourse.Team{{Name: "main", ClientSecret: "file:" + filepath.Join(tempDir, "password")}}},
+			},
+		}
+
+		read, err := source.WithCredentialFiles()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(read.Teams[0].Username).To(Equal("admin"))
+		Expect(read.Teams[0].Password).To(Equal("some-password"))
+		Expect(read.Targets[0].Teams[0].ClientSecret).To(Equal("some-password"))
+	})
+
+	Context("when the file does not exist", func() {
+		It("returns an error", func() {
+			source := concourse.Source{
+				Teams: []concourse.Team{
+					{Name: "main", Password: "file:" + filepath.Join(tempDir, "missing")},
+				},
+			}
+
+			_, err := source.WithCredentialFiles()
+			Expect(err).To(MatchError(ContainSubstring("failed to read credential for team main")))
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package concourse
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+)
+
+// Version modes, which set how the versions of pipelines are emitted.
+const (
+	VersionModeMap      = "map"
+	VersionModeDigest   = "digest"
+	VersionModePipeline = "pipeline"
+)
+
+// Keys of the entries of versions emitted in digest mode, which cannot
+// collide with the key of a pipeline as they have no team.
+const (
+	DigestKey = "digest"
+	CountKey  = "count"
+)
+
+// Digest returns the version rolled up into the hex-encoded SHA-256 digest
+// of its entries and their count, for sources with too many pipelines for
+// Concourse to handle a version holding all of them.
+func (v Version) Digest() Version {
+	h := sha256.New()
+	for _, k := range v.Keys() {
+		fmt.Fprintf(h, "%s=%s\n", k, v[k])
+	}
+
+	return Version{
+		DigestKey: fmt.Sprintf("%x", h.Sum(nil)),
+		CountKey:  strconv.Itoa(len(v)),
+	}
+}
+
+// Emitted returns the version in the form configured by VersionMode.
+func (s Source) Emitted(v Version) Version {
+	if s.VersionMode == VersionModeDigest {
+		return v.Digest()
+	}
+
+	return v
+}
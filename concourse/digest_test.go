@@ -0,0 +1,33 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Digest", func() {
+	It("rolls the version up into a digest of its entries and their count", func() {
+		version := concourse.Version{"main/a": "abc", "main/b": "def"}
+
+		Expect(version.Digest()).To(Equal(concourse.Version{
+			"digest": "eaad4a14dcc7e1cf0068a69092906145ca4c17e75a5f16a118237e7dc550396b",
+			"count":  "2",
+		}))
+	})
+
+	It("changes when any pipeline changes", func() {
+		a := concourse.Version{"main/a": "abc", "main/b": "def"}.Digest()
+		b := concourse.Version{"main/a": "abc", "main/b": "xyz"}.Digest()
+
+		Expect(a["digest"]).NotTo(Equal(b["digest"]))
+	})
+
+	It("is only emitted in digest mode", func() {
+		version := concourse.Version{"main/a": "abc"}
+
+		Expect(concourse.Source{}.Emitted(version)).To(Equal(version))
+		Expect(concourse.Source{VersionMode: "map"}.Emitted(version)).To(Equal(version))
+		Expect(concourse.Source{VersionMode: "digest"}.Emitted(version)).To(Equal(version.Digest()))
+	})
+})
@@ -0,0 +1,58 @@
+package concourse
+
+import (
+	"os"
+	"regexp"
+)
+
+var envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces each ${VAR} in s with the value of the environment
+// variable VAR. Other uses of $ are left alone, so that they can appear in
+// passwords.
+func expandEnv(s string) string {
+	return envVarRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		return os.Getenv(envVarRegexp.FindStringSubmatch(ref)[1])
+	})
+}
+
+// WithEnvExpanded returns a copy of the source in which ${VAR} is replaced
+// with the value of the environment variable VAR in the target and the names
+// and credentials of teams.
+func (s Source) WithEnvExpanded() Source {
+	s.Target = expandEnv(s.Target)
+	s.APIURL = expandEnv(s.APIURL)
+	s.Teams = expandTeamsEnv(s.Teams)
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Target = expandEnv(t.Target)
+			t.APIURL = expandEnv(t.APIURL)
+			t.Teams = expandTeamsEnv(t.Teams)
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s
+}
+
+func expandTeamsEnv(teams []Team) []Team {
+	if teams == nil {
+		return nil
+	}
+
+	expanded := make([]Team, len(teams))
+	for i, t := range teams {
+		t.Name = expandEnv(t.Name)
+		t.Username = expandEnv(t.Username)
+		t.Password = expandEnv(t.Password)
+		t.ClientID = expandEnv(t.ClientID)
+		t.ClientSecret = expandEnv(t.ClientSecret)
+		t.Token = expandEnv(t.Token)
+		expanded[i] = t
+	}
+
+	return expanded
+}
@@ -0,0 +1,58 @@
+package concourse_test
+
+import (
+	"os"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithEnvExpanded", func() {
+	BeforeEach(func() {
+		os.Setenv("SOME_TARGET", "https://ci.example.com")
+		os.Setenv("SOME_PASSWORD", "some-password")
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("SOME_TARGET")
+		os.Unsetenv("SOME_PASSWORD")
+	})
+
+	It("expands environment variables in the target and credentials", func() {
+		source := concourse.Source{
+			Target: "${SOME_TARGET}",
+			Teams: []concourse.Team{
+				{Name: "main", Username: "admin", Password: "${SOME_PASSWORD}"},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", Target: "${SOME_TARGET}/eu", Teams: []concourse.Team{{Name: "main", ClientSecret: "${SOME_PASSWORD}"}}},
+			},
+		}
+
+		expanded := source.WithEnvExpanded()
+
+		Expect(expanded.Target).To(Equal("https://ci.example.com"))
+		Expect(expanded.Teams[0].Password).To(Equal("some-password"))
+		Expect(expanded.Targets[0].Target).To(Equal("https://ci.example.com/eu"))
+		Expect(expanded.Targets[0].Teams[0].ClientSecret).To(Equal("some-password"))
+
+		Expect(source.Teams[0].Password).To(Equal("${SOME_PASSWORD}"))
+	})
+
+	It("only expands the braced form", func() {
+		source := concourse.Source{
+			Teams: []concourse.Team{
+				{Name: "main", Password: "pa$SOME_PASSWORD$"},
+			},
+		}
+
+		Expect(source.WithEnvExpanded().Teams[0].Password).To(Equal("pa$SOME_PASSWORD$"))
+	})
+
+	It("expands unset variables to nothing", func() {
+		source := concourse.Source{Target: "${SOME_UNSET_VARIABLE}"}
+
+		Expect(source.WithEnvExpanded().Target).To(BeEmpty())
+	})
+})
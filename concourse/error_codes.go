@@ -0,0 +1,60 @@
+package concourse
+
+import "errors"
+
+// ErrorCode is the stable category of a failure, so that automation wrapping
+// the resource can branch on why it failed without parsing messages.
+type ErrorCode string
+
+const (
+	ErrorCodeAuthFailed          ErrorCode = "AUTH_FAILED"
+	ErrorCodeTeamNotFound        ErrorCode = "TEAM_NOT_FOUND"
+	ErrorCodePipelineFetchFailed ErrorCode = "PIPELINE_FETCH_FAILED"
+	ErrorCodeVersionMismatch     ErrorCode = "VERSION_MISMATCH"
+	ErrorCodeUnknown             ErrorCode = "UNKNOWN"
+)
+
+// CodedError is a failure along with its code.
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// WithErrorCode returns the error with the code, unless it already has one,
+// which, being closer to the cause, is kept.
+func WithErrorCode(code ErrorCode, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return err
+	}
+
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorCodeOf returns the code of the error, or ErrorCodeUnknown if it has
+// none. The code of failures to handle several pipelines is that of the first.
+func ErrorCodeOf(err error) ErrorCode {
+	var pipelineErrs PipelineErrors
+	if errors.As(err, &pipelineErrs) && len(pipelineErrs) > 0 {
+		err = pipelineErrs[0]
+	}
+
+	var coded *CodedError
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	return ErrorCodeUnknown
+}
@@ -0,0 +1,45 @@
+package concourse_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrorCodeOf", func() {
+	someErr := errors.New("some error")
+
+	It("is unknown for errors without a code", func() {
+		Expect(concourse.ErrorCodeOf(someErr)).To(Equal(concourse.ErrorCodeUnknown))
+	})
+
+	It("is the code of a wrapped error", func() {
+		err := fmt.Errorf("wrapped: %w", concourse.WithErrorCode(concourse.ErrorCodeAuthFailed, someErr))
+
+		Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodeAuthFailed))
+		Expect(errors.Is(err, someErr)).To(BeTrue())
+		Expect(err).To(MatchError("wrapped: some error"))
+	})
+
+	It("keeps the code closest to the cause", func() {
+		err := concourse.WithErrorCode(concourse.ErrorCodeTeamNotFound, someErr)
+		err = concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
+
+		Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodeTeamNotFound))
+	})
+
+	It("is the code of the first of several pipeline failures", func() {
+		var errs concourse.PipelineErrors
+		errs.Add("", "main", "pipeline-1", concourse.WithErrorCode(concourse.ErrorCodeVersionMismatch, someErr))
+		errs.Add("", "main", "pipeline-2", concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, someErr))
+
+		Expect(concourse.ErrorCodeOf(errs.Err())).To(Equal(concourse.ErrorCodeVersionMismatch))
+	})
+
+	It("leaves no error as no error", func() {
+		Expect(concourse.WithErrorCode(concourse.ErrorCodeAuthFailed, nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,95 @@
+package concourse
+
+import (
+	"fmt"
+)
+
+// KubernetesSecret is a Kubernetes secret holding the credentials of a team
+// under the keys username and password, client_id and client_secret, or
+// token. The namespace defaults to that of the pod the resource runs in.
+type KubernetesSecret struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// UsesKubernetesSecrets tells whether the credentials of any team, including
+// those of targets, are read from a Kubernetes secret.
+func (s Source) UsesKubernetesSecrets() bool {
+	for _, t := range s.AllTargets() {
+		for _, team := range t.Teams {
+			if team.KubernetesSecret != nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WithKubernetesSecrets returns a copy of the source in which teams with a
+// KubernetesSecret take their credentials from its data, read with
+// readSecret. Such teams must not be given any credentials of their own.
+func (s Source) WithKubernetesSecrets(readSecret func(namespace string, name string) (map[string]string, error)) (Source, error) {
+	var err error
+
+	s.Teams, err = readTeamsKubernetesSecrets(s.Teams, readSecret)
+	if err != nil {
+		return Source{}, err
+	}
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Teams, err = readTeamsKubernetesSecrets(t.Teams, readSecret)
+			if err != nil {
+				return Source{}, err
+			}
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s, nil
+}
+
+func readTeamsKubernetesSecrets(teams []Team, readSecret func(namespace string, name string) (map[string]string, error)) ([]Team, error) {
+	if teams == nil {
+		return nil, nil
+	}
+
+	read := make([]Team, len(teams))
+	for i, t := range teams {
+		secret := t.KubernetesSecret
+		if secret == nil {
+			read[i] = t
+			continue
+		}
+
+		if t.Username != "" || t.Password != "" || t.ClientID != "" || t.ClientSecret != "" || t.Token != "" {
+			return nil, fmt.Errorf("team %s is given credentials as well as kubernetes_secret", t.Name)
+		}
+
+		if secret.Name == "" {
+			return nil, fmt.Errorf("kubernetes_secret.name must be provided for team %s", t.Name)
+		}
+
+		data, err := readSecret(secret.Namespace, secret.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials for team %s: %v", t.Name, err)
+		}
+
+		t.Username = data["username"]
+		t.Password = data["password"]
+		t.ClientID = data["client_id"]
+		t.ClientSecret = data["client_secret"]
+		t.Token = data["token"]
+
+		if t.Username == "" && t.Password == "" && t.ClientID == "" && t.ClientSecret == "" && t.Token == "" {
+			return nil, fmt.Errorf("failed to read credentials for team %s: secret %s holds none of username, password, client_id, client_secret or token", t.Name, secret.Name)
+		}
+
+		read[i] = t
+	}
+
+	return read, nil
+}
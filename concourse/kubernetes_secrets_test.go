@@ -0,0 +1,89 @@
+package concourse_test
+
+import (
+	"errors"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithKubernetesSecrets", func() {
+	var (
+		secrets map[string]map[string]string
+		read    []string
+	)
+
+	readSecret := func(namespace string, name string) (map[string]string, error) {
+		read = append(read, namespace+"/"+name)
+
+		data, found := secrets[name]
+		if !found {
+			return nil, errors.New("not found")
+		}
+
+		return data, nil
+	}
+
+	BeforeEach(func() {
+		read = nil
+		secrets = map[string]map[string]string{
+			"main-team": {"username": "admin", "password": "some-password"},
+			"eu-team":   {"token": "some-token", "other": "ignored"},
+			"empty":     {"other": "ignored"},
+		}
+	})
+
+	It("replaces the credentials of teams with those of their secrets", func() {
+		source := concourse.Source{
+			Teams: []concourse.Team{
+				{Name: "main", KubernetesSecret: &concourse.KubernetesSecret{Namespace: "ci", Name: "main-team"}},
+				{Name: "other", Token: "other-token"},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", Teams: []concourse.Team{{Name: "main", KubernetesSecret: &concourse.KubernetesSecret{Name: "eu-team"}}}},
+			},
+		}
+
+		Expect(source.UsesKubernetesSecrets()).To(BeTrue())
+
+		withSecrets, err := source.WithKubernetesSecrets(readSecret)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(read).To(Equal([]string{"ci/main-team", "/eu-team"}))
+		Expect(withSecrets.Teams[0].Username).To(Equal("admin"))
+		Expect(withSecrets.Teams[0].Password).To(Equal("some-password"))
+		Expect(withSecrets.Teams[1].Token).To(Equal("other-token"))
+		Expect(withSecrets.Targets[0].Teams[0].Token).To(Equal("some-token"))
+
+		Expect(source.Teams[0].Password).To(BeEmpty())
+	})
+
+	It("is not used without secrets", func() {
+		source := concourse.Source{Teams: []concourse.Team{{Name: "main", Token: "some-token"}}}
+
+		Expect(source.UsesKubernetesSecrets()).To(BeFalse())
+	})
+
+	DescribeTable("failing to read credentials",
+		func(team concourse.Team, expected string) {
+			source := concourse.Source{Teams: []concourse.Team{team}}
+
+			_, err := source.WithKubernetesSecrets(readSecret)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("teams given credentials as well",
+			concourse.Team{Name: "main", Token: "some-token", KubernetesSecret: &concourse.KubernetesSecret{Name: "main-team"}},
+			"team main is given credentials as well as kubernetes_secret"),
+		Entry("secrets without a name",
+			concourse.Team{Name: "main", KubernetesSecret: &concourse.KubernetesSecret{Namespace: "ci"}},
+			"kubernetes_secret.name must be provided for team main"),
+		Entry("secrets which cannot be read",
+			concourse.Team{Name: "main", KubernetesSecret: &concourse.KubernetesSecret{Name: "missing"}},
+			"failed to read credentials for team main: not found"),
+		Entry("secrets without credentials",
+			concourse.Team{Name: "main", KubernetesSecret: &concourse.KubernetesSecret{Name: "empty"}},
+			"failed to read credentials for team main: secret empty holds none of username, password, client_id, client_secret or token"),
+	)
+})
@@ -0,0 +1,26 @@
+package concourse
+
+// Modes of linting, which set whether out sets pipelines breaking the rules.
+const (
+	LintModeWarn    = "warn"
+	LintModeEnforce = "enforce"
+)
+
+// Lint configures the rules which out checks each pipeline config against
+// before setting it. Rules which are left unset are not checked.
+type Lint struct {
+	// Mode is either LintModeWarn, the default, to set pipelines breaking
+	// the rules with a warning, or LintModeEnforce to not set them.
+	Mode string `json:"mode"`
+
+	RequiredResourceTypes []string `json:"required_resource_types"`
+	ForbidPrivileged      bool     `json:"forbid_privileged"`
+	JobNamePattern        string   `json:"job_name_pattern"`
+	ResourceNamePattern   string   `json:"resource_name_pattern"`
+	MaxJobs               int      `json:"max_jobs"`
+}
+
+// Enforced tells whether pipelines breaking the rules are not set.
+func (l *Lint) Enforced() bool {
+	return l != nil && l.Mode == LintModeEnforce
+}
@@ -0,0 +1,124 @@
+package concourse
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MetadataSchemaVersion is the version of the structure of MetadataDocument.
+// It is incremented by any change which could break the tools reading it.
+const MetadataSchemaVersion = 1
+
+// Types of the values of metadata entries.
+const (
+	MetadataTypeString = "string"
+	MetadataTypeBool   = "bool"
+	MetadataTypeInt    = "int"
+	MetadataTypeList   = "list"
+)
+
+// MetadataEntry is a typed entry of metadata, from which the name/value
+// Metadata shown by Concourse is derived. Entries about a target or a
+// pipeline identify it.
+type MetadataEntry struct {
+	Name         string                 `json:"name"`
+	Type         string                 `json:"type"`
+	Value        interface{}            `json:"value"`
+	Target       string                 `json:"target,omitempty"`
+	Team         string                 `json:"team,omitempty"`
+	Pipeline     string                 `json:"pipeline,omitempty"`
+	InstanceVars map[string]interface{} `json:"instance_vars,omitempty"`
+}
+
+// MetadataDocument is the machine-readable form of the metadata of in and
+// out, so that tools need not parse the names and values of Metadata.
+type MetadataDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Entries       []MetadataEntry `json:"entries"`
+}
+
+// NewMetadataEntry returns the entry of the value, which must be a string,
+// bool, int or []string.
+func NewMetadataEntry(name string, value interface{}) MetadataEntry {
+	entry := MetadataEntry{Name: name, Type: MetadataTypeString, Value: value}
+
+	switch value.(type) {
+	case bool:
+		entry.Type = MetadataTypeBool
+	case int:
+		entry.Type = MetadataTypeInt
+	case []string:
+		entry.Type = MetadataTypeList
+	}
+
+	return entry
+}
+
+// Metadata returns the entry about the target, named with the field.
+func (t Target) Metadata(field string, value interface{}) MetadataEntry {
+	entry := NewMetadataEntry(t.MetadataName(field), value)
+	entry.Target = t.Name
+
+	return entry
+}
+
+// PipelineMetadata returns the entry about the pipeline of the team of the
+// target, named [target/]team/pipeline[/instance vars] followed by the field,
+// if it is not empty.
+func (t Target) PipelineMetadata(teamName string, pipelineName string, instanceVars map[string]interface{}, field string, value interface{}) MetadataEntry {
+	name := teamName + "/" + pipelineName
+	if len(instanceVars) > 0 {
+		name += "/" + FormatInstanceVars(instanceVars)
+	}
+	if field != "" {
+		name += " " + field
+	}
+
+	entry := NewMetadataEntry(t.MetadataName(name), value)
+	entry.Target = t.Name
+	entry.Team = teamName
+	entry.Pipeline = pipelineName
+	entry.InstanceVars = instanceVars
+
+	return entry
+}
+
+// Metadata returns the name and value of the entry as shown by Concourse.
+func (e MetadataEntry) Metadata() Metadata {
+	var value string
+
+	switch v := e.Value.(type) {
+	case string:
+		value = v
+	case bool:
+		value = strconv.FormatBool(v)
+	case int:
+		value = strconv.Itoa(v)
+	case []string:
+		value = strings.Join(v, ", ")
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	return Metadata{Name: e.Name, Value: value}
+}
+
+// MetadataOf returns the name and value of each of the entries.
+func MetadataOf(entries []MetadataEntry) []Metadata {
+	metadata := make([]Metadata, 0, len(entries))
+	for _, e := range entries {
+		metadata = append(metadata, e.Metadata())
+	}
+
+	return metadata
+}
+
+// NewMetadataDocument returns the document holding the entries.
+func NewMetadataDocument(entries []MetadataEntry) MetadataDocument {
+	if entries == nil {
+		entries = []MetadataEntry{}
+	}
+
+	return MetadataDocument{SchemaVersion: MetadataSchemaVersion, Entries: entries}
+}
@@ -0,0 +1,52 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MetadataEntry", func() {
+	DescribeTable("typing and formatting values",
+		func(value interface{}, expectedType string, expectedValue string) {
+			entry := concourse.NewMetadataEntry("some-name", value)
+			Expect(entry.Type).To(Equal(expectedType))
+			Expect(entry.Metadata()).To(Equal(concourse.Metadata{Name: "some-name", Value: expectedValue}))
+		},
+		Entry("a string", "some-value", "string", "some-value"),
+		Entry("a bool", true, "bool", "true"),
+		Entry("an int", 42, "int", "42"),
+		Entry("a list", []string{"a", "b"}, "list", "a, b"),
+	)
+
+	It("names and identifies entries about pipelines", func() {
+		target := concourse.Target{Name: "eu"}
+
+		entry := target.PipelineMetadata("main", "deploy", map[string]interface{}{"branch": "main"}, "jobs", 3)
+		Expect(entry).To(Equal(concourse.MetadataEntry{
+			Name:         `eu/main/deploy/branch:"main" jobs`,
+			Type:         "int",
+			Value:        3,
+			Target:       "eu",
+			Team:         "main",
+			Pipeline:     "deploy",
+			InstanceVars: map[string]interface{}{"branch": "main"},
+		}))
+
+		Expect(concourse.Target{}.PipelineMetadata("main", "deploy", nil, "", "url").Name).To(Equal("main/deploy"))
+	})
+
+	It("names and identifies entries about targets", func() {
+		entry := concourse.Target{Name: "eu"}.Metadata("fly_version", "7.9.1")
+		Expect(entry.Name).To(Equal("eu/fly_version"))
+		Expect(entry.Target).To(Equal("eu"))
+	})
+
+	It("versions the document", func() {
+		document := concourse.NewMetadataDocument(nil)
+		Expect(document.SchemaVersion).To(Equal(concourse.MetadataSchemaVersion))
+		Expect(document.Entries).To(BeEmpty())
+		Expect(document.Entries).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,71 @@
+package concourse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PipelineError is the failure to handle a single pipeline.
+type PipelineError struct {
+	Target   string
+	Team     string
+	Pipeline string
+	Err      error
+}
+
+func (e *PipelineError) Error() string {
+	name := e.Team + "/" + e.Pipeline
+	if e.Target != "" {
+		name = e.Target + "/" + name
+	}
+
+	return fmt.Sprintf("pipeline %s: %v", name, e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// PipelineErrors are the failures to handle each of several pipelines. They
+// are reported together once every pipeline has been handled, so that a
+// single run reveals every broken pipeline.
+type PipelineErrors []*PipelineError
+
+func (e PipelineErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d pipelines failed:\n  - %s", len(e), strings.Join(messages, "\n  - "))
+}
+
+// Is reports whether any of the failures is the target.
+func (e PipelineErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Add adds the failure to handle the pipeline of the team of the target.
+func (e *PipelineErrors) Add(targetName string, teamName string, pipelineName string, err error) {
+	*e = append(*e, &PipelineError{Target: targetName, Team: teamName, Pipeline: pipelineName, Err: err})
+}
+
+// Err returns the failures, or nil if there are none.
+func (e PipelineErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
@@ -0,0 +1,35 @@
+package concourse_test
+
+import (
+	"errors"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PipelineErrors", func() {
+	someErr := errors.New("some error")
+
+	It("is nil when there are no failures", func() {
+		var errs concourse.PipelineErrors
+		Expect(errs.Err()).To(BeNil())
+	})
+
+	It("names the pipeline of a single failure", func() {
+		var errs concourse.PipelineErrors
+		errs.Add("eu", "main", "some-pipeline", someErr)
+
+		Expect(errs.Err()).To(MatchError("pipeline eu/main/some-pipeline: some error"))
+		Expect(errors.Is(errs.Err(), someErr)).To(BeTrue())
+	})
+
+	It("lists each of several failures", func() {
+		var errs concourse.PipelineErrors
+		errs.Add("", "main", "pipeline-1", someErr)
+		errs.Add("", "other", "pipeline-2", errors.New("other error"))
+
+		Expect(errs.Err()).To(MatchError("2 pipelines failed:\n  - pipeline main/pipeline-1: some error\n  - pipeline other/pipeline-2: other error"))
+		Expect(errors.Is(errs.Err(), someErr)).To(BeTrue())
+	})
+})
@@ -0,0 +1,62 @@
+package concourse
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retries configures how every request to the ATC which fails with a
+// possibly transient error is retried, whether it is made by fly or directly.
+// The RetryAttempts and RetryBackoff of v1 sources are migrated to it.
+type Retries struct {
+	MaxAttempts    int    `json:"max_attempts"`
+	Backoff        string `json:"backoff"`
+	MaxElapsedTime string `json:"max_elapsed_time"`
+}
+
+// RetryPolicy is the parsed form of Retries.
+type RetryPolicy struct {
+	// Retries is the number of attempts made after the first.
+	Retries int
+
+	// Backoff is the wait before the first retry. Zero means the default.
+	Backoff time.Duration
+
+	// MaxElapsedTime is how long after the first attempt retries may still
+	// be made. Zero means no limit.
+	MaxElapsedTime time.Duration
+}
+
+// RetryPolicy returns the policy configured by Retries, which is not to
+// retry if it is not given.
+func (s Source) RetryPolicy() (RetryPolicy, error) {
+	if s.Retries == nil {
+		return RetryPolicy{}, nil
+	}
+
+	if s.Retries.MaxAttempts < 0 {
+		return RetryPolicy{}, fmt.Errorf("%s must not be negative", "retries.max_attempts")
+	}
+
+	policy := RetryPolicy{}
+	if s.Retries.MaxAttempts > 0 {
+		policy.Retries = s.Retries.MaxAttempts - 1
+	}
+
+	var err error
+	if s.Retries.Backoff != "" {
+		policy.Backoff, err = time.ParseDuration(s.Retries.Backoff)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("retries.backoff must be a duration such as 1s or 500ms: %v", err)
+		}
+	}
+
+	if s.Retries.MaxElapsedTime != "" {
+		policy.MaxElapsedTime, err = time.ParseDuration(s.Retries.MaxElapsedTime)
+		if err != nil {
+			return RetryPolicy{}, fmt.Errorf("retries.max_elapsed_time must be a duration such as 2m: %v", err)
+		}
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,39 @@
+package concourse_test
+
+import (
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	DescribeTable("parsing",
+		func(source concourse.Source, expected concourse.RetryPolicy) {
+			policy, err := source.RetryPolicy()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(policy).To(Equal(expected))
+		},
+		Entry("not given", concourse.Source{}, concourse.RetryPolicy{}),
+		Entry("retries",
+			concourse.Source{Retries: &concourse.Retries{MaxAttempts: 4, Backoff: "500ms", MaxElapsedTime: "1m"}},
+			concourse.RetryPolicy{Retries: 3, Backoff: 500 * time.Millisecond, MaxElapsedTime: time.Minute},
+		),
+		Entry("retries without max_attempts",
+			concourse.Source{Retries: &concourse.Retries{Backoff: "1s"}},
+			concourse.RetryPolicy{Backoff: time.Second},
+		),
+	)
+
+	DescribeTable("failing",
+		func(source concourse.Source, expected string) {
+			_, err := source.RetryPolicy()
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("negative max_attempts", concourse.Source{Retries: &concourse.Retries{MaxAttempts: -1}}, "retries.max_attempts must not be negative"),
+		Entry("bad backoff", concourse.Source{Retries: &concourse.Retries{Backoff: "soon"}}, "retries.backoff must be a duration"),
+		Entry("bad max_elapsed_time", concourse.Source{Retries: &concourse.Retries{MaxElapsedTime: "ages"}}, "retries.max_elapsed_time must be a duration"),
+	)
+})
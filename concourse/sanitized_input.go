@@ -1,15 +1,132 @@
 package concourse
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+)
 
+// privateKeyRegexp matches PEM blocks of private keys, e.g. those bundled by
+// mistake along with a CA certificate.
+var privateKeyRegexp = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)
+
+// SanitizedSource returns the credentials of the source, along with the
+// values of its secret vars and tracing headers and any private keys in its
+// certificates, mapped to what they are replaced with in the log.
 func SanitizedSource(source Source) map[string]string {
 	s := make(map[string]string)
 
+	if source.Password != "" {
+		s[source.Password] = "***REDACTED-PASSWORD***"
+	}
+
+	if source.ClientSecret != "" {
+		s[source.ClientSecret] = "***REDACTED-CLIENT-SECRET***"
+	}
+
+	if source.Token != "" {
+		s[source.Token] = "***REDACTED-TOKEN***"
+	}
+
+	if source.ClientKey != "" {
+		s[source.ClientKey] = "***REDACTED-CLIENT-KEY***"
+	}
+
+	if source.AWS != nil {
+		if source.AWS.SecretAccessKey != "" {
+			s[source.AWS.SecretAccessKey] = "***REDACTED-AWS-SECRET-ACCESS-KEY***"
+		}
+
+		if source.AWS.SessionToken != "" {
+			s[source.AWS.SessionToken] = "***REDACTED-AWS-SESSION-TOKEN***"
+		}
+	}
+
+	for _, cert := range []string{source.CACert, source.ClientCert} {
+		for _, key := range privateKeyRegexp.FindAllString(cert, -1) {
+			s[key] = "***REDACTED-PRIVATE-KEY***"
+		}
+	}
+
+	if source.TeamDefaults != nil {
+		t := source.TeamDefaults
+
+		if t.Password != "" {
+			s[t.Password] = "***REDACTED-PASSWORD-TEAM-DEFAULTS***"
+		}
+
+		if t.ClientSecret != "" {
+			s[t.ClientSecret] = "***REDACTED-CLIENT-SECRET-TEAM-DEFAULTS***"
+		}
+
+		if t.Token != "" {
+			s[t.Token] = "***REDACTED-TOKEN-TEAM-DEFAULTS***"
+		}
+
+		addSecretVars(s, source.SecretVars, t.Vars)
+	}
+
 	for i, t := range source.Teams {
 		if t.Password != "" {
 			s[t.Password] = fmt.Sprintf("***REDACTED-PASSWORD-TEAM-%d***", i)
 		}
+
+		if t.ClientSecret != "" {
+			s[t.ClientSecret] = fmt.Sprintf("***REDACTED-CLIENT-SECRET-TEAM-%d***", i)
+		}
+
+		if t.Token != "" {
+			s[t.Token] = fmt.Sprintf("***REDACTED-TOKEN-TEAM-%d***", i)
+		}
+
+		addSecretVars(s, source.SecretVars, t.Vars)
+	}
+
+	for i, target := range source.Targets {
+		for j, t := range target.Teams {
+			if t.Password != "" {
+				s[t.Password] = fmt.Sprintf("***REDACTED-PASSWORD-TARGET-%d-TEAM-%d***", i, j)
+			}
+
+			if t.ClientSecret != "" {
+				s[t.ClientSecret] = fmt.Sprintf("***REDACTED-CLIENT-SECRET-TARGET-%d-TEAM-%d***", i, j)
+			}
+
+			if t.Token != "" {
+				s[t.Token] = fmt.Sprintf("***REDACTED-TOKEN-TARGET-%d-TEAM-%d***", i, j)
+			}
+
+			addSecretVars(s, source.SecretVars, t.Vars)
+		}
+	}
+
+	for name, value := range source.TracingHeaders() {
+		if value != "" {
+			s[value] = fmt.Sprintf("***REDACTED-TRACING-HEADER-%s***", name)
+		}
+	}
+
+	return s
+}
+
+// SanitizedOutRequest returns what SanitizedSource does for the source of the
+// request, along with the values of the secret vars of the pipelines put.
+func SanitizedOutRequest(request OutRequest) map[string]string {
+	s := SanitizedSource(request.Source)
+
+	for _, p := range request.Params.Pipelines {
+		addSecretVars(s, request.Source.SecretVars, p.Vars)
 	}
 
 	return s
 }
+
+// addSecretVars adds the values of the vars with the given names which are
+// non-empty strings.
+func addSecretVars(s map[string]string, names []string, vars map[string]interface{}) {
+	for _, name := range names {
+		value, ok := vars[name].(string)
+		if ok && value != "" {
+			s[value] = fmt.Sprintf("***REDACTED-VAR-%s***", name)
+		}
+	}
+}
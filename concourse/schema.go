@@ -0,0 +1,134 @@
+package concourse
+
+import "fmt"
+
+const (
+	SchemaV1 = "v1"
+	SchemaV2 = "v2"
+)
+
+// Migrated returns a copy of the source translated to schema v2, along with
+// a description of each deprecated field which was migrated. Sources without
+// a schema are taken to be v1. Sources of schema v2 are returned as they are,
+// and must not use the deprecated fields.
+func (s Source) Migrated() (Source, []string, error) {
+	switch s.Schema {
+	case "", SchemaV1:
+	case SchemaV2:
+		deprecated := s.deprecatedFields()
+		if len(deprecated) > 0 {
+			return Source{}, nil, fmt.Errorf("%s is not supported by schema %s, see the README for its replacement", deprecated[0], SchemaV2)
+		}
+		return s, nil, nil
+	default:
+		return Source{}, nil, fmt.Errorf("schema must be one of %s or %s, got: '%s'", SchemaV1, SchemaV2, s.Schema)
+	}
+
+	var migrated []string
+
+	s.Insecure, s.SkipSSLValidation = migrateInsecure("insecure", s.Insecure, s.SkipSSLValidation, &migrated)
+	s.Teams = migrateTeams("teams", s.Teams, &migrated)
+
+	if s.TeamDefaults != nil {
+		defaults := *s.TeamDefaults
+		defaults.Insecure, defaults.SkipSSLValidation = migrateInsecure("team_defaults.insecure", defaults.Insecure, defaults.SkipSSLValidation, &migrated)
+		s.TeamDefaults = &defaults
+	}
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Insecure, t.SkipSSLValidation = migrateInsecure(fmt.Sprintf("targets[%d].insecure", i), t.Insecure, t.SkipSSLValidation, &migrated)
+			t.Teams = migrateTeams(fmt.Sprintf("targets[%d].teams", i), t.Teams, &migrated)
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	if s.RetryAttempts != 0 || s.RetryBackoff != "" {
+		if s.Retries != nil {
+			return Source{}, nil, fmt.Errorf("only one of %s or %s and %s may be provided in source", "retries", "retry_attempts", "retry_backoff")
+		}
+		if s.RetryAttempts < 0 {
+			return Source{}, nil, fmt.Errorf("%s must not be negative", "retry_attempts")
+		}
+
+		s.Retries = &Retries{MaxAttempts: s.RetryAttempts + 1, Backoff: s.RetryBackoff}
+		if s.RetryAttempts != 0 {
+			migrated = append(migrated, fmt.Sprintf("retry_attempts: %d migrated to retries.max_attempts: %d", s.RetryAttempts, s.Retries.MaxAttempts))
+		}
+		if s.RetryBackoff != "" {
+			migrated = append(migrated, "retry_backoff migrated to retries.backoff")
+		}
+		s.RetryAttempts = 0
+		s.RetryBackoff = ""
+	}
+
+	s.Schema = SchemaV2
+
+	return s, migrated, nil
+}
+
+func migrateTeams(path string, teams []Team, migrated *[]string) []Team {
+	if teams == nil {
+		return nil
+	}
+
+	result := make([]Team, len(teams))
+	for i, t := range teams {
+		t.Insecure, t.SkipSSLValidation = migrateInsecure(fmt.Sprintf("%s[%d].insecure", path, i), t.Insecure, t.SkipSSLValidation, migrated)
+		result[i] = t
+	}
+
+	return result
+}
+
+func migrateInsecure(path string, insecure Bool, skipSSLValidation Bool, migrated *[]string) (Bool, Bool) {
+	if insecure == "" {
+		return insecure, skipSSLValidation
+	}
+
+	if skipSSLValidation != "" {
+		*migrated = append(*migrated, fmt.Sprintf("%s dropped in favour of skip_ssl_validation", path))
+		return "", skipSSLValidation
+	}
+
+	*migrated = append(*migrated, fmt.Sprintf("%s migrated to skip_ssl_validation", path))
+	return "", insecure
+}
+
+// deprecatedFields returns the paths of the deprecated fields which the
+// source uses.
+func (s Source) deprecatedFields() []string {
+	var fields []string
+
+	if s.Insecure != "" {
+		fields = append(fields, "insecure")
+	}
+	for i, t := range s.Teams {
+		if t.Insecure != "" {
+			fields = append(fields, fmt.Sprintf("teams[%d].insecure", i))
+		}
+	}
+	if s.TeamDefaults != nil && s.TeamDefaults.Insecure != "" {
+		fields = append(fields, "team_defaults.insecure")
+	}
+	for i, t := range s.Targets {
+		if t.Insecure != "" {
+			fields = append(fields, fmt.Sprintf("targets[%d].insecure", i))
+		}
+		for j, team := range t.Teams {
+			if team.Insecure != "" {
+				fields = append(fields, fmt.Sprintf("targets[%d].teams[%d].insecure", i, j))
+			}
+		}
+	}
+	if s.RetryAttempts != 0 {
+		fields = append(fields, "retry_attempts")
+	}
+	if s.RetryBackoff != "" {
+		fields = append(fields, "retry_backoff")
+	}
+
+	return fields
+}
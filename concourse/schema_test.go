@@ -0,0 +1,92 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Migrated", func() {
+	It("translates v1 sources to v2, describing each migrated field", func() {
+		source := concourse.Source{
+			Insecure:      "true",
+			RetryAttempts: 2,
+			RetryBackoff:  "500ms",
+			Teams: []concourse.Team{
+				{Name: "main", Insecure: "false", SkipSSLValidation: "true"},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", Teams: []concourse.Team{{Name: "ops", Insecure: "true"}}},
+			},
+		}
+
+		migrated, fields, err := source.Migrated()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(migrated.Schema).To(Equal(concourse.SchemaV2))
+		Expect(migrated.Insecure).To(BeEmpty())
+		Expect(migrated.SkipSSLValidation).To(Equal(concourse.Bool("true")))
+		Expect(migrated.Teams[0].Insecure).To(BeEmpty())
+		Expect(migrated.Teams[0].SkipSSLValidation).To(Equal(concourse.Bool("true")))
+		Expect(migrated.Targets[0].Teams[0].SkipSSLValidation).To(Equal(concourse.Bool("true")))
+		Expect(migrated.RetryAttempts).To(BeZero())
+		Expect(migrated.RetryBackoff).To(BeEmpty())
+		Expect(migrated.Retries).To(Equal(&concourse.Retries{MaxAttempts: 3, Backoff: "500ms"}))
+
+		Expect(fields).To(Equal([]string{
+			"insecure migrated to skip_ssl_validation",
+			"teams[0].insecure dropped in favour of skip_ssl_validation",
+			"targets[0].teams[0].insecure migrated to skip_ssl_validation",
+			"retry_attempts: 2 migrated to retries.max_attempts: 3",
+			"retry_backoff migrated to retries.backoff",
+		}))
+
+		policy, err := migrated.RetryPolicy()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Retries).To(Equal(2))
+	})
+
+	It("migrates nothing when no deprecated fields are used", func() {
+		source := concourse.Source{Schema: concourse.SchemaV1, SkipSSLValidation: "true"}
+
+		migrated, fields, err := source.Migrated()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+		Expect(migrated.Schema).To(Equal(concourse.SchemaV2))
+		Expect(migrated.SkipSSLValidation).To(Equal(concourse.Bool("true")))
+	})
+
+	It("returns v2 sources as they are", func() {
+		source := concourse.Source{Schema: concourse.SchemaV2, Retries: &concourse.Retries{MaxAttempts: 2}}
+
+		migrated, fields, err := source.Migrated()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fields).To(BeEmpty())
+		Expect(migrated).To(Equal(source))
+	})
+
+	It("rejects deprecated fields in v2 sources", func() {
+		source := concourse.Source{
+			Schema:  concourse.SchemaV2,
+			Targets: []concourse.Target{{Name: "eu", Insecure: "true"}},
+		}
+
+		_, _, err := source.Migrated()
+		Expect(err).To(MatchError("targets[0].insecure is not supported by schema v2, see the README for its replacement"))
+	})
+
+	It("rejects a negative retry_attempts", func() {
+		_, _, err := concourse.Source{RetryAttempts: -1}.Migrated()
+		Expect(err).To(MatchError("retry_attempts must not be negative"))
+	})
+
+	It("rejects retry_attempts given along with retries", func() {
+		_, _, err := concourse.Source{RetryBackoff: "1s", Retries: &concourse.Retries{MaxAttempts: 2}}.Migrated()
+		Expect(err).To(MatchError("only one of retries or retry_attempts and retry_backoff may be provided in source"))
+	})
+
+	It("rejects unknown schemas", func() {
+		_, _, err := concourse.Source{Schema: "v3"}.Migrated()
+		Expect(err).To(MatchError("schema must be one of v1 or v2, got: 'v3'"))
+	})
+})
@@ -0,0 +1,35 @@
+package concourse
+
+// SelfTarget is the target standing for the Concourse running the build.
+const SelfTarget = "self"
+
+// WithSelfTarget returns a copy of the source in which each target given as
+// SelfTarget is replaced with the external URL of the Concourse running the
+// build. If no teams are given for it, its single team is that of the build,
+// if known, and takes the credentials given in the source.
+func (s Source) WithSelfTarget(externalURL string, buildTeamName string) Source {
+	if s.Target == SelfTarget {
+		s.Target = externalURL
+
+		if buildTeamName != "" {
+			var defaultTeam bool
+			s, defaultTeam = s.WithDefaultTeam()
+			if defaultTeam {
+				s.Teams[0].Name = buildTeamName
+			}
+		}
+	}
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			if t.Target == SelfTarget {
+				t.Target = externalURL
+			}
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s
+}
@@ -0,0 +1,62 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithSelfTarget", func() {
+	It("targets the Concourse running the build with the build's team", func() {
+		source := concourse.Source{Target: "self", Token: "some-token"}
+
+		self := source.WithSelfTarget("https://ci.example.com", "some-team")
+
+		Expect(self.Target).To(Equal("https://ci.example.com"))
+		Expect(self.Teams).To(Equal([]concourse.Team{{Name: "some-team", Token: "some-token"}}))
+		Expect(self.Token).To(BeEmpty())
+
+		Expect(source.Target).To(Equal("self"))
+	})
+
+	It("leaves the teams given alone", func() {
+		source := concourse.Source{
+			Target: "self",
+			Teams:  []concourse.Team{{Name: "main", Token: "some-token"}},
+		}
+
+		self := source.WithSelfTarget("https://ci.example.com", "some-team")
+
+		Expect(self.Teams).To(Equal(source.Teams))
+	})
+
+	It("leaves the teams to default when the build's team is not known", func() {
+		source := concourse.Source{Target: "self", Token: "some-token"}
+
+		self := source.WithSelfTarget("https://ci.example.com", "")
+
+		Expect(self.Teams).To(BeEmpty())
+		Expect(self.Token).To(Equal("some-token"))
+	})
+
+	It("replaces targets given as self", func() {
+		source := concourse.Source{
+			Targets: []concourse.Target{
+				{Name: "local", Target: "self"},
+				{Name: "eu", Target: "https://eu.example.com"},
+			},
+		}
+
+		self := source.WithSelfTarget("https://ci.example.com", "some-team")
+
+		Expect(self.Targets[0].Target).To(Equal("https://ci.example.com"))
+		Expect(self.Targets[1].Target).To(Equal("https://eu.example.com"))
+		Expect(source.Targets[0].Target).To(Equal("self"))
+	})
+
+	It("leaves other targets alone", func() {
+		source := concourse.Source{Target: "https://eu.example.com"}
+
+		Expect(source.WithSelfTarget("https://ci.example.com", "some-team")).To(Equal(source))
+	})
+})
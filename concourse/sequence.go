@@ -0,0 +1,52 @@
+package concourse
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// SequenceKey is the key of the entry of versions holding their sequence,
+// which cannot collide with the key of a pipeline as it has no team.
+const SequenceKey = "sequence"
+
+// WithSequence returns a copy of the version with a sequence entry, which is
+// greater in every newer version: the sequence of the previous version if it
+// holds the same pipelines, or else the current time in nanoseconds since
+// the Unix epoch, or one more than the previous sequence if that is greater.
+// previous may be nil.
+func (v Version) WithSequence(previous Version, now time.Time) Version {
+	previousSequence, err := strconv.ParseInt(previous[SequenceKey], 10, 64)
+	if err != nil {
+		previousSequence = 0
+	}
+
+	sequence := now.UnixNano()
+	if previousSequence > 0 && v.samePipelines(previous) {
+		sequence = previousSequence
+	} else if sequence <= previousSequence {
+		sequence = previousSequence + 1
+	}
+
+	sequenced := v.withoutSequence()
+	sequenced[SequenceKey] = strconv.FormatInt(sequence, 10)
+
+	return sequenced
+}
+
+// samePipelines tells whether the versions hold the same pipelines with the
+// same checksums, ignoring any sequence.
+func (v Version) samePipelines(other Version) bool {
+	return reflect.DeepEqual(v.withoutSequence(), other.withoutSequence())
+}
+
+func (v Version) withoutSequence() Version {
+	pipelines := make(Version, len(v))
+	for k, c := range v {
+		if k != SequenceKey {
+			pipelines[k] = c
+		}
+	}
+
+	return pipelines
+}
@@ -0,0 +1,47 @@
+package concourse_test
+
+import (
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithSequence", func() {
+	var (
+		now     time.Time
+		version concourse.Version
+	)
+
+	BeforeEach(func() {
+		now = time.Unix(0, 2000)
+		version = concourse.Version{"main/some-pipeline": "abc"}
+	})
+
+	It("uses the current time without a previous version", func() {
+		Expect(version.WithSequence(nil, now)).To(Equal(concourse.Version{
+			"main/some-pipeline": "abc",
+			"sequence":           "2000",
+		}))
+		Expect(version).NotTo(HaveKey("sequence"))
+	})
+
+	It("keeps the sequence of a previous version holding the same pipelines", func() {
+		previous := concourse.Version{"main/some-pipeline": "abc", "sequence": "1000"}
+
+		Expect(version.WithSequence(previous, now)).To(Equal(previous))
+	})
+
+	It("uses the current time when the pipelines have changed", func() {
+		previous := concourse.Version{"main/some-pipeline": "def", "sequence": "1000"}
+
+		Expect(version.WithSequence(previous, now)[concourse.SequenceKey]).To(Equal("2000"))
+	})
+
+	It("is greater than the previous sequence even if the clock is behind", func() {
+		previous := concourse.Version{"main/other-pipeline": "abc", "sequence": "5000"}
+
+		Expect(version.WithSequence(previous, now)[concourse.SequenceKey]).To(Equal("5001"))
+	})
+})
@@ -0,0 +1,44 @@
+package concourse
+
+// WithSkipSSLValidation returns a copy of the source in which the insecure
+// settings of the source, its targets and teams are replaced by their
+// skip_ssl_validation aliases wherever those are given, as they take
+// precedence. Insecure is kept for sources configured by a flyrc target.
+func (s Source) WithSkipSSLValidation() Source {
+	s.Insecure = resolveInsecure(s.Insecure, s.SkipSSLValidation)
+	s.Teams = teamsWithSkipSSLValidation(s.Teams)
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Insecure = resolveInsecure(t.Insecure, t.SkipSSLValidation)
+			t.Teams = teamsWithSkipSSLValidation(t.Teams)
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s
+}
+
+func teamsWithSkipSSLValidation(teams []Team) []Team {
+	if teams == nil {
+		return nil
+	}
+
+	resolved := make([]Team, len(teams))
+	for i, t := range teams {
+		t.Insecure = resolveInsecure(t.Insecure, t.SkipSSLValidation)
+		resolved[i] = t
+	}
+
+	return resolved
+}
+
+func resolveInsecure(insecure Bool, skipSSLValidation Bool) Bool {
+	if skipSSLValidation != "" {
+		return skipSSLValidation
+	}
+
+	return insecure
+}
@@ -0,0 +1,43 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithSkipSSLValidation", func() {
+	It("uses skip_ssl_validation in place of insecure", func() {
+		source := concourse.Source{
+			SkipSSLValidation: "true",
+			Teams: []concourse.Team{
+				{Name: "main", SkipSSLValidation: "false"},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", SkipSSLValidation: "true"},
+			},
+		}
+
+		resolved := source.WithSkipSSLValidation()
+
+		Expect(resolved.Insecure).To(Equal(concourse.Bool("true")))
+		Expect(resolved.Teams[0].Insecure).To(Equal(concourse.Bool("false")))
+		Expect(resolved.Targets[0].Insecure).To(Equal(concourse.Bool("true")))
+	})
+
+	It("prefers skip_ssl_validation when both are given", func() {
+		source := concourse.Source{Insecure: "true", SkipSSLValidation: "false"}
+
+		resolved := source.WithSkipSSLValidation()
+		Expect(resolved.Insecure).To(Equal(concourse.Bool("false")))
+	})
+
+	It("keeps insecure when skip_ssl_validation is not given", func() {
+		source := concourse.Source{
+			Teams: []concourse.Team{{Name: "main", Insecure: "true"}},
+		}
+
+		resolved := source.WithSkipSSLValidation()
+		Expect(resolved.Teams[0].Insecure).To(Equal(concourse.Bool("true")))
+	})
+})
@@ -0,0 +1,220 @@
+package concourse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTeamName is the team used when neither teams nor targets are given.
+const DefaultTeamName = "main"
+
+// Log formats, which set how the resource log is written.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// OutputLogLevel returns the level of the lines of the resource log which are
+// also written to the build output: LogLevel, or else info in quiet mode, or
+// else debug in debug mode, or else info.
+func (s Source) OutputLogLevel() string {
+	if s.LogLevel != "" {
+		return s.LogLevel
+	}
+
+	if s.Quiet {
+		return "info"
+	}
+
+	if s.Debug {
+		return "debug"
+	}
+
+	return "info"
+}
+
+// RequestTimeoutDuration parses RequestTimeout, returning zero if it is not
+// set.
+func (s Source) RequestTimeoutDuration() (time.Duration, error) {
+	if s.RequestTimeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s.RequestTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("request_timeout must be a duration such as 30s or 5m: %v", err)
+	}
+
+	return d, nil
+}
+
+// ConnectTimeoutDuration parses ConnectTimeout, returning zero if it is not
+// set.
+func (s Source) ConnectTimeoutDuration() (time.Duration, error) {
+	if s.ConnectTimeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s.ConnectTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("connect_timeout must be a duration such as 30s or 1m: %v", err)
+	}
+
+	return d, nil
+}
+
+// IdleTimeoutDuration parses IdleTimeout, returning zero if it is not set.
+func (s Source) IdleTimeoutDuration() (time.Duration, error) {
+	if s.IdleTimeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s.IdleTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("idle_timeout must be a duration such as 90s or 5m: %v", err)
+	}
+
+	return d, nil
+}
+
+// IncludesPipeline reports whether the pipeline is managed by the resource:
+// it must be in Include, if that is set, and must not be in Exclude.
+func (s Source) IncludesPipeline(pipelineName string) bool {
+	if len(s.Include) > 0 && !contains(s.Include, pipelineName) {
+		return false
+	}
+
+	return !contains(s.Exclude, pipelineName)
+}
+
+// IncludesPipeline reports whether the pipeline of the team is managed by the
+// resource: it must be in Pipelines, if that is set.
+func (t Team) IncludesPipeline(pipelineName string) bool {
+	return len(t.Pipelines) == 0 || contains(t.Pipelines, pipelineName)
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithDefaultTeam returns the source with a single team, DefaultTeamName,
+// logged in to with the top-level credentials, if neither Teams nor Targets
+// are given. It reports whether the default team was applied.
+func (s Source) WithDefaultTeam() (Source, bool) {
+	if len(s.Teams) > 0 || len(s.Targets) > 0 {
+		return s, false
+	}
+
+	s.Teams = []Team{{
+		Name:         DefaultTeamName,
+		Username:     s.Username,
+		Password:     s.Password,
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		Token:        s.Token,
+
+		KubernetesSecret: s.KubernetesSecret,
+	}}
+
+	s.Username = ""
+	s.Password = ""
+	s.ClientID = ""
+	s.ClientSecret = ""
+	s.Token = ""
+	s.KubernetesSecret = nil
+
+	return s, true
+}
+
+// AllTargets returns the targets configured in Targets, inheriting Insecure
+// and TeamPattern unless they override them. If there are none, it returns a
+// single unnamed target made up of Target, APIURL, Insecure, Teams and
+// TeamPattern.
+func (s Source) AllTargets() []Target {
+	if len(s.Targets) == 0 {
+		return []Target{{
+			Target:      s.Target,
+			APIURL:      s.APIURL,
+			Insecure:    s.Insecure,
+			Teams:       s.Teams,
+			TeamPattern: s.TeamPattern,
+		}}
+	}
+
+	targets := make([]Target, len(s.Targets))
+	for i, t := range s.Targets {
+		if t.Insecure == "" {
+			t.Insecure = s.Insecure
+		}
+		if t.TeamPattern == "" {
+			t.TeamPattern = s.TeamPattern
+		}
+		targets[i] = t
+	}
+
+	return targets
+}
+
+// APIEndpoint returns the URL at which the resource reaches the ATC: APIURL
+// if it is set, such as an internal address, or else Target.
+func (t Target) APIEndpoint() string {
+	if t.APIURL != "" {
+		return t.APIURL
+	}
+
+	return t.Target
+}
+
+// PipelineURL returns the URL of the pipeline in the web UI, which is always
+// at Target rather than APIURL, as it is meant for people to follow.
+func (t Target) PipelineURL(teamName string, pipelineName string, instanceVars map[string]interface{}) string {
+	u := fmt.Sprintf(
+		"%s/teams/%s/pipelines/%s",
+		strings.TrimSuffix(t.Target, "/"),
+		url.PathEscape(teamName),
+		url.PathEscape(pipelineName),
+	)
+
+	if len(instanceVars) == 0 {
+		return u
+	}
+
+	keys := make([]string, 0, len(instanceVars))
+	for k := range instanceVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		b, _ := json.Marshal(instanceVars[k])
+		params = append(params, url.QueryEscape("vars."+k)+"="+url.QueryEscape(string(b)))
+	}
+
+	return u + "?" + strings.Join(params, "&")
+}
+
+// InsecureSkipVerify parses Insecure, returning false if it is not set.
+func (t Target) InsecureSkipVerify() (bool, error) {
+	return t.Insecure.Parse()
+}
+
+// MetadataName returns the name prefixed with the name of the target, if it
+// has one, so that metadata of different targets do not collide.
+func (t Target) MetadataName(name string) string {
+	if t.Name == "" {
+		return name
+	}
+
+	return t.Name + "/" + name
+}
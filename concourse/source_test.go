@@ -0,0 +1,77 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithDefaultTeam", func() {
+	It("uses the main team with the top-level credentials when no teams are given", func() {
+		source := concourse.Source{
+			Target:   "https://ci.example.com",
+			Username: "admin",
+			Password: "some-password",
+		}
+
+		defaulted, applied := source.WithDefaultTeam()
+		Expect(applied).To(BeTrue())
+
+		Expect(defaulted.Teams).To(Equal([]concourse.Team{
+			{Name: "main", Username: "admin", Password: "some-password"},
+		}))
+		Expect(defaulted.Username).To(BeEmpty())
+		Expect(defaulted.Password).To(BeEmpty())
+	})
+
+	It("leaves the source as it is when teams are given", func() {
+		source := concourse.Source{
+			Teams: []concourse.Team{{Name: "some-team"}},
+		}
+
+		defaulted, applied := source.WithDefaultTeam()
+		Expect(applied).To(BeFalse())
+		Expect(defaulted).To(Equal(source))
+	})
+
+	It("leaves the source as it is when targets are given", func() {
+		source := concourse.Source{
+			Targets: []concourse.Target{{Name: "eu", Teams: []concourse.Team{{Name: "main"}}}},
+		}
+
+		defaulted, applied := source.WithDefaultTeam()
+		Expect(applied).To(BeFalse())
+		Expect(defaulted).To(Equal(source))
+	})
+})
+
+var _ = Describe("PipelineURL", func() {
+	It("links to the pipeline of the team at the target", func() {
+		target := concourse.Target{Target: "https://ci.example.com/", APIURL: "http://web.internal:8080"}
+
+		Expect(target.PipelineURL("main", "some pipeline", nil)).To(Equal("https://ci.example.com/teams/main/pipelines/some%20pipeline"))
+	})
+
+	It("identifies instanced pipelines by their instance vars", func() {
+		target := concourse.Target{Target: "https://ci.example.com"}
+
+		Expect(target.PipelineURL("main", "deploy", map[string]interface{}{"branch": "main", "n": 1})).To(Equal(
+			"https://ci.example.com/teams/main/pipelines/deploy?vars.branch=%22main%22&vars.n=1",
+		))
+	})
+})
+
+var _ = Describe("OutputLogLevel", func() {
+	It("is the log level, or else debug in debug mode, or else info", func() {
+		Expect(concourse.Source{}.OutputLogLevel()).To(Equal("info"))
+		Expect(concourse.Source{Debug: true}.OutputLogLevel()).To(Equal("debug"))
+		Expect(concourse.Source{Debug: true, LogLevel: "warn"}.OutputLogLevel()).To(Equal("warn"))
+	})
+
+	It("is info in quiet mode, unless the log level is set", func() {
+		Expect(concourse.Source{Quiet: true}.OutputLogLevel()).To(Equal("info"))
+		Expect(concourse.Source{Quiet: true, Debug: true}.OutputLogLevel()).To(Equal("info"))
+		Expect(concourse.Source{Quiet: true, LogLevel: "debug"}.OutputLogLevel()).To(Equal("debug"))
+		Expect(concourse.Source{Quiet: true, LogLevel: "warn"}.OutputLogLevel()).To(Equal("warn"))
+	})
+})
@@ -0,0 +1,70 @@
+package concourse
+
+// WithTeamDefaults returns a copy of the source in which TeamDefaults is
+// merged into each team, including those of targets. Credentials are only
+// taken from the defaults by teams without any of their own, so that a team
+// can use a different auth method. Vars are merged, with those of the team
+// taking precedence.
+func (s Source) WithTeamDefaults() Source {
+	if s.TeamDefaults == nil {
+		return s
+	}
+
+	s.Teams = teamsWithDefaults(s.Teams, *s.TeamDefaults)
+
+	if s.Targets != nil {
+		targets := make([]Target, len(s.Targets))
+		for i, t := range s.Targets {
+			t.Teams = teamsWithDefaults(t.Teams, *s.TeamDefaults)
+			targets[i] = t
+		}
+		s.Targets = targets
+	}
+
+	return s
+}
+
+func teamsWithDefaults(teams []Team, defaults Team) []Team {
+	if teams == nil {
+		return nil
+	}
+
+	merged := make([]Team, len(teams))
+	for i, t := range teams {
+		if t.Username == "" && t.Password == "" && t.ClientID == "" && t.ClientSecret == "" && t.Token == "" && t.KubernetesSecret == nil {
+			t.Username = defaults.Username
+			t.Password = defaults.Password
+			t.ClientID = defaults.ClientID
+			t.ClientSecret = defaults.ClientSecret
+			t.Token = defaults.Token
+			t.KubernetesSecret = defaults.KubernetesSecret
+		}
+
+		if t.Insecure == "" {
+			t.Insecure = defaults.Insecure
+		}
+
+		if t.SkipSSLValidation == "" {
+			t.SkipSSLValidation = defaults.SkipSSLValidation
+		}
+
+		if t.Pipelines == nil {
+			t.Pipelines = defaults.Pipelines
+		}
+
+		if defaults.Vars != nil {
+			vars := make(map[string]interface{})
+			for k, v := range defaults.Vars {
+				vars[k] = v
+			}
+			for k, v := range t.Vars {
+				vars[k] = v
+			}
+			t.Vars = vars
+		}
+
+		merged[i] = t
+	}
+
+	return merged
+}
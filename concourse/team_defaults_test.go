@@ -0,0 +1,59 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithTeamDefaults", func() {
+	It("merges the defaults into every team", func() {
+		source := concourse.Source{
+			TeamDefaults: &concourse.Team{
+				ClientID:     "some-client",
+				ClientSecret: "some-secret",
+				Insecure:     "true",
+				Pipelines:    []string{"deploy"},
+				Vars:         map[string]interface{}{"channel": "#ci", "env": "prod"},
+			},
+			Teams: []concourse.Team{
+				{Name: "team-1"},
+				{
+					Name:      "team-2",
+					Username:  "some-user",
+					Password:  "some-password",
+					Insecure:  "false",
+					Pipelines: []string{"build"},
+					Vars:      map[string]interface{}{"env": "staging"},
+				},
+			},
+			Targets: []concourse.Target{
+				{Name: "eu", Teams: []concourse.Team{{Name: "team-3"}}},
+			},
+		}
+
+		merged := source.WithTeamDefaults()
+
+		Expect(merged.Teams[0]).To(Equal(concourse.Team{
+			Name:         "team-1",
+			ClientID:     "some-client",
+			ClientSecret: "some-secret",
+			Insecure:     "true",
+			Pipelines:    []string{"deploy"},
+			Vars:         map[string]interface{}{"channel": "#ci", "env": "prod"},
+		}))
+
+		Expect(merged.Teams[1]).To(Equal(concourse.Team{
+			Name:      "team-2",
+			Username:  "some-user",
+			Password:  "some-password",
+			Insecure:  "false",
+			Pipelines: []string{"build"},
+			Vars:      map[string]interface{}{"channel": "#ci", "env": "staging"},
+		}))
+
+		Expect(merged.Targets[0].Teams[0].ClientID).To(Equal("some-client"))
+
+		Expect(source.Teams[0].ClientID).To(BeEmpty())
+	})
+})
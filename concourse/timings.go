@@ -0,0 +1,75 @@
+package concourse
+
+import (
+	"sync"
+	"time"
+)
+
+// Phases of a run whose durations are recorded by Timings.
+const (
+	PhaseSync     = "sync"
+	PhaseLogin    = "login"
+	PhaseList     = "list"
+	PhaseFetch    = "fetch"
+	PhaseSet      = "set"
+	PhaseChecksum = "checksum"
+)
+
+// Timings records the total time spent in each phase of a run, so that slow
+// runs can be diagnosed. A nil Timings records nothing.
+type Timings struct {
+	mutex  sync.Mutex
+	phases []string
+	totals map[string]time.Duration
+}
+
+func NewTimings() *Timings {
+	return &Timings{
+		totals: make(map[string]time.Duration),
+	}
+}
+
+// Start starts timing the phase, returning a function which stops timing it
+// and returns the time taken.
+func (t *Timings) Start(phase string) func() time.Duration {
+	start := time.Now()
+
+	return func() time.Duration {
+		d := time.Since(start)
+		t.Add(phase, d)
+		return d
+	}
+}
+
+// Add adds the duration to the total of the phase.
+func (t *Timings) Add(phase string, d time.Duration) {
+	if t == nil {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, ok := t.totals[phase]; !ok {
+		t.phases = append(t.phases, phase)
+	}
+	t.totals[phase] += d
+}
+
+// MetadataEntries returns an entry named "<phase> duration" holding the total
+// time spent in each phase, in the order they were first timed.
+func (t *Timings) MetadataEntries() []MetadataEntry {
+	if t == nil {
+		return nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	entries := make([]MetadataEntry, 0, len(t.phases))
+	for _, phase := range t.phases {
+		entries = append(entries, NewMetadataEntry(phase+" duration", t.totals[phase].Round(time.Millisecond).String()))
+	}
+
+	return entries
+}
@@ -0,0 +1,36 @@
+package concourse_test
+
+import (
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timings", func() {
+	It("totals the time spent in each phase, in the order first timed", func() {
+		timings := concourse.NewTimings()
+		timings.Add("login", 1500*time.Millisecond)
+		timings.Add("fetch", 200*time.Millisecond)
+		timings.Add("login", 250*time.Millisecond)
+
+		d := timings.Start("checksum")()
+		Expect(d).To(BeNumerically(">=", 0))
+
+		entries := timings.MetadataEntries()
+		Expect(concourse.MetadataOf(entries[:3])).To(Equal([]concourse.Metadata{
+			{Name: "login duration", Value: "1.75s"},
+			{Name: "fetch duration", Value: "200ms"},
+			{Name: "checksum duration", Value: d.Round(time.Millisecond).String()},
+		}))
+	})
+
+	It("records nothing when nil", func() {
+		var timings *concourse.Timings
+		timings.Add("login", time.Second)
+		timings.Start("fetch")()
+
+		Expect(timings.MetadataEntries()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,50 @@
+package concourse
+
+import (
+	"os"
+	"strings"
+)
+
+const (
+	otlpEndpointEnvKey       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnvKey = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+
+	otlpTracesPath = "/v1/traces"
+)
+
+// Tracing configures the export of traces of the resource over OTLP/HTTP.
+type Tracing struct {
+	// Endpoint is the base URL of the collector, to which /v1/traces is
+	// appended, as for OTEL_EXPORTER_OTLP_ENDPOINT.
+	Endpoint string            `json:"endpoint"`
+	Headers  map[string]string `json:"headers"`
+}
+
+// TracesEndpoint returns the URL to which traces are exported, taken from
+// tracing.endpoint, or else from the standard OpenTelemetry environment
+// variables. ok is false if traces are not exported.
+func (s Source) TracesEndpoint() (endpoint string, ok bool) {
+	if s.Tracing != nil && s.Tracing.Endpoint != "" {
+		return strings.TrimRight(s.Tracing.Endpoint, "/") + otlpTracesPath, true
+	}
+
+	if e := os.Getenv(otlpTracesEndpointEnvKey); e != "" {
+		return e, true
+	}
+
+	if e := os.Getenv(otlpEndpointEnvKey); e != "" {
+		return strings.TrimRight(e, "/") + otlpTracesPath, true
+	}
+
+	return "", false
+}
+
+// TracingHeaders returns the headers sent along with exported traces, e.g.
+// to authenticate with the collector.
+func (s Source) TracingHeaders() map[string]string {
+	if s.Tracing == nil {
+		return nil
+	}
+
+	return s.Tracing.Headers
+}
@@ -0,0 +1,43 @@
+package concourse_test
+
+import (
+	"os"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TracesEndpoint", func() {
+	AfterEach(func() {
+		os.Unsetenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		os.Unsetenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	})
+
+	It("is not set by default", func() {
+		_, ok := concourse.Source{}.TracesEndpoint()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("appends the path of traces to the endpoint in the source", func() {
+		os.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://env:4318/v1/traces")
+
+		endpoint, ok := concourse.Source{Tracing: &concourse.Tracing{Endpoint: "http://collector:4318/"}}.TracesEndpoint()
+		Expect(ok).To(BeTrue())
+		Expect(endpoint).To(Equal("http://collector:4318/v1/traces"))
+	})
+
+	It("falls back to the environment", func() {
+		os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://env:4318")
+
+		endpoint, ok := concourse.Source{}.TracesEndpoint()
+		Expect(ok).To(BeTrue())
+		Expect(endpoint).To(Equal("http://env:4318/v1/traces"))
+
+		os.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "http://env:4318/custom")
+
+		endpoint, ok = concourse.Source{}.TracesEndpoint()
+		Expect(ok).To(BeTrue())
+		Expect(endpoint).To(Equal("http://env:4318/custom"))
+	})
+})
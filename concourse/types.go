@@ -1,15 +1,117 @@
 package concourse
 
 type Source struct {
+	Schema string `json:"schema"`
+
+	Target    string `json:"target"`
+	APIURL    string `json:"api_url"`
+	Teams     []Team `json:"teams"`
+	Insecure  Bool   `json:"insecure"`
+	CACert    string `json:"ca_cert"`
+	Verbose   bool   `json:"verbose"`
+	Debug     bool   `json:"debug"`
+	LogFormat string `json:"log_format"`
+	LogLevel  string `json:"log_level"`
+	Quiet     bool   `json:"quiet"`
+	FlyHome   string `json:"fly_home"`
+	FlySHA256 string `json:"fly_sha256"`
+	WorkDir   string `json:"work_dir"`
+
+	SkipSSLValidation Bool `json:"skip_ssl_validation"`
+
+	Flyrc string `json:"flyrc"`
+
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	SecretVars []string `json:"secret_vars"`
+
+	DisableSanitizerForDebug bool `json:"disable_sanitizer_for_debug"`
+
+	LogRequests      bool `json:"log_requests"`
+	LogRequestBodies bool `json:"log_request_bodies"`
+
+	HTTPProxy  string `json:"http_proxy"`
+	HTTPSProxy string `json:"https_proxy"`
+	NoProxy    string `json:"no_proxy"`
+
+	RequestTimeout string `json:"request_timeout"`
+	ConnectTimeout string `json:"connect_timeout"`
+	IdleTimeout    string `json:"idle_timeout"`
+
+	// Deprecated: RetryAttempts and RetryBackoff are only read by Migrated,
+	// which replaces them with Retries.
+	RetryAttempts int    `json:"retry_attempts"`
+	RetryBackoff  string `json:"retry_backoff"`
+
+	Retries *Retries `json:"retries"`
+
+	RequestsPerSecond float64 `json:"requests_per_second"`
+
+	Tracing *Tracing `json:"tracing"`
+
+	Lint *Lint `json:"lint"`
+
+	AWS *AWS `json:"aws"`
+
+	Targets []Target `json:"targets"`
+
+	TeamDefaults *Team `json:"team_defaults"`
+
+	TeamPattern string `json:"team_pattern"`
+
+	ExpandEnv bool `json:"expand_env"`
+
+	ExpectPipelines bool `json:"expect_pipelines"`
+
+	Checksum          string `json:"checksum"`
+	CanonicalChecksum bool   `json:"canonical_checksum"`
+
+	VersionScheme   string `json:"version_scheme"`
+	VersionSequence bool   `json:"version_sequence"`
+	VersionMode     string `json:"version_mode"`
+
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Token        string `json:"token"`
+
+	KubernetesSecret *KubernetesSecret `json:"kubernetes_secret"`
+}
+
+// Target is one of several Concourse clusters whose pipelines are managed by
+// the resource.
+type Target struct {
+	Name     string `json:"name"`
 	Target   string `json:"target"`
+	APIURL   string `json:"api_url"`
+	Insecure Bool   `json:"insecure"`
 	Teams    []Team `json:"teams"`
-	Insecure string `json:"insecure"`
+
+	SkipSSLValidation Bool `json:"skip_ssl_validation"`
+
+	TeamPattern string `json:"team_pattern"`
 }
 
 type Team struct {
-	Name     string `json:"name"`
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Name         string `json:"name"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Token        string `json:"token"`
+	Insecure     Bool   `json:"insecure"`
+
+	SkipSSLValidation Bool `json:"skip_ssl_validation"`
+
+	KubernetesSecret *KubernetesSecret `json:"kubernetes_secret"`
+
+	Pipelines []string               `json:"pipelines"`
+	Vars      map[string]interface{} `json:"vars"`
 }
 
 type CheckRequest struct {
@@ -27,7 +129,18 @@ type InRequest struct {
 	Params  InParams `json:"params"`
 }
 
+// Formats in which in renders the graphs of pipelines.
+const (
+	GraphFormatDOT = "dot"
+	GraphFormatSVG = "svg"
+)
+
 type InParams struct {
+	IncludeStatus  bool   `json:"include_status"`
+	Canonical      bool   `json:"canonical"`
+	Graph          string `json:"graph,omitempty"`
+	ValidateSchema bool   `json:"validate_schema"`
+	LogFile        string `json:"log_file,omitempty"`
 }
 
 type InResponse struct {
@@ -46,8 +159,12 @@ type OutRequest struct {
 }
 
 type OutParams struct {
-	Pipelines     []Pipeline `json:"pipelines,omitempty"`
-	PipelinesFile string     `json:"pipelines_file,omitempty"`
+	Pipelines      []Pipeline `json:"pipelines,omitempty"`
+	PipelinesFile  string     `json:"pipelines_file,omitempty"`
+	LogFile        string     `json:"log_file,omitempty"`
+	AuditFile      string     `json:"audit_file,omitempty"`
+	ValidateSchema bool       `json:"validate_schema"`
+	DryRun         bool       `json:"dry_run"`
 }
 
 type Pipeline struct {
@@ -56,6 +173,7 @@ type Pipeline struct {
 	VarsFiles  []string               `json:"vars_files" yaml:"vars_files"`
 	Vars       map[string]interface{} `json:"vars" yaml:"vars"`
 	TeamName   string                 `json:"team" yaml:"team"`
+	Target     string                 `json:"target" yaml:"target"`
 	Unpaused   bool                   `json:"unpaused" yaml:"unpaused"`
 	Exposed    bool                   `json:"exposed" yaml:"exposed"`
 }
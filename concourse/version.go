@@ -0,0 +1,110 @@
+package concourse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PipelineVersion is the version of a single pipeline. A Version holds one
+// entry per pipeline, keyed by Key and valued by Checksum, as Concourse
+// requires versions to be flat maps of strings.
+type PipelineVersion struct {
+	Target       string
+	Team         string
+	Pipeline     string
+	InstanceVars map[string]interface{}
+	Checksum     string
+}
+
+// Key returns the key identifying the pipeline in versions, of the form
+// [target/]team/pipeline[/instance vars], where the instance vars are given
+// as in fly's --pipeline flag, e.g. eu/main/some-pipeline/branch:main.
+func (v PipelineVersion) Key() string {
+	key := v.Team + "/" + v.Pipeline
+	if v.Target != "" {
+		key = v.Target + "/" + key
+	}
+
+	if len(v.InstanceVars) > 0 {
+		key += "/" + FormatInstanceVars(v.InstanceVars)
+	}
+
+	return key
+}
+
+// NewVersion returns the version holding each of the pipeline versions.
+func NewVersion(pipelines []PipelineVersion) Version {
+	version := make(Version)
+	for _, p := range pipelines {
+		version[p.Key()] = p.Checksum
+	}
+
+	return version
+}
+
+// Keys returns the keys of the version, sorted, so that anything derived from
+// the entries of a version does not depend on the order of iterating a map.
+func (v Version) Keys() []string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// MarshalJSON encodes the version as a JSON object with its keys sorted, so
+// that identical versions are always byte-identical when emitted.
+func (v Version) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range v.Keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(v[k])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// FormatInstanceVars returns the instance vars in the form accepted by fly's
+// --pipeline flag, e.g. branch:"main",version:1. Every value is encoded as
+// JSON, so that strings are quoted and cannot be taken for other types or
+// run into the separators.
+func FormatInstanceVars(instanceVars map[string]interface{}) string {
+	keys := make([]string, 0, len(instanceVars))
+	for k := range instanceVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	vars := make([]string, 0, len(keys))
+	for _, k := range keys {
+		b, _ := json.Marshal(instanceVars[k])
+		vars = append(vars, fmt.Sprintf("%s:%s", k, b))
+	}
+
+	return strings.Join(vars, ",")
+}
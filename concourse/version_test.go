@@ -0,0 +1,44 @@
+package concourse_test
+
+import (
+	"encoding/json"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	It("keys pipelines by target, team, name and instance vars", func() {
+		version := concourse.NewVersion([]concourse.PipelineVersion{
+			{Team: "main", Pipeline: "some-pipeline", Checksum: "abc"},
+			{Target: "eu", Team: "ops", Pipeline: "deploy", InstanceVars: map[string]interface{}{"branch": "main", "n": 1}, Checksum: "def"},
+		})
+
+		Expect(version).To(Equal(concourse.Version{
+			"main/some-pipeline":              "abc",
+			`eu/ops/deploy/branch:"main",n:1`: "def",
+		}))
+	})
+
+	It("is encoded as JSON with its keys sorted", func() {
+		version := concourse.Version{"main/b": "2", "eu/main/a": "1", "sequence": "3", "main/<a>": "4"}
+
+		b, err := json.Marshal(version)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`{"eu/main/a":"1","main/\u003ca\u003e":"4","main/b":"2","sequence":"3"}`))
+
+		var decoded concourse.Version
+		err = json.Unmarshal(b, &decoded)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(decoded).To(Equal(version))
+
+		b, err = json.Marshal(concourse.CheckResponse{nil, {}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(b)).To(Equal(`[null,{}]`))
+	})
+
+	It("returns its keys sorted", func() {
+		Expect(concourse.Version{"main/b": "2", "main/a": "1"}.Keys()).To(Equal([]string{"main/a", "main/b"}))
+	})
+})
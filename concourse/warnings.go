@@ -0,0 +1,55 @@
+package concourse
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Warnings collects problems found during a run which do not fail it, such
+// as deprecated fields or pipelines which were skipped, so that they can be
+// surfaced in the metadata rather than buried in the log. A nil Warnings
+// collects nothing.
+type Warnings struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func NewWarnings() *Warnings {
+	return &Warnings{}
+}
+
+// Add adds the warning.
+func (w *Warnings) Add(format string, a ...interface{}) {
+	if w == nil {
+		return
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.messages = append(w.messages, fmt.Sprintf(format, a...))
+}
+
+// Messages returns the warnings in the order they were added.
+func (w *Warnings) Messages() []string {
+	if w == nil {
+		return nil
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return append([]string(nil), w.messages...)
+}
+
+// MetadataEntries returns an entry named "warning" for each warning.
+func (w *Warnings) MetadataEntries() []MetadataEntry {
+	messages := w.Messages()
+
+	entries := make([]MetadataEntry, 0, len(messages))
+	for _, m := range messages {
+		entries = append(entries, NewMetadataEntry("warning", m))
+	}
+
+	return entries
+}
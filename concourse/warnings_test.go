@@ -0,0 +1,28 @@
+package concourse_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Warnings", func() {
+	It("returns an entry for each warning, in the order added", func() {
+		warnings := concourse.NewWarnings()
+		warnings.Add("first %s", "warning")
+		warnings.Add("second warning")
+
+		Expect(concourse.MetadataOf(warnings.MetadataEntries())).To(Equal([]concourse.Metadata{
+			{Name: "warning", Value: "first warning"},
+			{Name: "warning", Value: "second warning"},
+		}))
+	})
+
+	It("collects nothing when nil", func() {
+		var warnings *concourse.Warnings
+		warnings.Add("some warning")
+
+		Expect(warnings.Messages()).To(BeEmpty())
+		Expect(warnings.MetadataEntries()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,13 @@
+package configschema_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestConfigschema(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Configschema Suite")
+}
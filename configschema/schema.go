@@ -0,0 +1,178 @@
+package configschema
+
+// pipelineSchema is the JSON Schema of pipeline configs, covering the fields
+// documented for Concourse 7. Unknown fields of jobs, steps, resources,
+// resource types, groups and var sources are rejected, as fly only warns
+// about some of them.
+const pipelineSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "title": "Concourse pipeline config",
+  "type": "object",
+  "properties": {
+    "jobs": {"type": "array", "items": {"$ref": "#/definitions/job"}},
+    "resources": {"type": "array", "items": {"$ref": "#/definitions/resource"}},
+    "resource_types": {"type": "array", "items": {"$ref": "#/definitions/resource_type"}},
+    "groups": {"type": "array", "items": {"$ref": "#/definitions/group"}},
+    "var_sources": {"type": "array", "items": {"$ref": "#/definitions/var_source"}},
+    "display": {"type": "object"}
+  },
+  "definitions": {
+    "strings": {"type": "array", "items": {"type": "string"}},
+    "job": {
+      "type": "object",
+      "required": ["name", "plan"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "old_name": {"type": "string"},
+        "plan": {"type": "array", "items": {"$ref": "#/definitions/step"}},
+        "serial": {"type": "boolean"},
+        "serial_groups": {"$ref": "#/definitions/strings"},
+        "max_in_flight": {"type": "integer"},
+        "build_log_retention": {"type": "object"},
+        "build_logs_to_retain": {"type": "integer"},
+        "public": {"type": "boolean"},
+        "disable_manual_trigger": {"type": "boolean"},
+        "interruptible": {"type": "boolean"},
+        "on_success": {"$ref": "#/definitions/step"},
+        "on_failure": {"$ref": "#/definitions/step"},
+        "on_error": {"$ref": "#/definitions/step"},
+        "on_abort": {"$ref": "#/definitions/step"},
+        "ensure": {"$ref": "#/definitions/step"}
+      }
+    },
+    "step": {
+      "type": "object",
+      "additionalProperties": false,
+      "anyOf": [
+        {"required": ["get"]},
+        {"required": ["put"]},
+        {"required": ["task"]},
+        {"required": ["set_pipeline"]},
+        {"required": ["load_var"]},
+        {"required": ["in_parallel"]},
+        {"required": ["aggregate"]},
+        {"required": ["do"]},
+        {"required": ["try"]}
+      ],
+      "properties": {
+        "get": {"type": "string"},
+        "put": {"type": "string"},
+        "task": {"type": "string"},
+        "set_pipeline": {"type": "string"},
+        "load_var": {"type": "string"},
+        "in_parallel": {
+          "type": ["array", "object"],
+          "items": {"$ref": "#/definitions/step"},
+          "additionalProperties": false,
+          "properties": {
+            "steps": {"type": "array", "items": {"$ref": "#/definitions/step"}},
+            "limit": {"type": "integer"},
+            "fail_fast": {"type": "boolean"}
+          }
+        },
+        "aggregate": {"type": "array", "items": {"$ref": "#/definitions/step"}},
+        "do": {"type": "array", "items": {"$ref": "#/definitions/step"}},
+        "try": {"$ref": "#/definitions/step"},
+        "across": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "required": ["var"],
+            "additionalProperties": false,
+            "properties": {
+              "var": {"type": "string"},
+              "values": {"type": "array"},
+              "max_in_flight": {"type": ["integer", "string"]},
+              "fail_fast": {"type": "boolean"}
+            }
+          }
+        },
+        "resource": {"type": "string"},
+        "passed": {"$ref": "#/definitions/strings"},
+        "trigger": {"type": "boolean"},
+        "version": {"type": ["string", "object"]},
+        "params": {"type": "object"},
+        "inputs": {"type": ["string", "array"]},
+        "get_params": {"type": "object"},
+        "no_get": {"type": "boolean"},
+        "config": {"type": "object"},
+        "file": {"type": "string"},
+        "image": {"type": "string"},
+        "privileged": {"type": "boolean"},
+        "vars": {"type": "object"},
+        "var_files": {"$ref": "#/definitions/strings"},
+        "container_limits": {"type": "object"},
+        "input_mapping": {"type": "object"},
+        "output_mapping": {"type": "object"},
+        "team": {"type": "string"},
+        "instance_vars": {"type": "object"},
+        "format": {"type": "string"},
+        "reveal": {"type": "boolean"},
+        "timeout": {"type": "string"},
+        "attempts": {"type": "integer"},
+        "tags": {"$ref": "#/definitions/strings"},
+        "on_success": {"$ref": "#/definitions/step"},
+        "on_failure": {"$ref": "#/definitions/step"},
+        "on_error": {"$ref": "#/definitions/step"},
+        "on_abort": {"$ref": "#/definitions/step"},
+        "ensure": {"$ref": "#/definitions/step"}
+      }
+    },
+    "resource": {
+      "type": "object",
+      "required": ["name", "type"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "old_name": {"type": "string"},
+        "type": {"type": "string"},
+        "source": {"type": "object"},
+        "icon": {"type": "string"},
+        "version": {"type": "object"},
+        "check_every": {"type": "string"},
+        "check_timeout": {"type": "string"},
+        "expose_build_created_by": {"type": "boolean"},
+        "tags": {"$ref": "#/definitions/strings"},
+        "public": {"type": "boolean"},
+        "webhook_token": {"type": "string"}
+      }
+    },
+    "resource_type": {
+      "type": "object",
+      "required": ["name", "type"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "type": {"type": "string"},
+        "source": {"type": "object"},
+        "privileged": {"type": "boolean"},
+        "params": {"type": "object"},
+        "check_every": {"type": "string"},
+        "tags": {"$ref": "#/definitions/strings"},
+        "defaults": {"type": "object"},
+        "unique_version_history": {"type": "boolean"}
+      }
+    },
+    "group": {
+      "type": "object",
+      "required": ["name"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "jobs": {"$ref": "#/definitions/strings"},
+        "resources": {"$ref": "#/definitions/strings"}
+      }
+    },
+    "var_source": {
+      "type": "object",
+      "required": ["name", "type"],
+      "additionalProperties": false,
+      "properties": {
+        "name": {"type": "string"},
+        "type": {"type": "string"},
+        "config": {"type": "object"}
+      }
+    }
+  }
+}`
@@ -0,0 +1,244 @@
+// Package configschema validates pipeline configs against a bundled JSON
+// Schema, reporting each field which does not match it.
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// varRegexp matches values which are entirely a var, such as ((branch)),
+// which may be of any type once interpolated.
+var varRegexp = regexp.MustCompile(`^\(\(.+\)\)$`)
+
+// schema is the subset of JSON Schema used by pipelineSchema.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 types              `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	AnyOf                []*schema          `json:"anyOf"`
+	Definitions          map[string]*schema `json:"definitions"`
+}
+
+// types are the types allowed by a schema, given as either a single type or
+// a list of them.
+type types []string
+
+func (t *types) UnmarshalJSON(b []byte) error {
+	var single string
+	if json.Unmarshal(b, &single) == nil {
+		*t = types{single}
+		return nil
+	}
+
+	var list []string
+	err := json.Unmarshal(b, &list)
+	if err != nil {
+		return err
+	}
+	*t = list
+
+	return nil
+}
+
+var root = mustParse(pipelineSchema)
+
+func mustParse(s string) *schema {
+	var parsed schema
+	err := json.Unmarshal([]byte(s), &parsed)
+	if err != nil {
+		panic(fmt.Sprintf("parsing pipeline schema: %v", err))
+	}
+
+	return &parsed
+}
+
+// Validate returns the problems with each field of the config which does not
+// match the schema of pipeline configs, e.g.
+// "jobs[0].plan[1].trigger must be a boolean". Values which are entirely a
+// var are not checked, as their type is only known once interpolated. An
+// error is returned only if the config is not YAML.
+func Validate(config []byte) ([]string, error) {
+	var v interface{}
+	err := yaml.Unmarshal(config, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return validate(root, stringKeys(v), "config"), nil
+}
+
+func validate(s *schema, v interface{}, path string) []string {
+	if s.Ref != "" {
+		s = root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+
+	// Fields left empty are taken by Concourse to be unset.
+	if v == nil {
+		return nil
+	}
+
+	if str, ok := v.(string); ok && varRegexp.MatchString(str) {
+		return nil
+	}
+
+	if len(s.Type) > 0 && !hasType(v, s.Type) {
+		return []string{fmt.Sprintf("%s must be %s", path, describeTypes(s.Type))}
+	}
+
+	var problems []string
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, r := range s.Required {
+			if _, ok := v[r]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s must be provided", path, r))
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			property, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					problems = append(problems, fmt.Sprintf("%s.%s is not a known field", path, k))
+				}
+				continue
+			}
+
+			problems = append(problems, validate(property, v[k], path+"."+k)...)
+		}
+
+		if len(s.AnyOf) > 0 && !matchesAny(s.AnyOf, v, path) {
+			problems = append(problems, describeAnyOf(s.AnyOf, path))
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				problems = append(problems, validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+
+	return problems
+}
+
+func matchesAny(schemas []*schema, v interface{}, path string) bool {
+	for _, s := range schemas {
+		if len(validate(s, v, path)) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describeAnyOf describes the alternatives, naming the fields of which one
+// must be provided where that is all that they require.
+func describeAnyOf(schemas []*schema, path string) string {
+	var fields []string
+	for _, s := range schemas {
+		if len(s.Required) != 1 {
+			return fmt.Sprintf("%s must match one of the alternatives of the schema", path)
+		}
+		fields = append(fields, s.Required[0])
+	}
+
+	return fmt.Sprintf("%s must have one of %s", path, joinOr(fields))
+}
+
+func hasType(v interface{}, allowed types) bool {
+	for _, t := range allowed {
+		switch t {
+		case "object":
+			if _, ok := v.(map[string]interface{}); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]interface{}); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "integer":
+			switch n := v.(type) {
+			case int, int64, uint64:
+				return true
+			case float64:
+				if n == float64(int64(n)) {
+					return true
+				}
+			}
+		case "number":
+			switch v.(type) {
+			case int, int64, uint64, float64:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func describeTypes(allowed types) string {
+	described := make([]string, len(allowed))
+	for i, t := range allowed {
+		switch t {
+		case "object", "array", "integer":
+			described[i] = "an " + t
+		default:
+			described[i] = "a " + t
+		}
+	}
+
+	return joinOr(described)
+}
+
+func joinOr(s []string) string {
+	if len(s) == 1 {
+		return s[0]
+	}
+
+	return strings.Join(s[:len(s)-1], ", ") + " or " + s[len(s)-1]
+}
+
+// stringKeys converts the maps decoded from YAML, whose keys may be of any
+// type, to maps with string keys, as in JSON.
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = stringKeys(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,72 @@
+package configschema_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/configschema"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	It("accepts valid configs", func() {
+		problems, err := configschema.Validate([]byte(`---
+resources:
+- name: repo
+  type: git
+  source: {uri: ((uri))}
+jobs:
+- name: build
+  serial: true
+  plan:
+  - in_parallel:
+      limit: 2
+      steps:
+      - get: repo
+        trigger: ((trigger))
+  - task: unit
+    file: repo/ci/unit.yml
+    attempts: 2
+  on_failure:
+    put: repo
+    params: {}
+groups:
+- name: all
+  jobs: [build]
+`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(problems).To(BeEmpty())
+	})
+
+	DescribeTable("reporting each field which does not match",
+		func(config string, expectedProblems ...string) {
+			problems, err := configschema.Validate([]byte(config))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(Equal(expectedProblems))
+		},
+		Entry("a missing field",
+			"jobs: [{plan: []}]",
+			"config.jobs[0].name must be provided"),
+		Entry("an unknown field",
+			"resources: [{name: repo, type: git, sourse: {}}]",
+			"config.resources[0].sourse is not a known field"),
+		Entry("a field of the wrong type",
+			"jobs: [{name: build, plan: [{get: repo, trigger: yes please}]}]",
+			"config.jobs[0].plan[0].trigger must be a boolean"),
+		Entry("a field of none of several types",
+			"jobs: [{name: build, plan: [{in_parallel: parallel}]}]",
+			"config.jobs[0].plan[0].in_parallel must be an array or an object"),
+		Entry("a step which is none of the kinds of step",
+			"jobs: [{name: build, plan: [{timeout: 1h}]}]",
+			"config.jobs[0].plan[0] must have one of get, put, task, set_pipeline, load_var, in_parallel, aggregate, do or try"),
+		Entry("several problems",
+			"jobs: [{name: build, plan: [{put: image, on_success: {task: a, privileged: 1}}], public: nope}]\ngroups: [{}]",
+			"config.groups[0].name must be provided",
+			"config.jobs[0].plan[0].on_success.privileged must be a boolean",
+			"config.jobs[0].public must be a boolean"),
+	)
+
+	It("fails for configs which are not YAML", func() {
+		_, err := configschema.Validate([]byte("jobs: ["))
+		Expect(err).To(HaveOccurred())
+	})
+})
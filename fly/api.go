@@ -0,0 +1,398 @@
+package fly
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/tracing"
+)
+
+const (
+	apiPrefix = "/api/v1"
+
+	configVersionHeader = "X-Concourse-Config-Version"
+
+	// maxDrainLength limits how much of an unread response body is discarded
+	// to allow the connection to be reused, rather than closing it.
+	maxDrainLength = 64 * 1024
+)
+
+// apiRequest makes a request to the ATC API using the session of the most
+// recent login, for information that fly does not expose. If the session
+// has expired, it logs in again (obtaining a new token) and repeats the
+// request once.
+func (f *command) apiRequest(method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := f.retryingAPIRequest(method, path, header, body)
+	if err == nil || f.relogin == nil || !isUnauthorized(err) {
+		return resp, err
+	}
+
+	f.logger.Infof("Session expired, logging in again\n")
+	loginErr := f.relogin()
+	if loginErr != nil {
+		return nil, fmt.Errorf("%v (re-login failed: %v)", err, loginErr)
+	}
+
+	return f.retryingAPIRequest(method, path, header, body)
+}
+
+// retryingAPIRequest retries idempotent requests which fail with a possibly
+// transient error. These are GET requests, and PUT requests without a body,
+// which set a pipeline to a state such as paused regardless of its current
+// state.
+func (f *command) retryingAPIRequest(method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	if method != "GET" && (method != "PUT" || body != nil) {
+		return f.rateLimitedAPIRequest(method, path, header, body)
+	}
+
+	var resp *http.Response
+	err := f.retry(method+" "+path, func() error {
+		var err error
+		resp, err = f.rateLimitedAPIRequest(method, path, header, body)
+		return err
+	})
+
+	return resp, err
+}
+
+// rateLimitedError is the failure of a request which the ATC refused as it
+// is rate limiting requests, along with the Retry-After of the response.
+type rateLimitedError struct {
+	err        error
+	retryAfter string
+}
+
+func (e *rateLimitedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rateLimitedError) Unwrap() error {
+	return e.err
+}
+
+// rateLimitedAPIRequest makes the request, waiting and retrying while the ATC
+// responds that it is rate limiting requests, as runRateLimited does for fly.
+// Requests with a body are retried too, as the ATC refused them unread.
+func (f *command) rateLimitedAPIRequest(method string, path string, header http.Header, body []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := f.doAPIRequest(method, path, header, body)
+
+		var limited *rateLimitedError
+		if err == nil || attempt >= maxRateLimitRetries || !errors.As(err, &limited) {
+			return resp, err
+		}
+
+		wait := rateLimitWait(limited.retryAfter, attempt)
+		f.logger.Warnf("Rate limited by target, retrying in %s\n", wait)
+
+		select {
+		case <-time.After(wait):
+		case <-f.context().Done():
+			return nil, f.context().Err()
+		}
+	}
+}
+
+func (f *command) doAPIRequest(method string, path string, header http.Header, body []byte) (resp *http.Response, err error) {
+	span := f.options.Tracer.Start(method+" "+strings.SplitN(path, "?", 2)[0], tracing.KindClient, map[string]string{
+		"http.request.method": method,
+		"url.path":            path,
+	})
+	defer func() { span.End(ErrorClass(err)) }()
+
+	target, err := f.loadTarget()
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(target.API, "/")+apiPrefix+path, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(f.context())
+	req.Header.Set("User-Agent", f.userAgent())
+	if span != nil {
+		req.Header.Set("traceparent", span.Traceparent())
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	err = f.waitForRateLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Token != nil {
+		req.Header.Set("Authorization", target.Token.Type+" "+target.Token.Value)
+	}
+
+	resp, err = f.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttribute("http.response.status_code", strconv.Itoa(resp.StatusCode))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer closeBody(resp.Body)
+
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		err = fmt.Errorf("%s %s failed: %s - %s", method, path, resp.Status, string(b))
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, &rateLimitedError{err: err, retryAfter: resp.Header.Get("Retry-After")}
+		}
+		return nil, classifyStatusError(resp.StatusCode, err)
+	}
+
+	return resp, nil
+}
+
+// pipelinePath returns the API path of the given resource of the pipeline,
+// including the query parameters identifying an instanced pipeline along with
+// any others given.
+func (f *command) pipelinePath(ref PipelineRef, resource string, params url.Values) (string, error) {
+	teamName, err := f.teamName()
+	if err != nil {
+		return "", err
+	}
+
+	path := fmt.Sprintf(
+		"/teams/%s/pipelines/%s/%s",
+		url.PathEscape(teamName),
+		url.PathEscape(ref.Name),
+		resource,
+	)
+
+	query := ref.QueryParams()
+	for k, v := range params {
+		if query == nil {
+			query = url.Values{}
+		}
+		query[k] = v
+	}
+
+	if query != nil {
+		path += "?" + query.Encode()
+	}
+
+	return path, nil
+}
+
+// closeBody discards any of the body which has not been read before closing
+// it, so that the connection can be reused by the next request.
+func closeBody(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, maxDrainLength))
+	body.Close()
+}
+
+func (f *command) teamName() (string, error) {
+	target, err := f.loadTarget()
+	if err != nil {
+		return "", err
+	}
+
+	return target.TeamName, nil
+}
+
+// GetPipelineConfig returns the config of the pipeline along with its config
+// version, which fly does not expose.
+func (f *command) GetPipelineConfig(ref PipelineRef) (PipelineConfig, string, error) {
+	path, err := f.pipelinePath(ref, "config", nil)
+	if err != nil {
+		return PipelineConfig{}, "", err
+	}
+
+	resp, err := f.apiRequest("GET", path, nil, nil)
+	if err != nil {
+		return PipelineConfig{}, "", err
+	}
+	defer closeBody(resp.Body)
+
+	var configResponse struct {
+		Config PipelineConfig `json:"config"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&configResponse)
+	if err != nil {
+		return PipelineConfig{}, "", err
+	}
+
+	return configResponse.Config, resp.Header.Get(configVersionHeader), nil
+}
+
+// Jobs returns the jobs of the pipeline, including the latest finished and
+// next build of each, without running fly.
+func (f *command) Jobs(ref PipelineRef) ([]Job, error) {
+	path, err := f.pipelinePath(ref, "jobs", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.apiRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var jobs []Job
+
+	err = json.NewDecoder(resp.Body).Decode(&jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// Resources returns the resources of the pipeline, including any version
+// each is pinned to, without running fly.
+func (f *command) Resources(ref PipelineRef) ([]Resource, error) {
+	path, err := f.pipelinePath(ref, "resources", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.apiRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var resources []Resource
+
+	err = json.NewDecoder(resp.Body).Decode(&resources)
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// ConfigWarning is a warning about a pipeline config reported by the ATC when
+// it is set.
+type ConfigWarning struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// SetPipelineConfig sets the config of the pipeline, creating the pipeline if
+// it does not exist, without running fly. The config must already have any
+// vars interpolated. If fromVersion is not empty and the config has changed
+// since that version was retrieved with GetPipelineConfig, an error matching
+// ErrConflict is returned instead.
+func (f *command) SetPipelineConfig(ref PipelineRef, config []byte, fromVersion string) ([]ConfigWarning, error) {
+	path, err := f.pipelinePath(ref, "config", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-yaml")
+	if fromVersion != "" {
+		header.Set(configVersionHeader, fromVersion)
+	}
+
+	// The config is not in a file, so the equivalent command refers to one.
+	f.logEquivalentCommand("set-pipeline", ref, "-c", "pipeline.yml")
+
+	resp, err := f.apiRequest("PUT", path, header, config)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var setResponse struct {
+		Warnings []ConfigWarning `json:"warnings"`
+	}
+
+	// The ATC does not always respond with a body.
+	err = json.NewDecoder(resp.Body).Decode(&setResponse)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return setResponse.Warnings, nil
+}
+
+// PausePipeline pauses the pipeline without running fly.
+func (f *command) PausePipeline(ref PipelineRef) error {
+	return f.pipelineAction(ref, "pause")
+}
+
+// UnpausePipeline unpauses the pipeline without running fly.
+func (f *command) UnpausePipeline(ref PipelineRef) error {
+	return f.pipelineAction(ref, "unpause")
+}
+
+// ExposePipeline makes the pipeline visible to unauthenticated users without
+// running fly.
+func (f *command) ExposePipeline(ref PipelineRef) error {
+	return f.pipelineAction(ref, "expose")
+}
+
+// HidePipeline makes the pipeline visible only to members of its team
+// without running fly.
+func (f *command) HidePipeline(ref PipelineRef) error {
+	return f.pipelineAction(ref, "hide")
+}
+
+// pipelineAction makes a PUT request to the endpoint of the pipeline for an
+// action, such as pausing it, which has no response.
+func (f *command) pipelineAction(ref PipelineRef, action string) error {
+	path, err := f.pipelinePath(ref, action, nil)
+	if err != nil {
+		return err
+	}
+
+	f.logEquivalentCommand(action+"-pipeline", ref)
+
+	resp, err := f.apiRequest("PUT", path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	closeBody(resp.Body)
+
+	return nil
+}
+
+// Builds returns up to limit of the most recent builds of the pipeline, most
+// recent first, without running fly.
+func (f *command) Builds(ref PipelineRef, limit int) ([]Build, error) {
+	path, err := f.pipelinePath(ref, "builds", url.Values{"limit": {strconv.Itoa(limit)}})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.apiRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+
+	var builds []Build
+
+	err = json.NewDecoder(resp.Body).Decode(&builds)
+	if err != nil {
+		return nil, err
+	}
+
+	return builds, nil
+}
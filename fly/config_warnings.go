@@ -0,0 +1,36 @@
+package fly
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+var (
+	warningHeaderRegexp = regexp.MustCompile(`WARNING:\s*$`)
+	ansiEscapeRegexp    = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// ConfigWarnings returns the warnings about a config which fly writes when
+// setting it, as a header ending in WARNING: followed by an item per warning.
+func ConfigWarnings(output []byte) []string {
+	var warnings []string
+
+	inWarnings := false
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapeRegexp.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		switch {
+		case warningHeaderRegexp.MatchString(line):
+			inWarnings = true
+		case inWarnings && strings.HasPrefix(strings.TrimSpace(line), "- "):
+			warnings = append(warnings, strings.TrimPrefix(strings.TrimSpace(line), "- "))
+		default:
+			inWarnings = false
+		}
+	}
+
+	return warnings
+}
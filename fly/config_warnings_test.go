@@ -0,0 +1,22 @@
+package fly_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigWarnings", func() {
+	It("returns each warning written by fly after setting a config", func() {
+		output := "configuration updated\n\n\x1b[33mWARNING:\x1b[0m\n  - invalid identifier: job 'Build'\n  - deprecated field: ensure\n\nthe pipeline is currently paused.\n"
+
+		Expect(fly.ConfigWarnings([]byte(output))).To(Equal([]string{
+			"invalid identifier: job 'Build'",
+			"deprecated field: ensure",
+		}))
+	})
+
+	It("returns none if there are none", func() {
+		Expect(fly.ConfigWarnings([]byte("configuration updated\n  - not a warning\n"))).To(BeEmpty())
+	})
+})
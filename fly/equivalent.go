@@ -0,0 +1,78 @@
+package fly
+
+import (
+	"regexp"
+	"strings"
+)
+
+// safeShellArgRegexp matches arguments which need no quoting in a shell.
+var safeShellArgRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-./:=@,+%]+$`)
+
+// equivalentCommand returns a fly command line, quoted so that it can be
+// copied and pasted into a shell, equivalent to running fly with the args,
+// so that operators can reproduce what the resource did by hand.
+func equivalentCommand(args ...string) string {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, "fly")
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// redactedArgs returns the args of fly with the password given to login
+// replaced by a reference to an environment variable, and the values of the
+// secret vars given with -y replaced by a placeholder, as quoting or encoding
+// them could defeat the redaction of the logger.
+func redactedArgs(args []string, secretVars []string) []string {
+	secret := make(map[string]bool, len(secretVars))
+	for _, name := range secretVars {
+		secret[name] = true
+	}
+
+	result := make([]string, len(args))
+	copy(result, args)
+
+	login := false
+	for i, arg := range result {
+		switch {
+		case arg == "login":
+			login = true
+		case login && (arg == "-p" || arg == "--password") && i+1 < len(result):
+			result[i+1] = "$FLY_PASSWORD"
+		case (arg == "-y" || arg == "--yaml-var") && i+1 < len(result):
+			name := strings.SplitN(result[i+1], "=", 2)[0]
+			if secret[name] {
+				result[i+1] = name + "=***REDACTED***"
+			}
+		}
+	}
+
+	return result
+}
+
+func shellQuote(arg string) string {
+	if arg == "$FLY_PASSWORD" {
+		return `"$FLY_PASSWORD"`
+	}
+
+	if safeShellArgRegexp.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+// logEquivalentCommand logs the fly command equivalent to a request made
+// directly to the ATC about the pipeline of the team logged in to.
+func (f *command) logEquivalentCommand(subcommand string, ref PipelineRef, args ...string) {
+	allArgs := []string{"-t", f.target, subcommand, "-p", ref.String()}
+
+	teamName, err := f.teamName()
+	if err == nil && teamName != "" {
+		allArgs = append(allArgs, "--team", teamName)
+	}
+
+	f.logger.Debugf("Equivalent fly command: %s\n", equivalentCommand(append(allArgs, args...)...))
+}
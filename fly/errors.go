@@ -0,0 +1,158 @@
+package fly
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// Errors returned by Command can be tested against these with errors.Is to
+// find out why fly or the ATC rejected a request.
+var (
+	ErrUnauthorized = errors.New("not authorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrServerError  = errors.New("server error")
+
+	// ErrVersionMismatch is returned when fly does not match the target.
+	ErrVersionMismatch = errors.New("version mismatch")
+)
+
+// maxErrorBodyLength limits how much of an ATC response body is included in
+// an error.
+const maxErrorBodyLength = 512
+
+// Error is a failure reported by fly or the ATC which has been classified as
+// one of the errors above.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+var (
+	unauthorizedRegexp = regexp.MustCompile(`(?i)not authorized|please log ?in again|401 Unauthorized`)
+	forbiddenRegexp    = regexp.MustCompile(`(?i)forbidden`)
+	notFoundRegexp     = regexp.MustCompile(`(?i)(pipeline|team|job|resource|build) not found`)
+	serverErrorRegexp  = regexp.MustCompile(`(?i)internal server error|bad gateway|service unavailable|gateway timeout`)
+
+	// statusRegexp matches the status fly reports for an unexpected response
+	// of the ATC, e.g. "Status: 404 Not Found" or "unexpected response code:
+	// 502", so that numbers elsewhere, such as in names, are not taken for it.
+	statusRegexp = regexp.MustCompile(`(?im)^\s*status: (\d{3})\b|unexpected response code:? (\d{3})\b`)
+
+	versionMismatchRegexp = regexp.MustCompile(`(?i)out of sync with the target|version mismatch`)
+)
+
+// classifyOutputError classifies an error from fly based on what fly wrote
+// about it, preferring the status of the response of the ATC, if given.
+// Errors that cannot be classified are returned unchanged.
+func classifyOutputError(err error) error {
+	msg := err.Error()
+
+	if m := statusRegexp.FindStringSubmatch(msg); m != nil {
+		statusCode, _ := strconv.Atoi(m[1] + m[2])
+		classified := classifyStatusError(statusCode, err)
+		if classified != err {
+			return classified
+		}
+	}
+
+	var kind error
+	switch {
+	case unauthorizedRegexp.MatchString(msg):
+		kind = ErrUnauthorized
+	case forbiddenRegexp.MatchString(msg):
+		kind = ErrForbidden
+	case notFoundRegexp.MatchString(msg):
+		kind = ErrNotFound
+	case serverErrorRegexp.MatchString(msg):
+		kind = ErrServerError
+	case versionMismatchRegexp.MatchString(msg):
+		kind = ErrVersionMismatch
+	default:
+		return err
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// classifyStatusError classifies an error from an ATC API response based on
+// its status code. Errors that cannot be classified are returned unchanged.
+func classifyStatusError(statusCode int, err error) error {
+	var kind error
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		kind = ErrUnauthorized
+	case statusCode == http.StatusForbidden:
+		kind = ErrForbidden
+	case statusCode == http.StatusNotFound:
+		kind = ErrNotFound
+	case statusCode == http.StatusConflict:
+		kind = ErrConflict
+	case statusCode >= 500:
+		kind = ErrServerError
+	default:
+		return err
+	}
+
+	return &Error{Kind: kind, Err: err}
+}
+
+// loginError returns the failure to log in to a team with the code of why it
+// failed, if known.
+func loginError(err error) error {
+	var code concourse.ErrorCode
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrVersionMismatch):
+		code = concourse.ErrorCodeVersionMismatch
+	case errors.Is(err, ErrNotFound):
+		code = concourse.ErrorCodeTeamNotFound
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrForbidden):
+		code = concourse.ErrorCodeAuthFailed
+	default:
+		return err
+	}
+
+	return concourse.WithErrorCode(code, err)
+}
+
+// errUnclassified stands in for errors which have not been classified in
+// ErrorClass.
+var errUnclassified = errors.New("unclassified error")
+
+// ErrorClass returns the kind of the error, e.g. ErrNotFound, without the
+// output of fly or the body of the ATC's response, which may include secrets,
+// so that it can be exported in traces.
+func ErrorClass(err error) error {
+	var classified *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &classified):
+		return classified.Kind
+	default:
+		return errUnclassified
+	}
+}
+
+func isUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
@@ -2,43 +2,205 @@ package fly
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-	"crypto/tls"
+	"net"
 	"net/http"
 
+	"github.com/concourse/concourse-pipeline-resource/concourse"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/tracing"
 )
 
 //go:generate counterfeiter . Command
 
 type Command interface {
 	Login(url string, teamName string, username string, password string, insecure bool) ([]byte, error)
-	Pipelines() ([]string, error)
+	LoginWithClientCredentials(url string, teamName string, clientID string, clientSecret string, insecure bool) ([]byte, error)
+	LoginWithToken(url string, teamName string, token string, insecure bool) ([]byte, error)
+	Info() (Info, error)
+	UserInfo() (UserInfo, error)
+	Teams() ([]string, error)
+	Pipelines(includeArchived bool) ([]Pipeline, error)
 	GetPipeline(pipelineName string) ([]byte, error)
+	GetPipelineJSON(pipelineName string) (PipelineConfig, error)
+	GetPipelineConfig(ref PipelineRef) (PipelineConfig, string, error)
+	Jobs(ref PipelineRef) ([]Job, error)
+	Resources(ref PipelineRef) ([]Resource, error)
+	FlyVersion() (string, error)
+	Builds(ref PipelineRef, limit int) ([]Build, error)
+	SetPipelineConfig(ref PipelineRef, config []byte, fromVersion string) ([]ConfigWarning, error)
 	SetPipeline(pipelineName string, configFilepath string, varsFilepaths []string, vars map[string]interface{}) ([]byte, error)
+	RenderPipeline(configFilepath string, varsFilepaths []string, vars map[string]interface{}) ([]byte, error)
 	DestroyPipeline(pipelineName string) ([]byte, error)
-	UnpausePipeline(pipelineName string) ([]byte, error)
-	ExposePipeline(pipelineName string) ([]byte, error)
+	OrderPipelines(pipelineNames []string) ([]byte, error)
+	PausePipeline(ref PipelineRef) error
+	UnpausePipeline(ref PipelineRef) error
+	ExposePipeline(ref PipelineRef) error
+	HidePipeline(ref PipelineRef) error
+	RenamePipeline(oldName string, newName string) ([]byte, error)
+	ArchivePipeline(pipelineName string) ([]byte, error)
 }
 
+// Options configures how fly is invoked.
+type Options struct {
+	// Verbose runs fly with --verbose, which includes the requests it makes
+	// in the output written to the logger.
+	Verbose bool
+
+	// Home is used as the home directory of fly, and therefore holds its
+	// .flyrc. If empty, the home directory of the current user is used.
+	Home string
+
+	// SHA256 is the expected hex-encoded SHA-256 digest of the fly binary
+	// after it has been synced with the target. If empty, the digest is not
+	// checked.
+	SHA256 string
+
+	// Context, when cancelled, kills any fly process that is still running
+	// and causes the command to return an error. If nil, commands are never
+	// cancelled.
+	Context context.Context
+
+	// RequestTimeout limits how long each fly command and each request made
+	// directly to the ATC may take. If zero, there is no limit.
+	RequestTimeout time.Duration
+
+	// ConnectTimeout limits how long connecting to the ATC, including the TLS
+	// handshake, may take for requests made directly to it. If zero, the
+	// defaults of the standard library are used.
+	ConnectTimeout time.Duration
+
+	// IdleTimeout is how long connections to the ATC are kept open for reuse
+	// between requests made directly to it. If zero, the default of the
+	// standard library is used.
+	IdleTimeout time.Duration
+
+	// Retries is the number of times a command which only reads from the
+	// target is retried after failing with a possibly transient error.
+	Retries int
+
+	// RetryBackoff is the wait before the first retry, which doubles for
+	// each subsequent retry. Defaults to one second.
+	RetryBackoff time.Duration
+
+	// RetryMaxElapsedTime is how long after the first attempt of a command or
+	// request it may still be retried. If zero, there is no limit.
+	RetryMaxElapsedTime time.Duration
+
+	// RequestsPerSecond limits how often fly commands are run and requests
+	// are made directly to the ATC, on average. If zero, there is no limit.
+	RequestsPerSecond float64
+
+	// Version is the version of the resource, which is included in the
+	// User-Agent of requests made directly to the ATC so that they can be
+	// attributed to it. Defaults to dev.
+	Version string
+
+	// CACert is a PEM-encoded certificate trusted, in addition to the system
+	// roots, by fly and requests made directly to the ATC.
+	CACert string
+
+	// HTTPProxy, HTTPSProxy and NoProxy configure the proxies used by fly and
+	// requests made directly to the ATC, in the same form as the environment
+	// variables of the same names. If none are set, the environment is used.
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+
+	// ClientCert and ClientKey are a PEM-encoded certificate and key
+	// presented by fly and requests made directly to the ATC, for ATCs behind
+	// proxies which require mutual TLS.
+	ClientCert string
+	ClientKey  string
+
+	// Timings records the time spent syncing and logging in. If nil, nothing
+	// is recorded.
+	Timings *concourse.Timings
+
+	// LogRequests logs the method, URL, status and latency of each request
+	// made directly to the ATC, and LogRequestBodies also their bodies.
+	LogRequests      bool
+	LogRequestBodies bool
+
+	// Tracer records a span for each fly command run and each request made
+	// directly to the ATC. If nil, nothing is traced.
+	Tracer *tracing.Tracer
+
+	// SecretVars are the names of the vars whose values are replaced in the
+	// fly commands written to the logger, as the quoting of the values may
+	// defeat the redaction of the logger.
+	SecretVars []string
+}
+
+// NewHome creates an empty directory inside parentDir suitable for use as
+// Options.Home, so that concurrent runs do not share a .flyrc. If parentDir
+// is empty the default directory for temporary files is used.
+func NewHome(parentDir string) (string, error) {
+	return ioutil.TempDir(parentDir, "fly-home")
+}
+
+// userAgentProduct identifies the resource in the User-Agent of requests made
+// directly to the ATC.
+const userAgentProduct = "concourse-pipeline-resource"
+
+// maxIdleConnsPerHost is the number of idle connections kept open to the ATC
+// for reuse by later requests.
+const maxIdleConnsPerHost = 16
+
 type command struct {
 	target        string
 	logger        logger.Logger
 	flyBinaryPath string
+	options       Options
+
+	// client is shared by all requests made directly to the ATC during the
+	// run, so that connections are kept alive and reused between them.
+	client   *http.Client
+	insecure bool
+
+	// limiter is shared by all fly commands and requests made directly to
+	// the ATC, and is nil if they are not rate limited.
+	limiter *rateLimiter
+
+	// relogin repeats the most recent successful login. It is used to recover
+	// from sessions that expire part way through a run.
+	relogin func() error
 }
 
-func NewCommand(target string, logger logger.Logger, flyBinaryPath string) Command {
+func NewCommand(target string, logger logger.Logger, flyBinaryPath string, options Options) Command {
+	var limiter *rateLimiter
+	if options.RequestsPerSecond > 0 {
+		limiter = newRateLimiter(options.RequestsPerSecond)
+	}
+
 	return &command{
 		target:        target,
 		logger:        logger,
 		flyBinaryPath: flyBinaryPath,
+		options:       options,
+		client: &http.Client{
+			Transport: loggedTransport(newTransport(false, options), logger, options),
+			Timeout:   options.RequestTimeout,
+		},
+		limiter: limiter,
 	}
 }
 
-func (f command) Login(
+func (f *command) Login(
 	url string,
 	teamName string,
 	username string,
@@ -57,57 +219,350 @@ func (f command) Login(
 
 	if insecure {
 		args = append(args, "-k")
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			Proxy:           http.ProxyFromEnvironment,
-		}
-		http.DefaultClient.Transport = tr
 	}
+	f.setInsecure(insecure)
 
-	syncOut, err := f.run("sync", "-c", url)
+	tlsFiles, err := f.writeTLSFiles()
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, tlsFiles.args()...)
+
+	syncOut, err := f.sync(url)
 	if err != nil {
 		return nil, err
 	}
+	defer f.options.Timings.Start(concourse.PhaseLogin)()
 
 	loginOut, err := f.run(args...)
 	if err != nil {
 		return nil, err
 	}
 
+	f.relogin = func() error {
+		_, err := f.run(args...)
+		return err
+	}
+
 	return append(loginOut, syncOut...), nil
 }
 
-func (f command) Pipelines() ([]string, error) {
-	psOut, err := f.run("pipelines", "--json")
+// LoginWithClientCredentials obtains a token using the OAuth client
+// credentials grant and stores it as the target, as fly does not support
+// this grant itself.
+func (f *command) LoginWithClientCredentials(
+	url string,
+	teamName string,
+	clientID string,
+	clientSecret string,
+	insecure bool,
+) ([]byte, error) {
+	f.setInsecure(insecure)
+
+	tlsFiles, err := f.writeTLSFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	syncOut, err := f.sync(url)
+	if err != nil {
+		return nil, err
+	}
+	defer f.options.Timings.Start(concourse.PhaseLogin)()
+
+	login := func() error {
+		t, err := requestClientCredentialsToken(f.context(), f.httpClient(), f.userAgent(), url, clientID, clientSecret)
+		if err != nil {
+			return err
+		}
+
+		return f.saveTarget(flyrcTarget{
+			API:      url,
+			TeamName: teamName,
+			Insecure: insecure,
+			CACert:   f.options.CACert,
+			Token: &flyrcToken{
+				Type:  t.TokenType,
+				Value: t.AccessToken,
+			},
+			ClientCertPath: tlsFiles.clientCert,
+			ClientKeyPath:  tlsFiles.clientKey,
+		})
+	}
+
+	err = login()
+	if err != nil {
+		return nil, err
+	}
+
+	statusOut, err := f.run("status")
+	if err != nil {
+		return nil, err
+	}
+
+	f.relogin = login
+
+	return append(statusOut, syncOut...), nil
+}
+
+// LoginWithToken stores the bearer token as the target, for service accounts
+// which are issued a token rather than credentials. A token which expires
+// cannot be renewed, so requests are not retried after logging in again.
+func (f *command) LoginWithToken(
+	url string,
+	teamName string,
+	token string,
+	insecure bool,
+) ([]byte, error) {
+	f.setInsecure(insecure)
+
+	tlsFiles, err := f.writeTLSFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	syncOut, err := f.sync(url)
+	if err != nil {
+		return nil, err
+	}
+	defer f.options.Timings.Start(concourse.PhaseLogin)()
+
+	err = f.saveTarget(flyrcTarget{
+		API:      url,
+		TeamName: teamName,
+		Insecure: insecure,
+		CACert:   f.options.CACert,
+		Token: &flyrcToken{
+			Type:  "bearer",
+			Value: token,
+		},
+		ClientCertPath: tlsFiles.clientCert,
+		ClientKeyPath:  tlsFiles.clientKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statusOut, err := f.run("status")
+	if err != nil {
+		return nil, err
+	}
+
+	f.relogin = nil
+
+	return append(statusOut, syncOut...), nil
+}
+
+// sync downloads the fly binary matching the target and verifies that the
+// result is intact before it is used for anything else.
+func (f *command) sync(url string) ([]byte, error) {
+	stop := f.options.Timings.Start(concourse.PhaseSync)
+
+	syncOut, err := f.run("sync", "-c", url)
+	if err != nil {
+		return nil, err
+	}
+
+	err = f.verifyBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	f.logger.Debugf("Synced in %s\n", stop())
+
+	return syncOut, nil
+}
+
+func (f *command) verifyBinary() error {
+	if f.options.SHA256 != "" {
+		file, err := os.Open(f.flyBinaryPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		h := sha256.New()
+		_, err = io.Copy(h, file)
+		if err != nil {
+			return err
+		}
+
+		actual := hex.EncodeToString(h.Sum(nil))
+		if !strings.EqualFold(actual, f.options.SHA256) {
+			return &Error{Kind: ErrVersionMismatch, Err: fmt.Errorf(
+				"fly binary checksum mismatch after sync - expected sha256 %s, got %s",
+				f.options.SHA256,
+				actual,
+			)}
+		}
+	}
+
+	// A truncated or corrupt download will fail to execute.
+	version, err := f.FlyVersion()
+	if err != nil {
+		return fmt.Errorf("fly binary failed verification after sync: %v", err)
+	}
+
+	f.logger.Debugf("Synced fly version: %s\n", version)
+
+	return nil
+}
+
+// FlyVersion returns the version of the fly binary, which is that of the
+// target it was last synced with.
+func (f *command) FlyVersion() (string, error) {
+	versionOut, err := exec.CommandContext(f.context(), f.flyBinaryPath, "--version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%v - %s", err, string(versionOut))
+	}
+
+	return strings.TrimSpace(string(versionOut)), nil
+}
+
+func (f *command) context() context.Context {
+	if f.options.Context == nil {
+		return context.Background()
+	}
+
+	return f.options.Context
+}
+
+func (f *command) userAgent() string {
+	version := f.options.Version
+	if version == "" {
+		version = "dev"
+	}
+
+	return fmt.Sprintf("%s/%s", userAgentProduct, version)
+}
+
+// httpClient returns the client for requests made directly to the ATC, which
+// shares the transport configured for fly logins.
+func (f *command) httpClient() *http.Client {
+	return f.client
+}
+
+// setInsecure sets whether the certificate of the ATC is verified by requests
+// made directly to it. Pooled connections are kept unless it changes.
+func (f *command) setInsecure(insecure bool) {
+	if insecure == f.insecure {
+		return
+	}
+
+	f.insecure = insecure
+	f.client.Transport = loggedTransport(newTransport(insecure, f.options), f.logger, f.options)
+}
+
+// loggedTransport returns the transport wrapped so that requests are logged,
+// if the options call for it.
+func loggedTransport(transport http.RoundTripper, logger logger.Logger, options Options) http.RoundTripper {
+	if !options.LogRequests && !options.LogRequestBodies {
+		return transport
+	}
+
+	return &requestLogger{
+		transport: transport,
+		logger:    logger,
+		bodies:    options.LogRequestBodies,
+	}
+}
+
+// newTransport returns a transport which pools connections, and uses HTTP/2
+// where the ATC supports it. Responses are requested gzip-compressed, and
+// decompressed transparently, as large pipeline configs otherwise dominate
+// the time taken by requests over slow links.
+func newTransport(insecure bool, options Options) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	tr.TLSClientConfig = tlsConfig(insecure, options)
+	tr.Proxy = proxyFunc(options)
+
+	if options.ConnectTimeout > 0 {
+		tr.DialContext = (&net.Dialer{
+			Timeout:   options.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		tr.TLSHandshakeTimeout = options.ConnectTimeout
+	}
+
+	if options.IdleTimeout > 0 {
+		tr.IdleConnTimeout = options.IdleTimeout
+	}
+
+	return tr
+}
+
+func (f *command) Teams() ([]string, error) {
+	tsOut, err := f.run("teams", "--json")
 	if err != nil {
 		return nil, err
 	}
 
-	var ps []struct {
+	var ts []struct {
 		Name string `json:"name"`
 	}
 
-	err = json.Unmarshal(psOut, &ps)
+	err = json.Unmarshal(tsOut, &ts)
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, len(ps))
-	for i, p := range ps {
-		names[i] = p.Name
+	names := make([]string, len(ts))
+	for i, t := range ts {
+		names[i] = t.Name
 	}
 
 	return names, nil
 }
 
-func (f command) GetPipeline(pipelineName string) ([]byte, error) {
+func (f *command) Pipelines(includeArchived bool) ([]Pipeline, error) {
+	args := []string{"pipelines", "--json"}
+	if includeArchived {
+		args = append(args, "--include-archived")
+	}
+
+	psOut, err := f.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var ps []Pipeline
+
+	err = json.Unmarshal(psOut, &ps)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+func (f *command) GetPipeline(pipelineName string) ([]byte, error) {
 	return f.run(
 		"get-pipeline",
 		"-p", pipelineName,
 	)
 }
 
-func (f command) SetPipeline(
+func (f *command) GetPipelineJSON(pipelineName string) (PipelineConfig, error) {
+	out, err := f.run(
+		"get-pipeline",
+		"-p", pipelineName,
+		"--json",
+	)
+	if err != nil {
+		return PipelineConfig{}, err
+	}
+
+	var config PipelineConfig
+	err = json.Unmarshal(out, &config)
+	if err != nil {
+		return PipelineConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (f *command) SetPipeline(
 	pipelineName string,
 	configFilepath string,
 	varsFilepaths []string,
@@ -120,8 +575,42 @@ func (f command) SetPipeline(
 		"-c", configFilepath,
 	}
 
+	varsArgs, err := pipelineVarsArgs(varsFilepaths, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.run(append(allArgs, varsArgs...)...)
+}
+
+// RenderPipeline returns the config with the vars interpolated, as it would be
+// set, without setting it.
+func (f *command) RenderPipeline(
+	configFilepath string,
+	varsFilepaths []string,
+	vars map[string]interface{},
+) ([]byte, error) {
+	allArgs := []string{
+		"validate-pipeline",
+		"-c", configFilepath,
+		"-o",
+	}
+
+	varsArgs, err := pipelineVarsArgs(varsFilepaths, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.run(append(allArgs, varsArgs...)...)
+}
+
+// pipelineVarsArgs returns the args of fly giving it the vars files and the
+// vars of a pipeline.
+func pipelineVarsArgs(varsFilepaths []string, vars map[string]interface{}) ([]string, error) {
+	var args []string
+
 	for _, vf := range varsFilepaths {
-		allArgs = append(allArgs, "-l", vf)
+		args = append(args, "-l", vf)
 	}
 
 	for key, value := range vars {
@@ -131,35 +620,114 @@ func (f command) SetPipeline(
 			return nil, err
 		}
 
-		allArgs = append(allArgs, "-y", fmt.Sprintf("%s=%s", key, payload))
+		args = append(args, "-y", fmt.Sprintf("%s=%s", key, payload))
 	}
 
-	return f.run(allArgs...)
+	return args, nil
 }
 
-func (f command) UnpausePipeline(pipelineName string) ([]byte, error) {
+func (f *command) DestroyPipeline(pipelineName string) ([]byte, error) {
 	return f.run(
-		"unpause-pipeline",
+		"destroy-pipeline",
+		"-n",
 		"-p", pipelineName,
 	)
 }
 
-func (f command) DestroyPipeline(pipelineName string) ([]byte, error) {
+func (f *command) OrderPipelines(pipelineNames []string) ([]byte, error) {
+	args := []string{
+		"order-pipelines",
+	}
+
+	for _, p := range pipelineNames {
+		args = append(args, "-p", p)
+	}
+
+	return f.run(args...)
+}
+
+func (f *command) RenamePipeline(oldName string, newName string) ([]byte, error) {
 	return f.run(
-		"destroy-pipeline",
-		"-n",
-		"-p", pipelineName,
+		"rename-pipeline",
+		"-o", oldName,
+		"-n", newName,
 	)
 }
 
-func (f command) ExposePipeline(pipelineName string) ([]byte, error) {
+func (f *command) ArchivePipeline(pipelineName string) ([]byte, error) {
 	return f.run(
-		"expose-pipeline",
+		"archive-pipeline",
+		"-n",
 		"-p", pipelineName,
 	)
 }
 
-func (f command) run(args ...string) ([]byte, error) {
+// run invokes fly with the given args. If the session has expired, it logs
+// in again and retries the command once before failing.
+func (f *command) run(args ...string) ([]byte, error) {
+	out, err := f.runRetrying(args...)
+	if err == nil || f.relogin == nil || !isUnauthorized(err) {
+		return out, err
+	}
+
+	switch args[0] {
+	case "login", "sync", "status":
+		return out, err
+	}
+
+	f.logger.Infof("Session expired, logging in again\n")
+	loginErr := f.relogin()
+	if loginErr != nil {
+		return out, fmt.Errorf("%v (re-login failed: %v)", err, loginErr)
+	}
+
+	return f.runRetrying(args...)
+}
+
+// runRetrying invokes fly, retrying commands which only read from the target
+// if they fail with an error which may be transient.
+func (f *command) runRetrying(args ...string) ([]byte, error) {
+	if !idempotentCommands[args[0]] {
+		return f.runRateLimited(args...)
+	}
+
+	var out []byte
+	err := f.retry("fly "+args[0], func() error {
+		var err error
+		out, err = f.runRateLimited(args...)
+		return err
+	})
+
+	return out, err
+}
+
+// runRateLimited invokes fly, waiting and retrying while the target responds
+// that it is rate limiting requests.
+func (f *command) runRateLimited(args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		out, err := f.runOnce(args...)
+		if err == nil || attempt >= maxRateLimitRetries || !isRateLimited(err) {
+			return out, err
+		}
+
+		wait := rateLimitWait(flyRetryAfter(err), attempt)
+		f.logger.Warnf("Rate limited by target, retrying in %s\n", wait)
+
+		select {
+		case <-time.After(wait):
+		case <-f.context().Done():
+			return out, f.context().Err()
+		}
+	}
+}
+
+func (f *command) runOnce(args ...string) (out []byte, err error) {
+	// Only the subcommand is recorded, as the other args include credentials.
+	span := f.options.Tracer.Start("fly "+args[0], tracing.KindInternal, map[string]string{
+		"fly.target": f.target,
+	})
+	defer func() { span.End(ErrorClass(err)) }()
+
 	if f.target == "" {
 		return nil, fmt.Errorf("target cannot be empty in command.run")
 	}
@@ -172,30 +740,138 @@ func (f command) run(args ...string) ([]byte, error) {
 		defaultArgs = []string{}
 	}
 
+	if f.options.Verbose {
+		defaultArgs = append(defaultArgs, "--verbose")
+	}
+
+	err = f.waitForRateLimit()
+	if err != nil {
+		return nil, err
+	}
+
 	allArgs := append(defaultArgs, args...)
-	cmd := exec.Command(f.flyBinaryPath, allArgs...)
+	ctx := f.context()
+	if f.options.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.options.RequestTimeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, f.flyBinaryPath, allArgs...)
+
+	var env []string
+	if f.options.Home != "" {
+		env = append(env, "HOME="+f.options.Home)
+	}
+	env = append(env, proxyEnv(f.options)...)
+
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	outbuf := bytes.NewBuffer(nil)
 	errbuf := bytes.NewBuffer(nil)
 
+	mutex := &sync.Mutex{}
+	errLog := &logWriter{logger: f.logger, prefix: "fly stderr: ", verbose: f.options.Verbose, mutex: mutex}
+	defer errLog.Flush()
+
 	cmd.Stdout = outbuf
-	cmd.Stderr = errbuf
+	cmd.Stderr = io.MultiWriter(errbuf, errLog)
+
+	// The output of these commands is data to be returned, not progress.
+	switch args[0] {
+	case "get-pipeline", "pipelines", "teams", "validate-pipeline":
+	default:
+		outLog := &logWriter{logger: f.logger, prefix: "fly stdout: ", verbose: f.options.Verbose, mutex: mutex}
+		defer outLog.Flush()
+
+		cmd.Stdout = io.MultiWriter(outbuf, outLog)
+	}
 
-	f.logger.Debugf("Starting fly command: %v\n", allArgs)
-	err := cmd.Start()
+	loggedArgs := redactedArgs(allArgs, f.options.SecretVars)
+	f.logger.Debugf("Starting fly command: %s\n", equivalentCommand(loggedArgs...))
+	err = cmd.Start()
 	if err != nil {
 		// If the command was never started, there will be nothing in the buffers
 		return nil, err
 	}
 
-	f.logger.Debugf("Waiting for fly command: %v\n", allArgs)
+	f.logger.Debugf("Waiting for fly command: %v\n", loggedArgs)
 	err = cmd.Wait()
+	if ctxErr := f.context().Err(); ctxErr != nil {
+		return outbuf.Bytes(), fmt.Errorf("fly command %v cancelled: %v", args[0], ctxErr)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return outbuf.Bytes(), fmt.Errorf("fly command %v timed out after %s", args[0], f.options.RequestTimeout)
+	}
+
 	if err != nil {
 		if len(errbuf.Bytes()) > 0 {
 			err = fmt.Errorf("%v - %s", err, string(errbuf.Bytes()))
 		}
-		return outbuf.Bytes(), err
+		return outbuf.Bytes(), classifyOutputError(err)
+	}
+
+	// fly writes the warnings of the ATC about a config it sets to stderr,
+	// and they are returned after the output so that they can be reported.
+	if args[0] == "set-pipeline" {
+		return append(outbuf.Bytes(), errbuf.Bytes()...), nil
 	}
 
 	return outbuf.Bytes(), nil
 }
+
+const (
+	maxRateLimitRetries = 5
+	maxRateLimitWait    = 30 * time.Second
+)
+
+var (
+	tooManyRequestsRegexp = regexp.MustCompile(`(?i)too many requests`)
+	retryAfterRegexp      = regexp.MustCompile(`(?i)retry-after:\s*(\d+)`)
+)
+
+// isRateLimited tells whether fly failed as the target is rate limiting
+// requests, by the status of the response if fly wrote it, so that numbers
+// elsewhere, such as in names, are not taken for it.
+func isRateLimited(err error) bool {
+	msg := err.Error()
+
+	if m := statusRegexp.FindStringSubmatch(msg); m != nil {
+		return m[1]+m[2] == strconv.Itoa(http.StatusTooManyRequests)
+	}
+
+	return tooManyRequestsRegexp.MatchString(msg)
+}
+
+// flyRetryAfter returns the Retry-After value in the fly output, if any.
+func flyRetryAfter(err error) string {
+	matches := retryAfterRegexp.FindStringSubmatch(err.Error())
+	if len(matches) != 2 {
+		return ""
+	}
+
+	return matches[1]
+}
+
+// rateLimitWait honours a Retry-After value, in seconds, if there is one,
+// and otherwise backs off exponentially.
+func rateLimitWait(retryAfter string, attempt int) time.Duration {
+	seconds, err := strconv.Atoi(retryAfter)
+	if err == nil && seconds >= 0 {
+		wait := time.Duration(seconds) * time.Second
+		if wait > maxRateLimitWait {
+			wait = maxRateLimitWait
+		}
+		return wait
+	}
+
+	wait := time.Second << uint(attempt)
+	if wait > maxRateLimitWait {
+		wait = maxRateLimitWait
+	}
+
+	return wait
+}
@@ -1,14 +1,34 @@
 package fly_test
 
 import (
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger/loggerfakes"
+	"github.com/concourse/concourse-pipeline-resource/tracing"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -32,181 +52,1777 @@ var _ = Describe("Command", func() {
 		fakeFlyContents string
 
 		fakeLogger *loggerfakes.FakeLogger
+
+		options fly.Options
 	)
 
-	BeforeEach(func() {
-		target = "some-target"
-		teamName = "main"
+	BeforeEach(func() {
+		target = "some-target"
+		teamName = "main"
+
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		flyBinaryPath = filepath.Join(tempDir, "fake_fly")
+
+		fakeFlyContents = `#!/bin/sh
+		echo $@`
+
+		fakeLogger = &loggerfakes.FakeLogger{}
+
+		options = fly.Options{}
+	})
+
+	// writeFlyrc logs in to the ATC at the given url as far as requests made
+	// directly to the ATC are concerned.
+	writeFlyrc := func(api string) {
+		flyrc := fmt.Sprintf(`targets:
+  %s:
+    api: %s
+    team: main
+    token:
+      type: bearer
+      value: some-token
+`, target, api)
+
+		err := ioutil.WriteFile(filepath.Join(tempDir, ".flyrc"), []byte(flyrc), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	JustBeforeEach(func() {
+		err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		flyCommand = fly.NewCommand(target, fakeLogger, flyBinaryPath, options)
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Login", func() {
+		var (
+			url      string
+			username string
+			password string
+			insecure bool
+		)
+
+		BeforeEach(func() {
+			url = "some-url"
+			username = "some-username"
+			password = "some-password"
+			insecure = false
+		})
+
+		It("returns output without error", func() {
+			output, err := flyCommand.Login(url, teamName, username, password, insecure)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
+				"-t", target,
+				"login",
+				"-c", url,
+				"-n", teamName,
+				"-u", username,
+				"-p", password,
+				"sync",
+				"-c", url,
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
+		})
+
+		Context("when insecure is true", func() {
+			BeforeEach(func() {
+				insecure = true
+			})
+
+			It("adds -k flag to command", func() {
+				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
+					"-t", target,
+					"login",
+					"-c", url,
+					"-n", teamName,
+					"-u", username,
+					"-p", password,
+					"-k",
+					"sync",
+					"-c", url,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+		})
+
+		Context("when a CA certificate is given", func() {
+			BeforeEach(func() {
+				options.Home = tempDir
+				options.CACert = "some-ca-cert"
+			})
+
+			It("writes it to a file passed to fly with --ca-cert", func() {
+				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).NotTo(HaveOccurred())
+
+				caCertPath := filepath.Join(tempDir, "ca.crt")
+				Expect(string(output)).To(ContainSubstring("-p %s --ca-cert %s\n", password, caCertPath))
+
+				b, err := ioutil.ReadFile(caCertPath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(Equal("some-ca-cert"))
+			})
+		})
+
+		Context("when a client certificate is given", func() {
+			BeforeEach(func() {
+				options.Home = tempDir
+				options.ClientCert = "some-client-cert"
+				options.ClientKey = "some-client-key"
+			})
+
+			It("writes it to files passed to fly with --client-cert and --client-key", func() {
+				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring(
+					"--client-cert %s --client-key %s\n",
+					filepath.Join(tempDir, "client.crt"),
+					filepath.Join(tempDir, "client.key"),
+				))
+			})
+			Context("when no fly home is given", func() {
+				var home string
+
+				BeforeEach(func() {
+					options.Home = ""
+
+					home = os.Getenv("HOME")
+					os.Setenv("HOME", tempDir)
+				})
+
+				AfterEach(func() {
+					os.Setenv("HOME", home)
+				})
+
+				It("writes them next to the flyrc in the home directory", func() {
+					output, err := flyCommand.Login(url, teamName, username, password, insecure)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(output)).To(ContainSubstring("--client-key %s\n", filepath.Join(tempDir, "client.key")))
+
+					b, err := ioutil.ReadFile(filepath.Join(tempDir, "client.key"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(b)).To(Equal("some-client-key"))
+				})
+			})
+		})
+
+		Context("when there is an error starting the commmand", func() {
+			BeforeEach(func() {
+				fakeFlyContents = ""
+			})
+
+			It("returns an error", func() {
+				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("when no username or password is specified", func() {
+			BeforeEach(func() {
+				username = ""
+				password = ""
+			})
+
+			It("does not pass the `p` or `u` flags to fly", func() {
+				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).NotTo(HaveOccurred())
+
+				expectedOutput := fmt.Sprintf(
+					"%s %s %s %s %s %s %s\n%s %s %s\n",
+					"-t", target,
+					"login",
+					"-c", url,
+					"-n", teamName,
+					"sync",
+					"-c", url,
+				)
+
+				Expect(string(output)).To(Equal(expectedOutput))
+			})
+		})
+
+		Context("when a fly checksum is configured", func() {
+			Context("when the checksum matches", func() {
+				BeforeEach(func() {
+					options.SHA256 = fmt.Sprintf("%x", sha256.Sum256([]byte(fakeFlyContents)))
+				})
+
+				It("returns output without error", func() {
+					_, err := flyCommand.Login(url, teamName, username, password, insecure)
+					Expect(err).NotTo(HaveOccurred())
+				})
+			})
+
+			Context("when the checksum does not match", func() {
+				BeforeEach(func() {
+					options.SHA256 = fmt.Sprintf("%x", sha256.Sum256([]byte("something else")))
+				})
+
+				It("returns an error", func() {
+					_, err := flyCommand.Login(url, teamName, username, password, insecure)
+					Expect(err).To(HaveOccurred())
+
+					Expect(err.Error()).To(ContainSubstring("checksum mismatch"))
+				})
+			})
+		})
+
+		Context("when the synced fly binary cannot be executed", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+if [ "$1" = "--version" ]; then exit 1; fi
+echo $@`
+			})
+
+			It("returns an error", func() {
+				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("failed verification"))
+			})
+		})
+
+		Context("when the command returns an error", func() {
+			BeforeEach(func() {
+				fakeFlyContents = errScript
+			})
+
+			It("appends stderr to the error", func() {
+				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*some err output.*"))
+			})
+		})
+	})
+
+	Describe("LoginWithClientCredentials", func() {
+		var (
+			server       *httptest.Server
+			clientID     string
+			clientSecret string
+		)
+
+		BeforeEach(func() {
+			clientID = "some-client-id"
+			clientSecret = "some-client-secret"
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/sky/issuer/token"))
+				Expect(r.FormValue("grant_type")).To(Equal("client_credentials"))
+
+				id, secret, ok := r.BasicAuth()
+				Expect(ok).To(BeTrue())
+				if id != "some-client-id" || secret != "some-client-secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				w.Write([]byte(`{"access_token":"some-access-token","token_type":"bearer"}`))
+			}))
+
+			options.Home = tempDir
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("stores the token as the target in the flyrc", func() {
+			output, err := flyCommand.LoginWithClientCredentials(server.URL, teamName, clientID, clientSecret, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s\n%s %s %s\n",
+				"-t", target,
+				"status",
+				"sync",
+				"-c", server.URL,
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
+
+			b, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(b)).To(ContainSubstring(target + ":"))
+			Expect(string(b)).To(ContainSubstring("api: " + server.URL))
+			Expect(string(b)).To(ContainSubstring("team: " + teamName))
+			Expect(string(b)).To(ContainSubstring("value: some-access-token"))
+		})
+
+		Context("when the ATC has a self-signed certificate", func() {
+			var tlsServer *httptest.Server
+
+			BeforeEach(func() {
+				tlsServer = httptest.NewTLSServer(server.Config.Handler)
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("only skips verification of the certificate for insecure logins", func() {
+				_, err := flyCommand.LoginWithClientCredentials(tlsServer.URL, teamName, clientID, clientSecret, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = flyCommand.LoginWithClientCredentials(tlsServer.URL, teamName, clientID, clientSecret, false)
+				Expect(err).To(MatchError(ContainSubstring("certificate")))
+			})
+		})
+
+		Context("when the ATC has a certificate signed by the given CA", func() {
+			var tlsServer *httptest.Server
+
+			BeforeEach(func() {
+				tlsServer = httptest.NewTLSServer(server.Config.Handler)
+
+				options.CACert = string(pem.EncodeToMemory(&pem.Block{
+					Type:  "CERTIFICATE",
+					Bytes: tlsServer.Certificate().Raw,
+				}))
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("verifies the certificate without skipping verification", func() {
+				_, err := flyCommand.LoginWithClientCredentials(tlsServer.URL, teamName, clientID, clientSecret, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				b, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(b)).To(ContainSubstring("ca_cert: |"))
+			})
+		})
+
+		Context("when the client credentials are rejected", func() {
+			BeforeEach(func() {
+				clientSecret = "wrong-secret"
+			})
+
+			It("returns an error", func() {
+				_, err := flyCommand.LoginWithClientCredentials(server.URL, teamName, clientID, clientSecret, false)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("401"))
+			})
+		})
+	})
+
+	Describe("LoginWithToken", func() {
+		BeforeEach(func() {
+			options.Home = tempDir
+		})
+
+		It("stores the token as the target in the flyrc", func() {
+			output, err := flyCommand.LoginWithToken("some-url", teamName, "some-bearer-token", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s\n%s %s %s\n",
+				"-t", target,
+				"status",
+				"sync",
+				"-c", "some-url",
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
+
+			b, err := ioutil.ReadFile(filepath.Join(tempDir, ".flyrc"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(b)).To(ContainSubstring("api: some-url"))
+			Expect(string(b)).To(ContainSubstring("team: " + teamName))
+			Expect(string(b)).To(ContainSubstring("value: some-bearer-token"))
+		})
+	})
+
+	Describe("session expiry", func() {
+		BeforeEach(func() {
+			fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+case "$3" in
+  login)
+    if [ -f %[1]s/logged-in ]; then touch %[1]s/relogged-in; fi
+    touch %[1]s/logged-in
+    ;;
+  get-pipeline)
+    if [ ! -f %[1]s/relogged-in ]; then
+      >&2 echo "not authorized. run the following to log in again:"
+      exit 1
+    fi
+    ;;
+esac
+echo $@`, tempDir)
+		})
+
+		Context("when logged in", func() {
+			JustBeforeEach(func() {
+				_, err := flyCommand.Login("some-url", teamName, "some-username", "some-password", false)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("logs in again and retries the command", func() {
+				output, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring("get-pipeline"))
+
+				_, err = os.Stat(filepath.Join(tempDir, "relogged-in"))
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when never logged in", func() {
+			It("returns the error", func() {
+				_, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("not authorized"))
+			})
+		})
+	})
+
+	Describe("Home", func() {
+		BeforeEach(func() {
+			options.Home = filepath.Join(tempDir, "some-home")
+
+			fakeFlyContents = `#!/bin/sh
+echo $HOME`
+		})
+
+		It("runs fly with the given home directory", func() {
+			output, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(Equal(options.Home + "\n"))
+		})
+	})
+
+	Describe("NewHome", func() {
+		It("creates a new directory in the parent directory", func() {
+			home1, err := fly.NewHome(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			home2, err := fly.NewHome(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Dir(home1)).To(Equal(tempDir))
+			Expect(home1).NotTo(Equal(home2))
+		})
+	})
+
+	Describe("rate limiting", func() {
+		BeforeEach(func() {
+			fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+if [ ! -f %[1]s/rate-limited ]; then
+  touch %[1]s/rate-limited
+  >&2 echo "429 Too Many Requests"
+  >&2 echo "Retry-After: 0"
+  exit 1
+fi
+echo $@`, tempDir)
+		})
+
+		It("waits and retries the command", func() {
+			output, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(ContainSubstring("get-pipeline"))
+		})
+
+		Context("when the target keeps rate limiting", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+>&2 echo "429 Too Many Requests"
+>&2 echo "Retry-After: 0"
+exit 1`
+			})
+
+			It("eventually returns the error", func() {
+				_, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(ContainSubstring("Too Many Requests"))
+			})
+		})
+
+		Context("when fly reports the status of the response", func() {
+			BeforeEach(func() {
+				fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+if [ ! -f %[1]s/rate-limited ]; then
+  touch %[1]s/rate-limited
+  >&2 echo "Unexpected Response"
+  >&2 echo "Status: 429 Unknown"
+  exit 1
+fi
+echo $@`, tempDir)
+			})
+
+			It("waits and retries the command", func() {
+				output, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(ContainSubstring("get-pipeline"))
+			})
+		})
+
+		Context("when 429 is only part of the output", func() {
+			BeforeEach(func() {
+				fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+echo run >> %s/runs
+>&2 echo "error: pipeline release-429 is invalid"
+exit 1`, tempDir)
+			})
+
+			It("returns the error without retrying", func() {
+				_, err := flyCommand.GetPipeline("release-429")
+				Expect(err).To(HaveOccurred())
+
+				runs, err := ioutil.ReadFile(filepath.Join(tempDir, "runs"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(runs)).To(Equal("run\n"))
+			})
+		})
+
+		Context("when the ATC rate limits a request made directly", func() {
+			var (
+				server   *httptest.Server
+				requests int
+			)
+
+			BeforeEach(func() {
+				requests = 0
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests++
+					if requests == 1 {
+						w.Header().Set("Retry-After", "0")
+						w.WriteHeader(http.StatusTooManyRequests)
+						return
+					}
+
+					w.Write([]byte(`{"version":"7.9.1"}`))
+				}))
+
+				options.Home = tempDir
+
+				writeFlyrc(server.URL)
+			})
+
+			AfterEach(func() {
+				server.Close()
+			})
+
+			It("waits and retries the request", func() {
+				info, err := flyCommand.Info()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(info.Version).To(Equal("7.9.1"))
+				Expect(requests).To(Equal(2))
+			})
+		})
+	})
+
+	Describe("InstallBinary", func() {
+		It("copies the binary into a new directory inside the given directory", func() {
+			workDir := filepath.Join(tempDir, "work")
+			err := os.Mkdir(workDir, os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			installedPath, err := fly.InstallBinary(flyBinaryPath, workDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Dir(filepath.Dir(installedPath))).To(Equal(workDir))
+
+			b, err := ioutil.ReadFile(installedPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(fakeFlyContents))
+
+			output, err := fly.NewCommand(target, fakeLogger, installedPath, options).GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(ContainSubstring("get-pipeline"))
+		})
+
+		Context("when the binary does not exist", func() {
+			It("returns an error", func() {
+				_, err := fly.InstallBinary(filepath.Join(tempDir, "not-fly"), tempDir)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Context", func() {
+		var cancel context.CancelFunc
+
+		BeforeEach(func() {
+			options.Context, cancel = context.WithCancel(context.Background())
+
+			fakeFlyContents = `#!/bin/sh
+exec sleep 10`
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		It("kills the fly process when the context is cancelled", func() {
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			start := time.Now()
+			_, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(ContainSubstring("cancelled"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+	})
+
+	Describe("RequestTimeout", func() {
+		BeforeEach(func() {
+			options.RequestTimeout = 100 * time.Millisecond
+
+			fakeFlyContents = `#!/bin/sh
+exec sleep 10`
+		})
+
+		It("kills fly commands that take too long", func() {
+			start := time.Now()
+			_, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(ContainSubstring("timed out"))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
+	})
+
+	Describe("errors", func() {
+		DescribeTable("classifies fly failures",
+			func(stderr string, expectedErr error) {
+				fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+>&2 echo "%s"
+exit 1`, stderr)
+
+				err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(errors.Is(err, expectedErr)).To(BeTrue())
+				Expect(err.Error()).To(ContainSubstring(stderr))
+			},
+			Entry("not authorized", "not authorized. run the following to log in again:", fly.ErrUnauthorized),
+			Entry("forbidden", "error: forbidden", fly.ErrForbidden),
+			Entry("pipeline not found", "error: pipeline not found", fly.ErrNotFound),
+			Entry("server error", "unexpected response code: 500 Internal Server Error", fly.ErrServerError),
+			Entry("status of an unexpected response", "error: Unexpected Response\nStatus: 404 Not Found\nBody:", fly.ErrNotFound),
+			Entry("unexpected response code", "error: unexpected response code: 502", fly.ErrServerError),
+			Entry("version mismatch", "fly version (6.7.0) is out of sync with the target (7.9.1)", fly.ErrVersionMismatch),
+		)
+
+		It("does not take numbers in names for statuses", func() {
+			fakeFlyContents = `#!/bin/sh
+>&2 echo "error: invalid pipeline release-404 at http://ci.example.com:502/pipelines"
+exit 1`
+
+			err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = flyCommand.GetPipeline("some-pipeline")
+			Expect(err).To(HaveOccurred())
+
+			var flyErr *fly.Error
+			Expect(errors.As(err, &flyErr)).To(BeFalse())
+		})
+
+		It("does not classify other failures", func() {
+			fakeFlyContents = errScript
+
+			err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = flyCommand.GetPipeline("some-pipeline")
+			Expect(err).To(HaveOccurred())
+
+			var flyErr *fly.Error
+			Expect(errors.As(err, &flyErr)).To(BeFalse())
+		})
+	})
+
+	Describe("Retries", func() {
+		BeforeEach(func() {
+			options.Retries = 2
+			options.RetryBackoff = time.Millisecond
+
+			fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+echo "$3" >> %[1]s/attempts
+if [ $(wc -l < %[1]s/attempts) -lt 3 ]; then
+  >&2 echo "unexpected response code: 502 Bad Gateway"
+  exit 1
+fi
+echo $@`, tempDir)
+		})
+
+		attempts := func() int {
+			b, err := ioutil.ReadFile(filepath.Join(tempDir, "attempts"))
+			Expect(err).NotTo(HaveOccurred())
+			return len(strings.Split(strings.TrimSpace(string(b)), "\n"))
+		}
+
+		It("retries read-only commands which fail with transient errors", func() {
+			output, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(ContainSubstring("get-pipeline"))
+			Expect(attempts()).To(Equal(3))
+		})
+
+		It("does not retry commands which change the target", func() {
+			_, err := flyCommand.DestroyPipeline("some-pipeline")
+			Expect(err).To(HaveOccurred())
+
+			Expect(attempts()).To(Equal(1))
+		})
+
+		Context("when the error is not transient", func() {
+			BeforeEach(func() {
+				fakeFlyContents = fmt.Sprintf(`#!/bin/sh
+echo "$3" >> %[1]s/attempts
+>&2 echo "error: pipeline not found"
+exit 1`, tempDir)
+			})
+
+			It("does not retry", func() {
+				_, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(attempts()).To(Equal(1))
+			})
+		})
+
+		Context("when the next retry would start after the max elapsed time", func() {
+			BeforeEach(func() {
+				options.RetryBackoff = time.Second
+				options.RetryMaxElapsedTime = 500 * time.Millisecond
+			})
+
+			It("does not retry", func() {
+				_, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(attempts()).To(Equal(1))
+			})
+		})
+
+		Context("when retries are not configured", func() {
+			BeforeEach(func() {
+				options.Retries = 0
+			})
+
+			It("does not retry", func() {
+				_, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				Expect(attempts()).To(Equal(1))
+			})
+		})
+	})
+
+	Describe("Verbose", func() {
+		BeforeEach(func() {
+			options.Verbose = true
+
+			fakeFlyContents = `#!/bin/sh
+>&2 echo "some verbose output"
+echo $@`
+		})
+
+		It("passes --verbose to fly", func() {
+			output, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s %s %s %s\n",
+				"-t", target,
+				"--verbose",
+				"get-pipeline",
+				"-p", "some-pipeline",
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
+		})
+
+		It("writes the fly output to the logger at the info level", func() {
+			_, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			var logged []string
+			for i := 0; i < fakeLogger.InfofCallCount(); i++ {
+				format, args := fakeLogger.InfofArgsForCall(i)
+				logged = append(logged, fmt.Sprintf(format, args...))
+			}
+
+			Expect(logged).To(ContainElement(ContainSubstring("some verbose output")))
+		})
+	})
+
+	Describe("output logging", func() {
+		BeforeEach(func() {
+			fakeFlyContents = `#!/bin/sh
+echo "some progress"
+>&2 echo "some warning"
+printf "no trailing newline"`
+		})
+
+		loggedLines := func() []string {
+			var logged []string
+			for i := 0; i < fakeLogger.DebugfCallCount(); i++ {
+				format, args := fakeLogger.DebugfArgsForCall(i)
+				logged = append(logged, fmt.Sprintf(format, args...))
+			}
+			return logged
+		}
+
+		It("writes each line of output to the logger", func() {
+			_, err := flyCommand.DestroyPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := loggedLines()
+			Expect(lines).To(ContainElement("fly stdout: some progress\n"))
+			Expect(lines).To(ContainElement("fly stderr: some warning\n"))
+			Expect(lines).To(ContainElement("fly stdout: no trailing newline\n"))
+		})
+
+		It("does not write pipeline configs to the logger", func() {
+			_, err := flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := loggedLines()
+			Expect(lines).NotTo(ContainElement(ContainSubstring("some progress")))
+			Expect(lines).To(ContainElement("fly stderr: some warning\n"))
+		})
+
+		It("writes the equivalent fly command, quoted for a shell, to the logger", func() {
+			_, err := flyCommand.ArchivePipeline("some pipeline's")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(loggedLines()).To(ContainElement(fmt.Sprintf(
+				"Starting fly command: fly -t %s archive-pipeline -n -p 'some pipeline'\\''s'\n",
+				target,
+			)))
+		})
+
+		It("does not write the password given to login to the logger", func() {
+			_, err := flyCommand.Login("some-url", teamName, "some-username", "some'password", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			lines := loggedLines()
+			Expect(lines).To(ContainElement(fmt.Sprintf(
+				"Starting fly command: fly -t %s login -c some-url -n %s -u some-username -p \"$FLY_PASSWORD\"\n",
+				target, teamName,
+			)))
+			Expect(lines).NotTo(ContainElement(ContainSubstring("password'")))
+		})
+
+		Context("when secret vars are given", func() {
+			BeforeEach(func() {
+				options.SecretVars = []string{"some-secret"}
+			})
+
+			It("does not write their values to the logger", func() {
+				_, err := flyCommand.SetPipeline("some-pipeline", "some-config-file", nil, map[string]interface{}{
+					"some-secret": `secret"with'quotes`,
+					"some-var":    "some-value",
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				lines := loggedLines()
+				Expect(lines).To(ContainElement(ContainSubstring("-y 'some-secret=***REDACTED***'")))
+				Expect(lines).To(ContainElement(ContainSubstring(`-y 'some-var="some-value"'`)))
+				Expect(lines).NotTo(ContainElement(ContainSubstring("with")))
+			})
+		})
+	})
+
+	Describe("Info", func() {
+		var (
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/api/v1/info"))
+
+				w.Write([]byte(`{"version":"7.9.1","worker_version":"2.4","external_url":"https://ci.example.com","cluster_name":"some-cluster"}`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the cluster info without error", func() {
+			info, err := flyCommand.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info).To(Equal(fly.Info{
+				Version:       "7.9.1",
+				WorkerVersion: "2.4",
+				ExternalURL:   "https://ci.example.com",
+				ClusterName:   "some-cluster",
+			}))
+		})
+
+		Context("when the ATC does not complete the TLS handshake", func() {
+			var listener net.Listener
+
+			BeforeEach(func() {
+				var err error
+				listener, err = net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).NotTo(HaveOccurred())
+
+				writeFlyrc("https://" + listener.Addr().String())
+
+				options.ConnectTimeout = 100 * time.Millisecond
+			})
+
+			AfterEach(func() {
+				listener.Close()
+			})
+
+			It("fails once the connect timeout has passed", func() {
+				start := time.Now()
+
+				_, err := flyCommand.Info()
+				Expect(err).To(MatchError(ContainSubstring("handshake timeout")))
+
+				Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			})
+		})
+
+		Context("when the ATC requires a client certificate", func() {
+			var tlsServer *httptest.Server
+
+			BeforeEach(func() {
+				tlsServer = httptest.NewUnstartedServer(server.Config.Handler)
+				tlsServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+				tlsServer.StartTLS()
+
+				writeFlyrc(tlsServer.URL)
+
+				options.CACert = string(pem.EncodeToMemory(&pem.Block{
+					Type:  "CERTIFICATE",
+					Bytes: tlsServer.Certificate().Raw,
+				}))
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			It("fails without one", func() {
+				_, err := flyCommand.Info()
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("presents the given client certificate", func() {
+				flyCommand = fly.NewCommand(target, fakeLogger, flyBinaryPath, withClientCert(options))
+
+				_, err := flyCommand.Info()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("LogRequests", func() {
+		var server *httptest.Server
+
+		debugLines := func() []string {
+			var lines []string
+			for i := 0; i < fakeLogger.DebugfCallCount(); i++ {
+				format, args := fakeLogger.DebugfArgsForCall(i)
+				lines = append(lines, fmt.Sprintf(format, args...))
+			}
+			return lines
+		}
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"version":"7.9.1"}`))
+			}))
+
+			options.Home = tempDir
+			options.LogRequests = true
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("logs the method, URL, status and latency of each request", func() {
+			_, err := flyCommand.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(debugLines()).To(ContainElement(MatchRegexp(`^HTTP GET ` + server.URL + `/api/v1/info: 200 OK in \d+m?s\n$`)))
+			Expect(debugLines()).NotTo(ContainElement(ContainSubstring("body")))
+			Expect(debugLines()).NotTo(ContainElement(ContainSubstring("some-token")))
+		})
+
+		Context("when bodies are logged too", func() {
+			BeforeEach(func() {
+				options.LogRequestBodies = true
+			})
+
+			It("logs the body of the response, still returning it", func() {
+				info, err := flyCommand.Info()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Version).To(Equal("7.9.1"))
+
+				Expect(debugLines()).To(ContainElement(HaveSuffix(`/api/v1/info response body: {"version":"7.9.1"}` + "\n")))
+			})
+		})
+	})
+
+	Describe("Tracer", func() {
+		var (
+			server      *httptest.Server
+			collector   *httptest.Server
+			traceparent string
+			exported    []byte
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent = r.Header.Get("traceparent")
+				w.Write([]byte(`{"version":"7.9.1"}`))
+			}))
+
+			collector = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				exported, _ = ioutil.ReadAll(r.Body)
+			}))
+
+			options.Home = tempDir
+			options.Tracer = tracing.NewTracer("check", collector.URL, nil, "", "1.2.3")
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+			collector.Close()
+		})
+
+		It("records a span for each request and fly command, propagating the trace to the ATC", func() {
+			_, err := flyCommand.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = flyCommand.GetPipeline("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(traceparent).To(MatchRegexp(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`))
+
+			err = options.Tracer.Export(nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(exported)).To(ContainSubstring(`"name":"GET /info"`))
+			Expect(string(exported)).To(ContainSubstring(`"name":"fly get-pipeline"`))
+			Expect(string(exported)).NotTo(ContainSubstring("some-pipeline"))
+		})
+
+		Context("when fly fails", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+>&2 echo 'error: some "secret" value'
+>&2 echo 'Status: 404 Not Found'
+exit 1`
+			})
+
+			It("records only the class of the error in the span", func() {
+				_, err := flyCommand.DestroyPipeline("some-pipeline")
+				Expect(err).To(HaveOccurred())
+
+				err = options.Tracer.Export(nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(exported)).To(ContainSubstring(`"message":"not found"`))
+				Expect(string(exported)).NotTo(ContainSubstring("secret"))
+			})
+		})
+	})
+
+	Describe("proxies", func() {
+		var (
+			server *httptest.Server
+			proxy  *httptest.Server
+
+			proxied int32
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"version":"7.9.1"}`))
+			}))
+
+			atomic.StoreInt32(&proxied, 0)
+			proxy = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				atomic.AddInt32(&proxied, 1)
+				Expect(r.URL.String()).To(Equal(server.URL + "/api/v1/info"))
+
+				w.Write([]byte(`{"version":"7.9.1"}`))
+			}))
+
+			options.Home = tempDir
+			options.HTTPProxy = proxy.URL
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+			proxy.Close()
+		})
+
+		It("sends requests made directly to the ATC through the proxy", func() {
+			_, err := flyCommand.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(atomic.LoadInt32(&proxied)).To(Equal(int32(1)))
+		})
+
+		Context("when the ATC is excluded from proxying", func() {
+			BeforeEach(func() {
+				options.NoProxy = "example.com, 127.0.0.0/8"
+			})
+
+			It("sends requests directly", func() {
+				_, err := flyCommand.Info()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(atomic.LoadInt32(&proxied)).To(Equal(int32(0)))
+			})
+		})
+
+		Context("when running fly", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+echo "$HTTP_PROXY $http_proxy"`
+			})
+
+			It("passes the proxy in its environment", func() {
+				output, err := flyCommand.GetPipeline("some-pipeline")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(Equal(proxy.URL + " " + proxy.URL + "\n"))
+			})
+		})
+	})
+
+	Describe("UserInfo", func() {
+		var (
+			server *httptest.Server
+
+			userAgent string
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/api/v1/user"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
+
+				userAgent = r.Header.Get("User-Agent")
+
+				w.Write([]byte(`{"user_name":"some-user","is_admin":false,"teams":{"main":["owner"]}}`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the user and its roles without error", func() {
+			info, err := flyCommand.UserInfo()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.UserName).To(Equal("some-user"))
+			Expect(info.Teams).To(Equal(map[string][]string{"main": {"owner"}}))
+		})
+
+		It("identifies the resource in the User-Agent", func() {
+			_, err := flyCommand.UserInfo()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(userAgent).To(Equal("concourse-pipeline-resource/dev"))
+		})
+
+		Context("when the version is provided", func() {
+			BeforeEach(func() {
+				options.Version = "1.2.3"
+			})
+
+			It("includes the version in the User-Agent", func() {
+				_, err := flyCommand.UserInfo()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(userAgent).To(Equal("concourse-pipeline-resource/1.2.3"))
+			})
+		})
+	})
+
+	Describe("Teams", func() {
+		BeforeEach(func() {
+			fakeFlyContents = `#!/bin/sh
+echo '[{"id":1,"name":"main"},{"id":2,"name":"other-team"}]'
+`
+		})
+
+		It("returns teams without error", func() {
+			teams, err := flyCommand.Teams()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(teams).To(Equal([]string{"main", "other-team"}))
+		})
+
+		Context("when requests are rate limited", func() {
+			BeforeEach(func() {
+				options.RequestsPerSecond = 10
+			})
+
+			It("waits once the burst has been used up", func() {
+				start := time.Now()
+
+				for i := 0; i < 12; i++ {
+					_, err := flyCommand.Teams()
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(time.Since(start)).To(BeNumerically(">=", 150*time.Millisecond))
+			})
+		})
+	})
+
+	Describe("Pipelines", func() {
+		BeforeEach(func() {
+			fakeFlyContents = `#!/bin/sh
+echo '[{"id":1,"name":"abc","team_name":"main","paused":true,"last_updated":1600000000},{"id":2,"name":"def","instance_vars":{"branch":"main"},"team_name":"main","public":true,"archived":true}]'
+`
+		})
+
+		It("returns pipelines without error", func() {
+			pipelines, err := flyCommand.Pipelines(false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pipelines).To(Equal([]fly.Pipeline{
+				{
+					ID:          1,
+					Name:        "abc",
+					TeamName:    "main",
+					Paused:      true,
+					LastUpdated: 1600000000,
+				},
+				{
+					ID:           2,
+					Name:         "def",
+					InstanceVars: map[string]interface{}{"branch": "main"},
+					TeamName:     "main",
+					Public:       true,
+					Archived:     true,
+				},
+			}))
+		})
+
+		Context("when archived pipelines are included", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+if [ "$3 $4 $5" != "pipelines --json --include-archived" ]; then exit 1; fi
+echo '[{"id":2,"name":"def","archived":true}]'
+`
+			})
+
+			It("returns archived pipelines without error", func() {
+				pipelines, err := flyCommand.Pipelines(true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(pipelines).To(HaveLen(1))
+				Expect(pipelines[0].Archived).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("GetPipeline", func() {
+		var (
+			pipelineName string
+		)
+
+		BeforeEach(func() {
+			pipelineName = "some-pipeline"
+		})
+
+		It("returns output without error", func() {
+			output, err := flyCommand.GetPipeline(pipelineName)
+			Expect(err).NotTo(HaveOccurred())
+
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s %s %s\n",
+				"-t", target,
+				"get-pipeline",
+				"-p", pipelineName,
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
+		})
+	})
+
+	Describe("GetPipelineJSON", func() {
+		BeforeEach(func() {
+			fakeFlyContents = `#!/bin/sh
+if [ "$3 $4 $5 $6" != "get-pipeline -p some-pipeline --json" ]; then exit 1; fi
+echo '{"jobs":[{"name":"some-job"}],"resources":[{"name":"some-resource","type":"git"}]}'
+`
+		})
+
+		It("returns the parsed config without error", func() {
+			config, err := flyCommand.GetPipelineJSON("some-pipeline")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Jobs).To(HaveLen(1))
+			Expect(config.Jobs[0]["name"]).To(Equal("some-job"))
+			Expect(config.Resources).To(HaveLen(1))
+			Expect(config.Resources[0]["type"]).To(Equal("git"))
+			Expect(config.ResourceTypes).To(BeEmpty())
+		})
+
+		Context("when the output is not valid json", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+echo 'not json'`
+			})
+
+			It("returns an error", func() {
+				_, err := flyCommand.GetPipelineJSON("some-pipeline")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Jobs", func() {
+		var (
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/jobs"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
+
+				w.Write([]byte(`[{"name":"some-job","finished_build":{"id":1,"status":"failed"}},{"name":"other-job","paused":true}]`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the jobs without running fly", func() {
+			fakeFlyContents = `#!/bin/sh
+exit 1`
+
+			jobs, err := flyCommand.Jobs(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs[0].Name).To(Equal("some-job"))
+			Expect(jobs[0].FinishedBuild.Status).To(Equal("failed"))
+			Expect(jobs[1].Paused).To(BeTrue())
+			Expect(jobs[1].FinishedBuild).To(BeNil())
+		})
+
+		Context("when the pipeline is instanced", func() {
+			BeforeEach(func() {
+				server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/jobs"))
+					Expect(r.URL.Query().Get("vars")).To(Equal(`{"branch":"main"}`))
+
+					w.Write([]byte(`[]`))
+				})
+			})
+
+			It("identifies the instance in the request", func() {
+				_, err := flyCommand.Jobs(fly.PipelineRef{
+					Name:         "some-pipeline",
+					InstanceVars: map[string]interface{}{"branch": "main"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("when the pipeline does not exist", func() {
+			BeforeEach(func() {
+				server.Config.Handler = http.NotFoundHandler()
+			})
+
+			It("returns a not found error", func() {
+				_, err := flyCommand.Jobs(fly.PipelineRef{Name: "some-pipeline"})
+				Expect(errors.Is(err, fly.ErrNotFound)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Builds", func() {
+		var (
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/builds"))
+				Expect(r.URL.Query().Get("limit")).To(Equal("2"))
+				Expect(r.URL.Query().Get("vars")).To(Equal(`{"branch":"main"}`))
+
+				w.Write([]byte(`[{"id":2,"name":"2","status":"started","job_name":"some-job","start_time":1600000100,"created_by":"some-user"},{"id":1,"name":"1","status":"succeeded","job_name":"some-job","start_time":1600000000,"end_time":1600000050}]`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the most recent builds without error", func() {
+			builds, err := flyCommand.Builds(fly.PipelineRef{
+				Name:         "some-pipeline",
+				InstanceVars: map[string]interface{}{"branch": "main"},
+			}, 2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(builds).To(Equal([]fly.Build{
+				{ID: 2, Name: "2", Status: "started", JobName: "some-job", StartTime: 1600000100, CreatedBy: "some-user"},
+				{ID: 1, Name: "1", Status: "succeeded", JobName: "some-job", StartTime: 1600000000, EndTime: 1600000050},
+			}))
+		})
+	})
 
-		var err error
-		tempDir, err = ioutil.TempDir("", "")
-		Expect(err).NotTo(HaveOccurred())
+	DescribeTable("PipelineStatus",
+		func(statuses []string, expected string) {
+			var jobs []fly.Job
+			for _, status := range statuses {
+				job := fly.Job{}
+				if status != "" {
+					job.FinishedBuild = &fly.Build{Status: status}
+				}
+				jobs = append(jobs, job)
+			}
+
+			Expect(fly.PipelineStatus(jobs)).To(Equal(expected))
+		},
+		Entry("no jobs", nil, "unknown"),
+		Entry("no finished builds", []string{""}, "unknown"),
+		Entry("all succeeded", []string{"succeeded", "succeeded"}, "succeeded"),
+		Entry("an aborted build", []string{"succeeded", "aborted"}, "aborted"),
+		Entry("an errored build", []string{"aborted", "errored"}, "errored"),
+		Entry("a failed build", []string{"errored", "failed", "succeeded"}, "failed"),
+	)
 
-		flyBinaryPath = filepath.Join(tempDir, "fake_fly")
+	Describe("FlyVersion", func() {
+		BeforeEach(func() {
+			fakeFlyContents = `#!/bin/sh
+if [ "$1" = "--version" ]; then echo 7.9.1; fi`
+		})
 
-		fakeFlyContents = `#!/bin/sh
-		echo $@`
+		It("returns the version reported by fly", func() {
+			version, err := flyCommand.FlyVersion()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal("7.9.1"))
+		})
 
-		fakeLogger = &loggerfakes.FakeLogger{}
+		Context("when fly cannot be executed", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+echo broken >&2
+exit 1`
+			})
+
+			It("returns an error including its output", func() {
+				_, err := flyCommand.FlyVersion()
+				Expect(err).To(MatchError(ContainSubstring("broken")))
+			})
+		})
 	})
 
-	JustBeforeEach(func() {
-		err := ioutil.WriteFile(flyBinaryPath, []byte(fakeFlyContents), os.ModePerm)
-		Expect(err).NotTo(HaveOccurred())
+	Describe("Resources", func() {
+		var (
+			server *httptest.Server
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/resources"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
 
-		flyCommand = fly.NewCommand(target, fakeLogger, flyBinaryPath)
+				w.Write([]byte(`[{"name":"some-resource","type":"git","pinned_version":{"ref":"abc"}},{"name":"other-resource","type":"time"}]`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("returns the resources without running fly", func() {
+			fakeFlyContents = `#!/bin/sh
+exit 1`
+
+			resources, err := flyCommand.Resources(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(HaveLen(2))
+			Expect(resources[0].PinnedVersion).To(Equal(map[string]string{"ref": "abc"}))
+			Expect(resources[1].PinnedVersion).To(BeNil())
+			Expect(fly.PinnedResources(resources)).To(Equal(1))
+		})
 	})
 
-	AfterEach(func() {
-		err := os.RemoveAll(tempDir)
-		Expect(err).NotTo(HaveOccurred())
+	It("counts paused jobs", func() {
+		Expect(fly.PausedJobs([]fly.Job{{Paused: true}, {}, {Paused: true}})).To(Equal(2))
 	})
 
-	Describe("Login", func() {
+	Describe("GetPipelineConfig", func() {
 		var (
-			url      string
-			username string
-			password string
-			insecure bool
+			server *httptest.Server
 		)
 
 		BeforeEach(func() {
-			url = "some-url"
-			username = "some-username"
-			password = "some-password"
-			insecure = false
-		})
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
 
-		It("returns output without error", func() {
-			output, err := flyCommand.Login(url, teamName, username, password, insecure)
-			Expect(err).NotTo(HaveOccurred())
+				Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/config"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
-				"-t", target,
-				"login",
-				"-c", url,
-				"-n", teamName,
-				"-u", username,
-				"-p", password,
-				"-t", target,
-				"sync",
-			)
+				w.Header().Set("X-Concourse-Config-Version", "42")
+				w.Write([]byte(`{"config":{"jobs":[{"name":"some-job"}]}}`))
+			}))
 
-			Expect(string(output)).To(Equal(expectedOutput))
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
 		})
 
-		Context("when insecure is true", func() {
+		AfterEach(func() {
+			server.Close()
+		})
+
+		Context("when the token has expired", func() {
+			var tokenServer *httptest.Server
+
 			BeforeEach(func() {
-				insecure = true
+				server.Close()
+
+				tokens := 0
+				tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/sky/issuer/token":
+						tokens++
+						fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer"}`, tokens)
+					case "/api/v1/teams/main/pipelines/some-pipeline/config":
+						if r.Header.Get("Authorization") != "bearer token-2" {
+							w.WriteHeader(http.StatusUnauthorized)
+							return
+						}
+						w.Header().Set("X-Concourse-Config-Version", "43")
+						w.Write([]byte(`{"config":{}}`))
+					}
+				}))
 			})
 
-			It("adds -k flag to command", func() {
-				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+			AfterEach(func() {
+				tokenServer.Close()
+			})
+
+			It("obtains a new token and repeats the request", func() {
+				_, err := flyCommand.LoginWithClientCredentials(tokenServer.URL, "main", "some-client-id", "some-client-secret", false)
 				Expect(err).NotTo(HaveOccurred())
 
-				expectedOutput := fmt.Sprintf(
-					"%s %s %s %s %s %s %s %s %s %s %s %s\n%s %s %s\n",
-					"-t", target,
-					"login",
-					"-c", url,
-					"-n", teamName,
-					"-u", username,
-					"-p", password,
-					"-k",
-					"-t", target,
-					"sync",
-				)
+				_, version, err := flyCommand.GetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"})
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(string(output)).To(Equal(expectedOutput))
+				Expect(version).To(Equal("43"))
 			})
 		})
 
-		Context("when there is an error starting the commmand", func() {
+		Context("when the ATC compresses the response", func() {
 			BeforeEach(func() {
-				fakeFlyContents = ""
+				server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					defer GinkgoRecover()
+
+					Expect(r.Header.Get("Accept-Encoding")).To(ContainSubstring("gzip"))
+
+					w.Header().Set("Content-Encoding", "gzip")
+					w.Header().Set("X-Concourse-Config-Version", "42")
+
+					gw := gzip.NewWriter(w)
+					gw.Write([]byte(`{"config":{"jobs":[{"name":"some-job"}]}}`))
+					gw.Close()
+				})
 			})
 
-			It("returns an error", func() {
-				_, err := flyCommand.Login(url, teamName, username, password, insecure)
-				Expect(err).To(HaveOccurred())
+			It("decompresses the response", func() {
+				config, version, err := flyCommand.GetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(version).To(Equal("42"))
+				Expect(config.Jobs[0]["name"]).To(Equal("some-job"))
 			})
 		})
 
-		Context("when no username or password is specified", func() {
-			BeforeEach(func() {
-				username = ""
-				password = ""
-			})
+		It("reuses connections between requests", func() {
+			var newConns int32
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					atomic.AddInt32(&newConns, 1)
+				}
+			}
 
-			It("does not pass the `p` or `u` flags to fly", func() {
-				output, err := flyCommand.Login(url, teamName, username, password, insecure)
+			for i := 0; i < 3; i++ {
+				_, _, err := flyCommand.GetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"})
 				Expect(err).NotTo(HaveOccurred())
+			}
 
-				expectedOutput := fmt.Sprintf(
-					"%s %s %s %s %s %s %s\n%s %s %s\n",
-					"-t", target,
-					"login",
-					"-c", url,
-					"-n", teamName,
-					"-t", target,
-					"sync",
-				)
+			Expect(atomic.LoadInt32(&newConns)).To(Equal(int32(1)))
+		})
 
-				Expect(string(output)).To(Equal(expectedOutput))
-			})
+		It("returns the config and config version without error", func() {
+			config, version, err := flyCommand.GetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(version).To(Equal("42"))
+			Expect(config.Jobs).To(HaveLen(1))
+			Expect(config.Jobs[0]["name"]).To(Equal("some-job"))
 		})
 
-		Context("when the command returns an error", func() {
+		Context("when not logged in to the target", func() {
 			BeforeEach(func() {
-				fakeFlyContents = errScript
+				err := os.Remove(filepath.Join(tempDir, ".flyrc"))
+				Expect(err).NotTo(HaveOccurred())
 			})
 
-			It("appends stderr to the error", func() {
-				_, err := flyCommand.Login(url, teamName, username, password, insecure)
+			It("returns an error", func() {
+				_, _, err := flyCommand.GetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"})
 				Expect(err).To(HaveOccurred())
 
-				Expect(err.Error()).To(MatchRegexp(".*some err output.*"))
+				Expect(err.Error()).To(ContainSubstring("login first"))
 			})
 		})
 	})
 
-	Describe("Pipelines", func() {
+	Describe("SetPipelineConfig", func() {
+		var (
+			server *httptest.Server
+
+			statusCode int
+		)
+
 		BeforeEach(func() {
-			fakeFlyContents = `#!/bin/sh
-echo '[{"name":"abc"},{"name":"def"}]'
-`
-		})
+			statusCode = http.StatusOK
 
-		It("returns pipelines without error", func() {
-			pipelines, err := flyCommand.Pipelines()
-			Expect(err).NotTo(HaveOccurred())
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
 
-			Expect(pipelines).To(Equal([]string{"abc", "def"}))
-		})
-	})
+				Expect(r.Method).To(Equal("PUT"))
+				Expect(r.URL.Path).To(Equal("/api/v1/teams/main/pipelines/some-pipeline/config"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/x-yaml"))
+				Expect(r.Header.Get("X-Concourse-Config-Version")).To(Equal("42"))
 
-	Describe("GetPipeline", func() {
-		var (
-			pipelineName string
-		)
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(Equal("jobs: []\n"))
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+				w.WriteHeader(statusCode)
+				w.Write([]byte(`{"warnings":[{"type":"pipeline","message":"some warning"}]}`))
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
 		})
 
-		It("returns output without error", func() {
-			output, err := flyCommand.GetPipeline(pipelineName)
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("sets the config and returns the warnings without error", func() {
+			warnings, err := flyCommand.SetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"}, []byte("jobs: []\n"), "42")
 			Expect(err).NotTo(HaveOccurred())
 
-			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
-				"-t", target,
-				"get-pipeline",
-				"-p", pipelineName,
-			)
+			Expect(warnings).To(Equal([]fly.ConfigWarning{
+				{Type: "pipeline", Message: "some warning"},
+			}))
+		})
 
-			Expect(string(output)).To(Equal(expectedOutput))
+		Context("when the config has changed since the version", func() {
+			BeforeEach(func() {
+				statusCode = http.StatusConflict
+			})
+
+			It("returns a conflict error", func() {
+				_, err := flyCommand.SetPipelineConfig(fly.PipelineRef{Name: "some-pipeline"}, []byte("jobs: []\n"), "42")
+				Expect(errors.Is(err, fly.ErrConflict)).To(BeTrue())
+			})
 		})
 	})
 
@@ -237,6 +1853,21 @@ echo '[{"name":"abc"},{"name":"def"}]'
 			Expect(string(output)).To(Equal(expectedOutput))
 		})
 
+		Context("when fly warns about the config", func() {
+			BeforeEach(func() {
+				fakeFlyContents = `#!/bin/sh
+echo configuration updated
+>&2 printf 'WARNING:\n  - invalid identifier\n'`
+			})
+
+			It("returns the warnings after the output", func() {
+				output, err := flyCommand.SetPipeline(pipelineName, configFilepath, nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(output)).To(Equal("configuration updated\nWARNING:\n  - invalid identifier\n"))
+			})
+		})
+
 		Context("when optional vars are provided", func() {
 
 			var (
@@ -299,6 +1930,28 @@ echo '[{"name":"abc"},{"name":"def"}]'
 		})
 	})
 
+	Describe("RenderPipeline", func() {
+		It("returns the config rendered by fly with the vars", func() {
+			output, err := flyCommand.RenderPipeline("some-config-file", []string{"vars-file-1"}, map[string]interface{}{"launch-missiles": true})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(output)).To(Equal(fmt.Sprintf(
+				"-t %s validate-pipeline -c some-config-file -o -l vars-file-1 -y launch-missiles=true\n",
+				target,
+			)))
+		})
+
+		It("does not write the config to the logger", func() {
+			_, err := flyCommand.RenderPipeline("some-config-file", nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := 0; i < fakeLogger.DebugfCallCount(); i++ {
+				format, args := fakeLogger.DebugfArgsForCall(i)
+				Expect(fmt.Sprintf(format, args...)).NotTo(HavePrefix("fly stdout: "))
+			}
+		})
+	})
+
 	Describe("DestroyPipeline", func() {
 		var (
 			pipelineName string
@@ -324,7 +1977,104 @@ echo '[{"name":"abc"},{"name":"def"}]'
 		})
 	})
 
-	Describe("UnpausePipeline", func() {
+	Describe("pipeline actions", func() {
+		var (
+			server *httptest.Server
+
+			requests []string
+		)
+
+		BeforeEach(func() {
+			requests = nil
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer some-token"))
+
+				requests = append(requests, r.Method+" "+r.URL.Path)
+			}))
+
+			options.Home = tempDir
+
+			writeFlyrc(server.URL)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("pauses and unpauses the pipeline without running fly", func() {
+			fakeFlyContents = `#!/bin/sh
+exit 1`
+
+			err := flyCommand.PausePipeline(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = flyCommand.UnpausePipeline(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(Equal([]string{
+				"PUT /api/v1/teams/main/pipelines/some-pipeline/pause",
+				"PUT /api/v1/teams/main/pipelines/some-pipeline/unpause",
+			}))
+		})
+
+		It("exposes and hides the pipeline without running fly", func() {
+			fakeFlyContents = `#!/bin/sh
+exit 1`
+
+			err := flyCommand.ExposePipeline(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = flyCommand.HidePipeline(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(Equal([]string{
+				"PUT /api/v1/teams/main/pipelines/some-pipeline/expose",
+				"PUT /api/v1/teams/main/pipelines/some-pipeline/hide",
+			}))
+		})
+
+		It("writes the equivalent fly command to the logger", func() {
+			err := flyCommand.PausePipeline(fly.PipelineRef{Name: "some-pipeline"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var lines []string
+			for i := 0; i < fakeLogger.DebugfCallCount(); i++ {
+				format, args := fakeLogger.DebugfArgsForCall(i)
+				lines = append(lines, fmt.Sprintf(format, args...))
+			}
+
+			Expect(lines).To(ContainElement(fmt.Sprintf(
+				"Equivalent fly command: fly -t %s pause-pipeline -p some-pipeline --team main\n",
+				target,
+			)))
+		})
+
+		Context("when the request fails with a transient error", func() {
+			BeforeEach(func() {
+				options.Retries = 2
+				options.RetryBackoff = time.Millisecond
+
+				server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					requests = append(requests, r.Method+" "+r.URL.Path)
+					if len(requests) < 3 {
+						w.WriteHeader(http.StatusBadGateway)
+					}
+				})
+			})
+
+			It("retries the request", func() {
+				err := flyCommand.PausePipeline(fly.PipelineRef{Name: "some-pipeline"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(requests).To(HaveLen(3))
+			})
+		})
+	})
+
+	Describe("ArchivePipeline", func() {
 		var (
 			pipelineName string
 		)
@@ -334,13 +2084,14 @@ echo '[{"name":"abc"},{"name":"def"}]'
 		})
 
 		It("returns output without error", func() {
-			output, err := flyCommand.UnpausePipeline(pipelineName)
+			output, err := flyCommand.ArchivePipeline(pipelineName)
 			Expect(err).NotTo(HaveOccurred())
 
 			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
+				"%s %s %s %s %s %s\n",
 				"-t", target,
-				"unpause-pipeline",
+				"archive-pipeline",
+				"-n",
 				"-p", pipelineName,
 			)
 
@@ -348,27 +2099,63 @@ echo '[{"name":"abc"},{"name":"def"}]'
 		})
 	})
 
-	Describe("ExposePipeline", func() {
-		var (
-			pipelineName string
-		)
+	Describe("OrderPipelines", func() {
+		It("returns output without error", func() {
+			output, err := flyCommand.OrderPipelines([]string{"pipeline-1", "pipeline-2"})
+			Expect(err).NotTo(HaveOccurred())
 
-		BeforeEach(func() {
-			pipelineName = "some-pipeline"
+			expectedOutput := fmt.Sprintf(
+				"%s %s %s %s %s %s %s\n",
+				"-t", target,
+				"order-pipelines",
+				"-p", "pipeline-1",
+				"-p", "pipeline-2",
+			)
+
+			Expect(string(output)).To(Equal(expectedOutput))
 		})
+	})
 
+	Describe("RenamePipeline", func() {
 		It("returns output without error", func() {
-			output, err := flyCommand.ExposePipeline(pipelineName)
+			output, err := flyCommand.RenamePipeline("old-name", "new-name")
 			Expect(err).NotTo(HaveOccurred())
 
 			expectedOutput := fmt.Sprintf(
-				"%s %s %s %s %s\n",
+				"%s %s %s %s %s %s %s\n",
 				"-t", target,
-				"expose-pipeline",
-				"-p", pipelineName,
+				"rename-pipeline",
+				"-o", "old-name",
+				"-n", "new-name",
 			)
 
 			Expect(string(output)).To(Equal(expectedOutput))
 		})
 	})
 })
+
+// withClientCert returns the options with a newly generated, self-signed
+// client certificate.
+func withClientCert(options fly.Options) fly.Options {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "some-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	options.ClientCert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	options.ClientKey = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	return options
+}
@@ -8,6 +8,33 @@ import (
 )
 
 type FakeCommand struct {
+	ArchivePipelineStub        func(string) ([]byte, error)
+	archivePipelineMutex       sync.RWMutex
+	archivePipelineArgsForCall []struct {
+		arg1 string
+	}
+	archivePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	archivePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	BuildsStub        func(fly.PipelineRef, int) ([]fly.Build, error)
+	buildsMutex       sync.RWMutex
+	buildsArgsForCall []struct {
+		arg1 fly.PipelineRef
+		arg2 int
+	}
+	buildsReturns struct {
+		result1 []fly.Build
+		result2 error
+	}
+	buildsReturnsOnCall map[int]struct {
+		result1 []fly.Build
+		result2 error
+	}
 	DestroyPipelineStub        func(string) ([]byte, error)
 	destroyPipelineMutex       sync.RWMutex
 	destroyPipelineArgsForCall []struct {
@@ -21,17 +48,27 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	ExposePipelineStub        func(string) ([]byte, error)
+	ExposePipelineStub        func(fly.PipelineRef) error
 	exposePipelineMutex       sync.RWMutex
 	exposePipelineArgsForCall []struct {
-		arg1 string
+		arg1 fly.PipelineRef
 	}
 	exposePipelineReturns struct {
-		result1 []byte
-		result2 error
+		result1 error
 	}
 	exposePipelineReturnsOnCall map[int]struct {
-		result1 []byte
+		result1 error
+	}
+	FlyVersionStub        func() (string, error)
+	flyVersionMutex       sync.RWMutex
+	flyVersionArgsForCall []struct {
+	}
+	flyVersionReturns struct {
+		result1 string
+		result2 error
+	}
+	flyVersionReturnsOnCall map[int]struct {
+		result1 string
 		result2 error
 	}
 	GetPipelineStub        func(string) ([]byte, error)
@@ -47,6 +84,70 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
+	GetPipelineConfigStub        func(fly.PipelineRef) (fly.PipelineConfig, string, error)
+	getPipelineConfigMutex       sync.RWMutex
+	getPipelineConfigArgsForCall []struct {
+		arg1 fly.PipelineRef
+	}
+	getPipelineConfigReturns struct {
+		result1 fly.PipelineConfig
+		result2 string
+		result3 error
+	}
+	getPipelineConfigReturnsOnCall map[int]struct {
+		result1 fly.PipelineConfig
+		result2 string
+		result3 error
+	}
+	GetPipelineJSONStub        func(string) (fly.PipelineConfig, error)
+	getPipelineJSONMutex       sync.RWMutex
+	getPipelineJSONArgsForCall []struct {
+		arg1 string
+	}
+	getPipelineJSONReturns struct {
+		result1 fly.PipelineConfig
+		result2 error
+	}
+	getPipelineJSONReturnsOnCall map[int]struct {
+		result1 fly.PipelineConfig
+		result2 error
+	}
+	HidePipelineStub        func(fly.PipelineRef) error
+	hidePipelineMutex       sync.RWMutex
+	hidePipelineArgsForCall []struct {
+		arg1 fly.PipelineRef
+	}
+	hidePipelineReturns struct {
+		result1 error
+	}
+	hidePipelineReturnsOnCall map[int]struct {
+		result1 error
+	}
+	InfoStub        func() (fly.Info, error)
+	infoMutex       sync.RWMutex
+	infoArgsForCall []struct {
+	}
+	infoReturns struct {
+		result1 fly.Info
+		result2 error
+	}
+	infoReturnsOnCall map[int]struct {
+		result1 fly.Info
+		result2 error
+	}
+	JobsStub        func(fly.PipelineRef) ([]fly.Job, error)
+	jobsMutex       sync.RWMutex
+	jobsArgsForCall []struct {
+		arg1 fly.PipelineRef
+	}
+	jobsReturns struct {
+		result1 []fly.Job
+		result2 error
+	}
+	jobsReturnsOnCall map[int]struct {
+		result1 []fly.Job
+		result2 error
+	}
 	LoginStub        func(string, string, string, string, bool) ([]byte, error)
 	loginMutex       sync.RWMutex
 	loginArgsForCall []struct {
@@ -64,16 +165,116 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	PipelinesStub        func() ([]string, error)
+	LoginWithClientCredentialsStub        func(string, string, string, string, bool) ([]byte, error)
+	loginWithClientCredentialsMutex       sync.RWMutex
+	loginWithClientCredentialsArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}
+	loginWithClientCredentialsReturns struct {
+		result1 []byte
+		result2 error
+	}
+	loginWithClientCredentialsReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	LoginWithTokenStub        func(string, string, string, bool) ([]byte, error)
+	loginWithTokenMutex       sync.RWMutex
+	loginWithTokenArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 bool
+	}
+	loginWithTokenReturns struct {
+		result1 []byte
+		result2 error
+	}
+	loginWithTokenReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	OrderPipelinesStub        func([]string) ([]byte, error)
+	orderPipelinesMutex       sync.RWMutex
+	orderPipelinesArgsForCall []struct {
+		arg1 []string
+	}
+	orderPipelinesReturns struct {
+		result1 []byte
+		result2 error
+	}
+	orderPipelinesReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	PausePipelineStub        func(fly.PipelineRef) error
+	pausePipelineMutex       sync.RWMutex
+	pausePipelineArgsForCall []struct {
+		arg1 fly.PipelineRef
+	}
+	pausePipelineReturns struct {
+		result1 error
+	}
+	pausePipelineReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PipelinesStub        func(bool) ([]fly.Pipeline, error)
 	pipelinesMutex       sync.RWMutex
 	pipelinesArgsForCall []struct {
+		arg1 bool
 	}
 	pipelinesReturns struct {
-		result1 []string
+		result1 []fly.Pipeline
 		result2 error
 	}
 	pipelinesReturnsOnCall map[int]struct {
-		result1 []string
+		result1 []fly.Pipeline
+		result2 error
+	}
+	RenamePipelineStub        func(string, string) ([]byte, error)
+	renamePipelineMutex       sync.RWMutex
+	renamePipelineArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	renamePipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	renamePipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	RenderPipelineStub        func(string, []string, map[string]interface{}) ([]byte, error)
+	renderPipelineMutex       sync.RWMutex
+	renderPipelineArgsForCall []struct {
+		arg1 string
+		arg2 []string
+		arg3 map[string]interface{}
+	}
+	renderPipelineReturns struct {
+		result1 []byte
+		result2 error
+	}
+	renderPipelineReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
+	ResourcesStub        func(fly.PipelineRef) ([]fly.Resource, error)
+	resourcesMutex       sync.RWMutex
+	resourcesArgsForCall []struct {
+		arg1 fly.PipelineRef
+	}
+	resourcesReturns struct {
+		result1 []fly.Resource
+		result2 error
+	}
+	resourcesReturnsOnCall map[int]struct {
+		result1 []fly.Resource
 		result2 error
 	}
 	SetPipelineStub        func(string, string, []string, map[string]interface{}) ([]byte, error)
@@ -92,38 +293,205 @@ type FakeCommand struct {
 		result1 []byte
 		result2 error
 	}
-	UnpausePipelineStub        func(string) ([]byte, error)
+	SetPipelineConfigStub        func(fly.PipelineRef, []byte, string) ([]fly.ConfigWarning, error)
+	setPipelineConfigMutex       sync.RWMutex
+	setPipelineConfigArgsForCall []struct {
+		arg1 fly.PipelineRef
+		arg2 []byte
+		arg3 string
+	}
+	setPipelineConfigReturns struct {
+		result1 []fly.ConfigWarning
+		result2 error
+	}
+	setPipelineConfigReturnsOnCall map[int]struct {
+		result1 []fly.ConfigWarning
+		result2 error
+	}
+	TeamsStub        func() ([]string, error)
+	teamsMutex       sync.RWMutex
+	teamsArgsForCall []struct {
+	}
+	teamsReturns struct {
+		result1 []string
+		result2 error
+	}
+	teamsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	UnpausePipelineStub        func(fly.PipelineRef) error
 	unpausePipelineMutex       sync.RWMutex
 	unpausePipelineArgsForCall []struct {
-		arg1 string
+		arg1 fly.PipelineRef
 	}
 	unpausePipelineReturns struct {
-		result1 []byte
-		result2 error
+		result1 error
 	}
 	unpausePipelineReturnsOnCall map[int]struct {
-		result1 []byte
+		result1 error
+	}
+	UserInfoStub        func() (fly.UserInfo, error)
+	userInfoMutex       sync.RWMutex
+	userInfoArgsForCall []struct {
+	}
+	userInfoReturns struct {
+		result1 fly.UserInfo
+		result2 error
+	}
+	userInfoReturnsOnCall map[int]struct {
+		result1 fly.UserInfo
 		result2 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeCommand) ArchivePipeline(arg1 string) ([]byte, error) {
+	fake.archivePipelineMutex.Lock()
+	ret, specificReturn := fake.archivePipelineReturnsOnCall[len(fake.archivePipelineArgsForCall)]
+	fake.archivePipelineArgsForCall = append(fake.archivePipelineArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ArchivePipelineStub
+	fakeReturns := fake.archivePipelineReturns
+	fake.recordInvocation("ArchivePipeline", []interface{}{arg1})
+	fake.archivePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) ArchivePipelineCallCount() int {
+	fake.archivePipelineMutex.RLock()
+	defer fake.archivePipelineMutex.RUnlock()
+	return len(fake.archivePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) ArchivePipelineCalls(stub func(string) ([]byte, error)) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = stub
+}
+
+func (fake *FakeCommand) ArchivePipelineArgsForCall(i int) string {
+	fake.archivePipelineMutex.RLock()
+	defer fake.archivePipelineMutex.RUnlock()
+	argsForCall := fake.archivePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) ArchivePipelineReturns(result1 []byte, result2 error) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = nil
+	fake.archivePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) ArchivePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.archivePipelineMutex.Lock()
+	defer fake.archivePipelineMutex.Unlock()
+	fake.ArchivePipelineStub = nil
+	if fake.archivePipelineReturnsOnCall == nil {
+		fake.archivePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.archivePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Builds(arg1 fly.PipelineRef, arg2 int) ([]fly.Build, error) {
+	fake.buildsMutex.Lock()
+	ret, specificReturn := fake.buildsReturnsOnCall[len(fake.buildsArgsForCall)]
+	fake.buildsArgsForCall = append(fake.buildsArgsForCall, struct {
+		arg1 fly.PipelineRef
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.BuildsStub
+	fakeReturns := fake.buildsReturns
+	fake.recordInvocation("Builds", []interface{}{arg1, arg2})
+	fake.buildsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) BuildsCallCount() int {
+	fake.buildsMutex.RLock()
+	defer fake.buildsMutex.RUnlock()
+	return len(fake.buildsArgsForCall)
+}
+
+func (fake *FakeCommand) BuildsCalls(stub func(fly.PipelineRef, int) ([]fly.Build, error)) {
+	fake.buildsMutex.Lock()
+	defer fake.buildsMutex.Unlock()
+	fake.BuildsStub = stub
+}
+
+func (fake *FakeCommand) BuildsArgsForCall(i int) (fly.PipelineRef, int) {
+	fake.buildsMutex.RLock()
+	defer fake.buildsMutex.RUnlock()
+	argsForCall := fake.buildsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCommand) BuildsReturns(result1 []fly.Build, result2 error) {
+	fake.buildsMutex.Lock()
+	defer fake.buildsMutex.Unlock()
+	fake.BuildsStub = nil
+	fake.buildsReturns = struct {
+		result1 []fly.Build
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) BuildsReturnsOnCall(i int, result1 []fly.Build, result2 error) {
+	fake.buildsMutex.Lock()
+	defer fake.buildsMutex.Unlock()
+	fake.BuildsStub = nil
+	if fake.buildsReturnsOnCall == nil {
+		fake.buildsReturnsOnCall = make(map[int]struct {
+			result1 []fly.Build
+			result2 error
+		})
+	}
+	fake.buildsReturnsOnCall[i] = struct {
+		result1 []fly.Build
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCommand) DestroyPipeline(arg1 string) ([]byte, error) {
 	fake.destroyPipelineMutex.Lock()
 	ret, specificReturn := fake.destroyPipelineReturnsOnCall[len(fake.destroyPipelineArgsForCall)]
 	fake.destroyPipelineArgsForCall = append(fake.destroyPipelineArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.DestroyPipelineStub
+	fakeReturns := fake.destroyPipelineReturns
 	fake.recordInvocation("DestroyPipeline", []interface{}{arg1})
 	fake.destroyPipelineMutex.Unlock()
-	if fake.DestroyPipelineStub != nil {
-		return fake.DestroyPipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.destroyPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -172,22 +540,23 @@ func (fake *FakeCommand) DestroyPipelineReturnsOnCall(i int, result1 []byte, res
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) ExposePipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) ExposePipeline(arg1 fly.PipelineRef) error {
 	fake.exposePipelineMutex.Lock()
 	ret, specificReturn := fake.exposePipelineReturnsOnCall[len(fake.exposePipelineArgsForCall)]
 	fake.exposePipelineArgsForCall = append(fake.exposePipelineArgsForCall, struct {
-		arg1 string
+		arg1 fly.PipelineRef
 	}{arg1})
+	stub := fake.ExposePipelineStub
+	fakeReturns := fake.exposePipelineReturns
 	fake.recordInvocation("ExposePipeline", []interface{}{arg1})
 	fake.exposePipelineMutex.Unlock()
-	if fake.ExposePipelineStub != nil {
-		return fake.ExposePipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	fakeReturns := fake.exposePipelineReturns
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1
 }
 
 func (fake *FakeCommand) ExposePipelineCallCount() int {
@@ -196,41 +565,94 @@ func (fake *FakeCommand) ExposePipelineCallCount() int {
 	return len(fake.exposePipelineArgsForCall)
 }
 
-func (fake *FakeCommand) ExposePipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) ExposePipelineCalls(stub func(fly.PipelineRef) error) {
 	fake.exposePipelineMutex.Lock()
 	defer fake.exposePipelineMutex.Unlock()
 	fake.ExposePipelineStub = stub
 }
 
-func (fake *FakeCommand) ExposePipelineArgsForCall(i int) string {
+func (fake *FakeCommand) ExposePipelineArgsForCall(i int) fly.PipelineRef {
 	fake.exposePipelineMutex.RLock()
 	defer fake.exposePipelineMutex.RUnlock()
 	argsForCall := fake.exposePipelineArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeCommand) ExposePipelineReturns(result1 []byte, result2 error) {
+func (fake *FakeCommand) ExposePipelineReturns(result1 error) {
 	fake.exposePipelineMutex.Lock()
 	defer fake.exposePipelineMutex.Unlock()
 	fake.ExposePipelineStub = nil
 	fake.exposePipelineReturns = struct {
-		result1 []byte
-		result2 error
-	}{result1, result2}
+		result1 error
+	}{result1}
 }
 
-func (fake *FakeCommand) ExposePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+func (fake *FakeCommand) ExposePipelineReturnsOnCall(i int, result1 error) {
 	fake.exposePipelineMutex.Lock()
 	defer fake.exposePipelineMutex.Unlock()
 	fake.ExposePipelineStub = nil
 	if fake.exposePipelineReturnsOnCall == nil {
 		fake.exposePipelineReturnsOnCall = make(map[int]struct {
-			result1 []byte
-			result2 error
+			result1 error
 		})
 	}
 	fake.exposePipelineReturnsOnCall[i] = struct {
-		result1 []byte
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) FlyVersion() (string, error) {
+	fake.flyVersionMutex.Lock()
+	ret, specificReturn := fake.flyVersionReturnsOnCall[len(fake.flyVersionArgsForCall)]
+	fake.flyVersionArgsForCall = append(fake.flyVersionArgsForCall, struct {
+	}{})
+	stub := fake.FlyVersionStub
+	fakeReturns := fake.flyVersionReturns
+	fake.recordInvocation("FlyVersion", []interface{}{})
+	fake.flyVersionMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) FlyVersionCallCount() int {
+	fake.flyVersionMutex.RLock()
+	defer fake.flyVersionMutex.RUnlock()
+	return len(fake.flyVersionArgsForCall)
+}
+
+func (fake *FakeCommand) FlyVersionCalls(stub func() (string, error)) {
+	fake.flyVersionMutex.Lock()
+	defer fake.flyVersionMutex.Unlock()
+	fake.FlyVersionStub = stub
+}
+
+func (fake *FakeCommand) FlyVersionReturns(result1 string, result2 error) {
+	fake.flyVersionMutex.Lock()
+	defer fake.flyVersionMutex.Unlock()
+	fake.FlyVersionStub = nil
+	fake.flyVersionReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) FlyVersionReturnsOnCall(i int, result1 string, result2 error) {
+	fake.flyVersionMutex.Lock()
+	defer fake.flyVersionMutex.Unlock()
+	fake.FlyVersionStub = nil
+	if fake.flyVersionReturnsOnCall == nil {
+		fake.flyVersionReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.flyVersionReturnsOnCall[i] = struct {
+		result1 string
 		result2 error
 	}{result1, result2}
 }
@@ -241,15 +663,16 @@ func (fake *FakeCommand) GetPipeline(arg1 string) ([]byte, error) {
 	fake.getPipelineArgsForCall = append(fake.getPipelineArgsForCall, struct {
 		arg1 string
 	}{arg1})
+	stub := fake.GetPipelineStub
+	fakeReturns := fake.getPipelineReturns
 	fake.recordInvocation("GetPipeline", []interface{}{arg1})
 	fake.getPipelineMutex.Unlock()
-	if fake.GetPipelineStub != nil {
-		return fake.GetPipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.getPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -298,65 +721,378 @@ func (fake *FakeCommand) GetPipelineReturnsOnCall(i int, result1 []byte, result2
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) Login(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) ([]byte, error) {
-	fake.loginMutex.Lock()
-	ret, specificReturn := fake.loginReturnsOnCall[len(fake.loginArgsForCall)]
-	fake.loginArgsForCall = append(fake.loginArgsForCall, struct {
-		arg1 string
-		arg2 string
-		arg3 string
-		arg4 string
-		arg5 bool
-	}{arg1, arg2, arg3, arg4, arg5})
-	fake.recordInvocation("Login", []interface{}{arg1, arg2, arg3, arg4, arg5})
-	fake.loginMutex.Unlock()
-	if fake.LoginStub != nil {
-		return fake.LoginStub(arg1, arg2, arg3, arg4, arg5)
+func (fake *FakeCommand) GetPipelineConfig(arg1 fly.PipelineRef) (fly.PipelineConfig, string, error) {
+	fake.getPipelineConfigMutex.Lock()
+	ret, specificReturn := fake.getPipelineConfigReturnsOnCall[len(fake.getPipelineConfigArgsForCall)]
+	fake.getPipelineConfigArgsForCall = append(fake.getPipelineConfigArgsForCall, struct {
+		arg1 fly.PipelineRef
+	}{arg1})
+	stub := fake.GetPipelineConfigStub
+	fakeReturns := fake.getPipelineConfigReturns
+	fake.recordInvocation("GetPipelineConfig", []interface{}{arg1})
+	fake.getPipelineConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1, ret.result2, ret.result3
 	}
-	fakeReturns := fake.loginReturns
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
 }
 
-func (fake *FakeCommand) LoginCallCount() int {
-	fake.loginMutex.RLock()
-	defer fake.loginMutex.RUnlock()
-	return len(fake.loginArgsForCall)
+func (fake *FakeCommand) GetPipelineConfigCallCount() int {
+	fake.getPipelineConfigMutex.RLock()
+	defer fake.getPipelineConfigMutex.RUnlock()
+	return len(fake.getPipelineConfigArgsForCall)
 }
 
-func (fake *FakeCommand) LoginCalls(stub func(string, string, string, string, bool) ([]byte, error)) {
-	fake.loginMutex.Lock()
-	defer fake.loginMutex.Unlock()
-	fake.LoginStub = stub
+func (fake *FakeCommand) GetPipelineConfigCalls(stub func(fly.PipelineRef) (fly.PipelineConfig, string, error)) {
+	fake.getPipelineConfigMutex.Lock()
+	defer fake.getPipelineConfigMutex.Unlock()
+	fake.GetPipelineConfigStub = stub
 }
 
-func (fake *FakeCommand) LoginArgsForCall(i int) (string, string, string, string, bool) {
-	fake.loginMutex.RLock()
-	defer fake.loginMutex.RUnlock()
-	argsForCall := fake.loginArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+func (fake *FakeCommand) GetPipelineConfigArgsForCall(i int) fly.PipelineRef {
+	fake.getPipelineConfigMutex.RLock()
+	defer fake.getPipelineConfigMutex.RUnlock()
+	argsForCall := fake.getPipelineConfigArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *FakeCommand) LoginReturns(result1 []byte, result2 error) {
-	fake.loginMutex.Lock()
-	defer fake.loginMutex.Unlock()
-	fake.LoginStub = nil
-	fake.loginReturns = struct {
-		result1 []byte
-		result2 error
-	}{result1, result2}
+func (fake *FakeCommand) GetPipelineConfigReturns(result1 fly.PipelineConfig, result2 string, result3 error) {
+	fake.getPipelineConfigMutex.Lock()
+	defer fake.getPipelineConfigMutex.Unlock()
+	fake.GetPipelineConfigStub = nil
+	fake.getPipelineConfigReturns = struct {
+		result1 fly.PipelineConfig
+		result2 string
+		result3 error
+	}{result1, result2, result3}
 }
 
-func (fake *FakeCommand) LoginReturnsOnCall(i int, result1 []byte, result2 error) {
-	fake.loginMutex.Lock()
-	defer fake.loginMutex.Unlock()
-	fake.LoginStub = nil
-	if fake.loginReturnsOnCall == nil {
-		fake.loginReturnsOnCall = make(map[int]struct {
-			result1 []byte
-			result2 error
+func (fake *FakeCommand) GetPipelineConfigReturnsOnCall(i int, result1 fly.PipelineConfig, result2 string, result3 error) {
+	fake.getPipelineConfigMutex.Lock()
+	defer fake.getPipelineConfigMutex.Unlock()
+	fake.GetPipelineConfigStub = nil
+	if fake.getPipelineConfigReturnsOnCall == nil {
+		fake.getPipelineConfigReturnsOnCall = make(map[int]struct {
+			result1 fly.PipelineConfig
+			result2 string
+			result3 error
+		})
+	}
+	fake.getPipelineConfigReturnsOnCall[i] = struct {
+		result1 fly.PipelineConfig
+		result2 string
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeCommand) GetPipelineJSON(arg1 string) (fly.PipelineConfig, error) {
+	fake.getPipelineJSONMutex.Lock()
+	ret, specificReturn := fake.getPipelineJSONReturnsOnCall[len(fake.getPipelineJSONArgsForCall)]
+	fake.getPipelineJSONArgsForCall = append(fake.getPipelineJSONArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetPipelineJSONStub
+	fakeReturns := fake.getPipelineJSONReturns
+	fake.recordInvocation("GetPipelineJSON", []interface{}{arg1})
+	fake.getPipelineJSONMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) GetPipelineJSONCallCount() int {
+	fake.getPipelineJSONMutex.RLock()
+	defer fake.getPipelineJSONMutex.RUnlock()
+	return len(fake.getPipelineJSONArgsForCall)
+}
+
+func (fake *FakeCommand) GetPipelineJSONCalls(stub func(string) (fly.PipelineConfig, error)) {
+	fake.getPipelineJSONMutex.Lock()
+	defer fake.getPipelineJSONMutex.Unlock()
+	fake.GetPipelineJSONStub = stub
+}
+
+func (fake *FakeCommand) GetPipelineJSONArgsForCall(i int) string {
+	fake.getPipelineJSONMutex.RLock()
+	defer fake.getPipelineJSONMutex.RUnlock()
+	argsForCall := fake.getPipelineJSONArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) GetPipelineJSONReturns(result1 fly.PipelineConfig, result2 error) {
+	fake.getPipelineJSONMutex.Lock()
+	defer fake.getPipelineJSONMutex.Unlock()
+	fake.GetPipelineJSONStub = nil
+	fake.getPipelineJSONReturns = struct {
+		result1 fly.PipelineConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) GetPipelineJSONReturnsOnCall(i int, result1 fly.PipelineConfig, result2 error) {
+	fake.getPipelineJSONMutex.Lock()
+	defer fake.getPipelineJSONMutex.Unlock()
+	fake.GetPipelineJSONStub = nil
+	if fake.getPipelineJSONReturnsOnCall == nil {
+		fake.getPipelineJSONReturnsOnCall = make(map[int]struct {
+			result1 fly.PipelineConfig
+			result2 error
+		})
+	}
+	fake.getPipelineJSONReturnsOnCall[i] = struct {
+		result1 fly.PipelineConfig
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) HidePipeline(arg1 fly.PipelineRef) error {
+	fake.hidePipelineMutex.Lock()
+	ret, specificReturn := fake.hidePipelineReturnsOnCall[len(fake.hidePipelineArgsForCall)]
+	fake.hidePipelineArgsForCall = append(fake.hidePipelineArgsForCall, struct {
+		arg1 fly.PipelineRef
+	}{arg1})
+	stub := fake.HidePipelineStub
+	fakeReturns := fake.hidePipelineReturns
+	fake.recordInvocation("HidePipeline", []interface{}{arg1})
+	fake.hidePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCommand) HidePipelineCallCount() int {
+	fake.hidePipelineMutex.RLock()
+	defer fake.hidePipelineMutex.RUnlock()
+	return len(fake.hidePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) HidePipelineCalls(stub func(fly.PipelineRef) error) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = stub
+}
+
+func (fake *FakeCommand) HidePipelineArgsForCall(i int) fly.PipelineRef {
+	fake.hidePipelineMutex.RLock()
+	defer fake.hidePipelineMutex.RUnlock()
+	argsForCall := fake.hidePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) HidePipelineReturns(result1 error) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = nil
+	fake.hidePipelineReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) HidePipelineReturnsOnCall(i int, result1 error) {
+	fake.hidePipelineMutex.Lock()
+	defer fake.hidePipelineMutex.Unlock()
+	fake.HidePipelineStub = nil
+	if fake.hidePipelineReturnsOnCall == nil {
+		fake.hidePipelineReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.hidePipelineReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) Info() (fly.Info, error) {
+	fake.infoMutex.Lock()
+	ret, specificReturn := fake.infoReturnsOnCall[len(fake.infoArgsForCall)]
+	fake.infoArgsForCall = append(fake.infoArgsForCall, struct {
+	}{})
+	stub := fake.InfoStub
+	fakeReturns := fake.infoReturns
+	fake.recordInvocation("Info", []interface{}{})
+	fake.infoMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) InfoCallCount() int {
+	fake.infoMutex.RLock()
+	defer fake.infoMutex.RUnlock()
+	return len(fake.infoArgsForCall)
+}
+
+func (fake *FakeCommand) InfoCalls(stub func() (fly.Info, error)) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = stub
+}
+
+func (fake *FakeCommand) InfoReturns(result1 fly.Info, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	fake.infoReturns = struct {
+		result1 fly.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) InfoReturnsOnCall(i int, result1 fly.Info, result2 error) {
+	fake.infoMutex.Lock()
+	defer fake.infoMutex.Unlock()
+	fake.InfoStub = nil
+	if fake.infoReturnsOnCall == nil {
+		fake.infoReturnsOnCall = make(map[int]struct {
+			result1 fly.Info
+			result2 error
+		})
+	}
+	fake.infoReturnsOnCall[i] = struct {
+		result1 fly.Info
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Jobs(arg1 fly.PipelineRef) ([]fly.Job, error) {
+	fake.jobsMutex.Lock()
+	ret, specificReturn := fake.jobsReturnsOnCall[len(fake.jobsArgsForCall)]
+	fake.jobsArgsForCall = append(fake.jobsArgsForCall, struct {
+		arg1 fly.PipelineRef
+	}{arg1})
+	stub := fake.JobsStub
+	fakeReturns := fake.jobsReturns
+	fake.recordInvocation("Jobs", []interface{}{arg1})
+	fake.jobsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) JobsCallCount() int {
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	return len(fake.jobsArgsForCall)
+}
+
+func (fake *FakeCommand) JobsCalls(stub func(fly.PipelineRef) ([]fly.Job, error)) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = stub
+}
+
+func (fake *FakeCommand) JobsArgsForCall(i int) fly.PipelineRef {
+	fake.jobsMutex.RLock()
+	defer fake.jobsMutex.RUnlock()
+	argsForCall := fake.jobsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) JobsReturns(result1 []fly.Job, result2 error) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	fake.jobsReturns = struct {
+		result1 []fly.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) JobsReturnsOnCall(i int, result1 []fly.Job, result2 error) {
+	fake.jobsMutex.Lock()
+	defer fake.jobsMutex.Unlock()
+	fake.JobsStub = nil
+	if fake.jobsReturnsOnCall == nil {
+		fake.jobsReturnsOnCall = make(map[int]struct {
+			result1 []fly.Job
+			result2 error
+		})
+	}
+	fake.jobsReturnsOnCall[i] = struct {
+		result1 []fly.Job
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Login(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) ([]byte, error) {
+	fake.loginMutex.Lock()
+	ret, specificReturn := fake.loginReturnsOnCall[len(fake.loginArgsForCall)]
+	fake.loginArgsForCall = append(fake.loginArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.LoginStub
+	fakeReturns := fake.loginReturns
+	fake.recordInvocation("Login", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.loginMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) LoginCallCount() int {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	return len(fake.loginArgsForCall)
+}
+
+func (fake *FakeCommand) LoginCalls(stub func(string, string, string, string, bool) ([]byte, error)) {
+	fake.loginMutex.Lock()
+	defer fake.loginMutex.Unlock()
+	fake.LoginStub = stub
+}
+
+func (fake *FakeCommand) LoginArgsForCall(i int) (string, string, string, string, bool) {
+	fake.loginMutex.RLock()
+	defer fake.loginMutex.RUnlock()
+	argsForCall := fake.loginArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeCommand) LoginReturns(result1 []byte, result2 error) {
+	fake.loginMutex.Lock()
+	defer fake.loginMutex.Unlock()
+	fake.LoginStub = nil
+	fake.loginReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) LoginReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.loginMutex.Lock()
+	defer fake.loginMutex.Unlock()
+	fake.LoginStub = nil
+	if fake.loginReturnsOnCall == nil {
+		fake.loginReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
 		})
 	}
 	fake.loginReturnsOnCall[i] = struct {
@@ -365,20 +1101,287 @@ func (fake *FakeCommand) LoginReturnsOnCall(i int, result1 []byte, result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) Pipelines() ([]string, error) {
+func (fake *FakeCommand) LoginWithClientCredentials(arg1 string, arg2 string, arg3 string, arg4 string, arg5 bool) ([]byte, error) {
+	fake.loginWithClientCredentialsMutex.Lock()
+	ret, specificReturn := fake.loginWithClientCredentialsReturnsOnCall[len(fake.loginWithClientCredentialsArgsForCall)]
+	fake.loginWithClientCredentialsArgsForCall = append(fake.loginWithClientCredentialsArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.LoginWithClientCredentialsStub
+	fakeReturns := fake.loginWithClientCredentialsReturns
+	fake.recordInvocation("LoginWithClientCredentials", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.loginWithClientCredentialsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) LoginWithClientCredentialsCallCount() int {
+	fake.loginWithClientCredentialsMutex.RLock()
+	defer fake.loginWithClientCredentialsMutex.RUnlock()
+	return len(fake.loginWithClientCredentialsArgsForCall)
+}
+
+func (fake *FakeCommand) LoginWithClientCredentialsCalls(stub func(string, string, string, string, bool) ([]byte, error)) {
+	fake.loginWithClientCredentialsMutex.Lock()
+	defer fake.loginWithClientCredentialsMutex.Unlock()
+	fake.LoginWithClientCredentialsStub = stub
+}
+
+func (fake *FakeCommand) LoginWithClientCredentialsArgsForCall(i int) (string, string, string, string, bool) {
+	fake.loginWithClientCredentialsMutex.RLock()
+	defer fake.loginWithClientCredentialsMutex.RUnlock()
+	argsForCall := fake.loginWithClientCredentialsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeCommand) LoginWithClientCredentialsReturns(result1 []byte, result2 error) {
+	fake.loginWithClientCredentialsMutex.Lock()
+	defer fake.loginWithClientCredentialsMutex.Unlock()
+	fake.LoginWithClientCredentialsStub = nil
+	fake.loginWithClientCredentialsReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) LoginWithClientCredentialsReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.loginWithClientCredentialsMutex.Lock()
+	defer fake.loginWithClientCredentialsMutex.Unlock()
+	fake.LoginWithClientCredentialsStub = nil
+	if fake.loginWithClientCredentialsReturnsOnCall == nil {
+		fake.loginWithClientCredentialsReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.loginWithClientCredentialsReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) LoginWithToken(arg1 string, arg2 string, arg3 string, arg4 bool) ([]byte, error) {
+	fake.loginWithTokenMutex.Lock()
+	ret, specificReturn := fake.loginWithTokenReturnsOnCall[len(fake.loginWithTokenArgsForCall)]
+	fake.loginWithTokenArgsForCall = append(fake.loginWithTokenArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 bool
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.LoginWithTokenStub
+	fakeReturns := fake.loginWithTokenReturns
+	fake.recordInvocation("LoginWithToken", []interface{}{arg1, arg2, arg3, arg4})
+	fake.loginWithTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) LoginWithTokenCallCount() int {
+	fake.loginWithTokenMutex.RLock()
+	defer fake.loginWithTokenMutex.RUnlock()
+	return len(fake.loginWithTokenArgsForCall)
+}
+
+func (fake *FakeCommand) LoginWithTokenCalls(stub func(string, string, string, bool) ([]byte, error)) {
+	fake.loginWithTokenMutex.Lock()
+	defer fake.loginWithTokenMutex.Unlock()
+	fake.LoginWithTokenStub = stub
+}
+
+func (fake *FakeCommand) LoginWithTokenArgsForCall(i int) (string, string, string, bool) {
+	fake.loginWithTokenMutex.RLock()
+	defer fake.loginWithTokenMutex.RUnlock()
+	argsForCall := fake.loginWithTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeCommand) LoginWithTokenReturns(result1 []byte, result2 error) {
+	fake.loginWithTokenMutex.Lock()
+	defer fake.loginWithTokenMutex.Unlock()
+	fake.LoginWithTokenStub = nil
+	fake.loginWithTokenReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) LoginWithTokenReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.loginWithTokenMutex.Lock()
+	defer fake.loginWithTokenMutex.Unlock()
+	fake.LoginWithTokenStub = nil
+	if fake.loginWithTokenReturnsOnCall == nil {
+		fake.loginWithTokenReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.loginWithTokenReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) OrderPipelines(arg1 []string) ([]byte, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.orderPipelinesMutex.Lock()
+	ret, specificReturn := fake.orderPipelinesReturnsOnCall[len(fake.orderPipelinesArgsForCall)]
+	fake.orderPipelinesArgsForCall = append(fake.orderPipelinesArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.OrderPipelinesStub
+	fakeReturns := fake.orderPipelinesReturns
+	fake.recordInvocation("OrderPipelines", []interface{}{arg1Copy})
+	fake.orderPipelinesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) OrderPipelinesCallCount() int {
+	fake.orderPipelinesMutex.RLock()
+	defer fake.orderPipelinesMutex.RUnlock()
+	return len(fake.orderPipelinesArgsForCall)
+}
+
+func (fake *FakeCommand) OrderPipelinesCalls(stub func([]string) ([]byte, error)) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = stub
+}
+
+func (fake *FakeCommand) OrderPipelinesArgsForCall(i int) []string {
+	fake.orderPipelinesMutex.RLock()
+	defer fake.orderPipelinesMutex.RUnlock()
+	argsForCall := fake.orderPipelinesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) OrderPipelinesReturns(result1 []byte, result2 error) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = nil
+	fake.orderPipelinesReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) OrderPipelinesReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.orderPipelinesMutex.Lock()
+	defer fake.orderPipelinesMutex.Unlock()
+	fake.OrderPipelinesStub = nil
+	if fake.orderPipelinesReturnsOnCall == nil {
+		fake.orderPipelinesReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.orderPipelinesReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) PausePipeline(arg1 fly.PipelineRef) error {
+	fake.pausePipelineMutex.Lock()
+	ret, specificReturn := fake.pausePipelineReturnsOnCall[len(fake.pausePipelineArgsForCall)]
+	fake.pausePipelineArgsForCall = append(fake.pausePipelineArgsForCall, struct {
+		arg1 fly.PipelineRef
+	}{arg1})
+	stub := fake.PausePipelineStub
+	fakeReturns := fake.pausePipelineReturns
+	fake.recordInvocation("PausePipeline", []interface{}{arg1})
+	fake.pausePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCommand) PausePipelineCallCount() int {
+	fake.pausePipelineMutex.RLock()
+	defer fake.pausePipelineMutex.RUnlock()
+	return len(fake.pausePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) PausePipelineCalls(stub func(fly.PipelineRef) error) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = stub
+}
+
+func (fake *FakeCommand) PausePipelineArgsForCall(i int) fly.PipelineRef {
+	fake.pausePipelineMutex.RLock()
+	defer fake.pausePipelineMutex.RUnlock()
+	argsForCall := fake.pausePipelineArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) PausePipelineReturns(result1 error) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = nil
+	fake.pausePipelineReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) PausePipelineReturnsOnCall(i int, result1 error) {
+	fake.pausePipelineMutex.Lock()
+	defer fake.pausePipelineMutex.Unlock()
+	fake.PausePipelineStub = nil
+	if fake.pausePipelineReturnsOnCall == nil {
+		fake.pausePipelineReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pausePipelineReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) Pipelines(arg1 bool) ([]fly.Pipeline, error) {
 	fake.pipelinesMutex.Lock()
 	ret, specificReturn := fake.pipelinesReturnsOnCall[len(fake.pipelinesArgsForCall)]
 	fake.pipelinesArgsForCall = append(fake.pipelinesArgsForCall, struct {
-	}{})
-	fake.recordInvocation("Pipelines", []interface{}{})
+		arg1 bool
+	}{arg1})
+	stub := fake.PipelinesStub
+	fakeReturns := fake.pipelinesReturns
+	fake.recordInvocation("Pipelines", []interface{}{arg1})
 	fake.pipelinesMutex.Unlock()
-	if fake.PipelinesStub != nil {
-		return fake.PipelinesStub()
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.pipelinesReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -388,34 +1391,241 @@ func (fake *FakeCommand) PipelinesCallCount() int {
 	return len(fake.pipelinesArgsForCall)
 }
 
-func (fake *FakeCommand) PipelinesCalls(stub func() ([]string, error)) {
+func (fake *FakeCommand) PipelinesCalls(stub func(bool) ([]fly.Pipeline, error)) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = stub
 }
 
-func (fake *FakeCommand) PipelinesReturns(result1 []string, result2 error) {
+func (fake *FakeCommand) PipelinesArgsForCall(i int) bool {
+	fake.pipelinesMutex.RLock()
+	defer fake.pipelinesMutex.RUnlock()
+	argsForCall := fake.pipelinesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) PipelinesReturns(result1 []fly.Pipeline, result2 error) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = nil
 	fake.pipelinesReturns = struct {
-		result1 []string
+		result1 []fly.Pipeline
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) PipelinesReturnsOnCall(i int, result1 []string, result2 error) {
+func (fake *FakeCommand) PipelinesReturnsOnCall(i int, result1 []fly.Pipeline, result2 error) {
 	fake.pipelinesMutex.Lock()
 	defer fake.pipelinesMutex.Unlock()
 	fake.PipelinesStub = nil
 	if fake.pipelinesReturnsOnCall == nil {
 		fake.pipelinesReturnsOnCall = make(map[int]struct {
-			result1 []string
+			result1 []fly.Pipeline
 			result2 error
 		})
 	}
 	fake.pipelinesReturnsOnCall[i] = struct {
-		result1 []string
+		result1 []fly.Pipeline
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) RenamePipeline(arg1 string, arg2 string) ([]byte, error) {
+	fake.renamePipelineMutex.Lock()
+	ret, specificReturn := fake.renamePipelineReturnsOnCall[len(fake.renamePipelineArgsForCall)]
+	fake.renamePipelineArgsForCall = append(fake.renamePipelineArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RenamePipelineStub
+	fakeReturns := fake.renamePipelineReturns
+	fake.recordInvocation("RenamePipeline", []interface{}{arg1, arg2})
+	fake.renamePipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) RenamePipelineCallCount() int {
+	fake.renamePipelineMutex.RLock()
+	defer fake.renamePipelineMutex.RUnlock()
+	return len(fake.renamePipelineArgsForCall)
+}
+
+func (fake *FakeCommand) RenamePipelineCalls(stub func(string, string) ([]byte, error)) {
+	fake.renamePipelineMutex.Lock()
+	defer fake.renamePipelineMutex.Unlock()
+	fake.RenamePipelineStub = stub
+}
+
+func (fake *FakeCommand) RenamePipelineArgsForCall(i int) (string, string) {
+	fake.renamePipelineMutex.RLock()
+	defer fake.renamePipelineMutex.RUnlock()
+	argsForCall := fake.renamePipelineArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCommand) RenamePipelineReturns(result1 []byte, result2 error) {
+	fake.renamePipelineMutex.Lock()
+	defer fake.renamePipelineMutex.Unlock()
+	fake.RenamePipelineStub = nil
+	fake.renamePipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) RenamePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.renamePipelineMutex.Lock()
+	defer fake.renamePipelineMutex.Unlock()
+	fake.RenamePipelineStub = nil
+	if fake.renamePipelineReturnsOnCall == nil {
+		fake.renamePipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.renamePipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) RenderPipeline(arg1 string, arg2 []string, arg3 map[string]interface{}) ([]byte, error) {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.renderPipelineMutex.Lock()
+	ret, specificReturn := fake.renderPipelineReturnsOnCall[len(fake.renderPipelineArgsForCall)]
+	fake.renderPipelineArgsForCall = append(fake.renderPipelineArgsForCall, struct {
+		arg1 string
+		arg2 []string
+		arg3 map[string]interface{}
+	}{arg1, arg2Copy, arg3})
+	stub := fake.RenderPipelineStub
+	fakeReturns := fake.renderPipelineReturns
+	fake.recordInvocation("RenderPipeline", []interface{}{arg1, arg2Copy, arg3})
+	fake.renderPipelineMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) RenderPipelineCallCount() int {
+	fake.renderPipelineMutex.RLock()
+	defer fake.renderPipelineMutex.RUnlock()
+	return len(fake.renderPipelineArgsForCall)
+}
+
+func (fake *FakeCommand) RenderPipelineCalls(stub func(string, []string, map[string]interface{}) ([]byte, error)) {
+	fake.renderPipelineMutex.Lock()
+	defer fake.renderPipelineMutex.Unlock()
+	fake.RenderPipelineStub = stub
+}
+
+func (fake *FakeCommand) RenderPipelineArgsForCall(i int) (string, []string, map[string]interface{}) {
+	fake.renderPipelineMutex.RLock()
+	defer fake.renderPipelineMutex.RUnlock()
+	argsForCall := fake.renderPipelineArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeCommand) RenderPipelineReturns(result1 []byte, result2 error) {
+	fake.renderPipelineMutex.Lock()
+	defer fake.renderPipelineMutex.Unlock()
+	fake.RenderPipelineStub = nil
+	fake.renderPipelineReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) RenderPipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.renderPipelineMutex.Lock()
+	defer fake.renderPipelineMutex.Unlock()
+	fake.RenderPipelineStub = nil
+	if fake.renderPipelineReturnsOnCall == nil {
+		fake.renderPipelineReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.renderPipelineReturnsOnCall[i] = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Resources(arg1 fly.PipelineRef) ([]fly.Resource, error) {
+	fake.resourcesMutex.Lock()
+	ret, specificReturn := fake.resourcesReturnsOnCall[len(fake.resourcesArgsForCall)]
+	fake.resourcesArgsForCall = append(fake.resourcesArgsForCall, struct {
+		arg1 fly.PipelineRef
+	}{arg1})
+	stub := fake.ResourcesStub
+	fakeReturns := fake.resourcesReturns
+	fake.recordInvocation("Resources", []interface{}{arg1})
+	fake.resourcesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) ResourcesCallCount() int {
+	fake.resourcesMutex.RLock()
+	defer fake.resourcesMutex.RUnlock()
+	return len(fake.resourcesArgsForCall)
+}
+
+func (fake *FakeCommand) ResourcesCalls(stub func(fly.PipelineRef) ([]fly.Resource, error)) {
+	fake.resourcesMutex.Lock()
+	defer fake.resourcesMutex.Unlock()
+	fake.ResourcesStub = stub
+}
+
+func (fake *FakeCommand) ResourcesArgsForCall(i int) fly.PipelineRef {
+	fake.resourcesMutex.RLock()
+	defer fake.resourcesMutex.RUnlock()
+	argsForCall := fake.resourcesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCommand) ResourcesReturns(result1 []fly.Resource, result2 error) {
+	fake.resourcesMutex.Lock()
+	defer fake.resourcesMutex.Unlock()
+	fake.ResourcesStub = nil
+	fake.resourcesReturns = struct {
+		result1 []fly.Resource
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) ResourcesReturnsOnCall(i int, result1 []fly.Resource, result2 error) {
+	fake.resourcesMutex.Lock()
+	defer fake.resourcesMutex.Unlock()
+	fake.ResourcesStub = nil
+	if fake.resourcesReturnsOnCall == nil {
+		fake.resourcesReturnsOnCall = make(map[int]struct {
+			result1 []fly.Resource
+			result2 error
+		})
+	}
+	fake.resourcesReturnsOnCall[i] = struct {
+		result1 []fly.Resource
 		result2 error
 	}{result1, result2}
 }
@@ -434,15 +1644,16 @@ func (fake *FakeCommand) SetPipeline(arg1 string, arg2 string, arg3 []string, ar
 		arg3 []string
 		arg4 map[string]interface{}
 	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.SetPipelineStub
+	fakeReturns := fake.setPipelineReturns
 	fake.recordInvocation("SetPipeline", []interface{}{arg1, arg2, arg3Copy, arg4})
 	fake.setPipelineMutex.Unlock()
-	if fake.SetPipelineStub != nil {
-		return fake.SetPipelineStub(arg1, arg2, arg3, arg4)
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	fakeReturns := fake.setPipelineReturns
 	return fakeReturns.result1, fakeReturns.result2
 }
 
@@ -491,22 +1702,150 @@ func (fake *FakeCommand) SetPipelineReturnsOnCall(i int, result1 []byte, result2
 	}{result1, result2}
 }
 
-func (fake *FakeCommand) UnpausePipeline(arg1 string) ([]byte, error) {
+func (fake *FakeCommand) SetPipelineConfig(arg1 fly.PipelineRef, arg2 []byte, arg3 string) ([]fly.ConfigWarning, error) {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.setPipelineConfigMutex.Lock()
+	ret, specificReturn := fake.setPipelineConfigReturnsOnCall[len(fake.setPipelineConfigArgsForCall)]
+	fake.setPipelineConfigArgsForCall = append(fake.setPipelineConfigArgsForCall, struct {
+		arg1 fly.PipelineRef
+		arg2 []byte
+		arg3 string
+	}{arg1, arg2Copy, arg3})
+	stub := fake.SetPipelineConfigStub
+	fakeReturns := fake.setPipelineConfigReturns
+	fake.recordInvocation("SetPipelineConfig", []interface{}{arg1, arg2Copy, arg3})
+	fake.setPipelineConfigMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) SetPipelineConfigCallCount() int {
+	fake.setPipelineConfigMutex.RLock()
+	defer fake.setPipelineConfigMutex.RUnlock()
+	return len(fake.setPipelineConfigArgsForCall)
+}
+
+func (fake *FakeCommand) SetPipelineConfigCalls(stub func(fly.PipelineRef, []byte, string) ([]fly.ConfigWarning, error)) {
+	fake.setPipelineConfigMutex.Lock()
+	defer fake.setPipelineConfigMutex.Unlock()
+	fake.SetPipelineConfigStub = stub
+}
+
+func (fake *FakeCommand) SetPipelineConfigArgsForCall(i int) (fly.PipelineRef, []byte, string) {
+	fake.setPipelineConfigMutex.RLock()
+	defer fake.setPipelineConfigMutex.RUnlock()
+	argsForCall := fake.setPipelineConfigArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeCommand) SetPipelineConfigReturns(result1 []fly.ConfigWarning, result2 error) {
+	fake.setPipelineConfigMutex.Lock()
+	defer fake.setPipelineConfigMutex.Unlock()
+	fake.SetPipelineConfigStub = nil
+	fake.setPipelineConfigReturns = struct {
+		result1 []fly.ConfigWarning
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) SetPipelineConfigReturnsOnCall(i int, result1 []fly.ConfigWarning, result2 error) {
+	fake.setPipelineConfigMutex.Lock()
+	defer fake.setPipelineConfigMutex.Unlock()
+	fake.SetPipelineConfigStub = nil
+	if fake.setPipelineConfigReturnsOnCall == nil {
+		fake.setPipelineConfigReturnsOnCall = make(map[int]struct {
+			result1 []fly.ConfigWarning
+			result2 error
+		})
+	}
+	fake.setPipelineConfigReturnsOnCall[i] = struct {
+		result1 []fly.ConfigWarning
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) Teams() ([]string, error) {
+	fake.teamsMutex.Lock()
+	ret, specificReturn := fake.teamsReturnsOnCall[len(fake.teamsArgsForCall)]
+	fake.teamsArgsForCall = append(fake.teamsArgsForCall, struct {
+	}{})
+	stub := fake.TeamsStub
+	fakeReturns := fake.teamsReturns
+	fake.recordInvocation("Teams", []interface{}{})
+	fake.teamsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) TeamsCallCount() int {
+	fake.teamsMutex.RLock()
+	defer fake.teamsMutex.RUnlock()
+	return len(fake.teamsArgsForCall)
+}
+
+func (fake *FakeCommand) TeamsCalls(stub func() ([]string, error)) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = stub
+}
+
+func (fake *FakeCommand) TeamsReturns(result1 []string, result2 error) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = nil
+	fake.teamsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) TeamsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.teamsMutex.Lock()
+	defer fake.teamsMutex.Unlock()
+	fake.TeamsStub = nil
+	if fake.teamsReturnsOnCall == nil {
+		fake.teamsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.teamsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) UnpausePipeline(arg1 fly.PipelineRef) error {
 	fake.unpausePipelineMutex.Lock()
 	ret, specificReturn := fake.unpausePipelineReturnsOnCall[len(fake.unpausePipelineArgsForCall)]
 	fake.unpausePipelineArgsForCall = append(fake.unpausePipelineArgsForCall, struct {
-		arg1 string
+		arg1 fly.PipelineRef
 	}{arg1})
+	stub := fake.UnpausePipelineStub
+	fakeReturns := fake.unpausePipelineReturns
 	fake.recordInvocation("UnpausePipeline", []interface{}{arg1})
 	fake.unpausePipelineMutex.Unlock()
-	if fake.UnpausePipelineStub != nil {
-		return fake.UnpausePipelineStub(arg1)
+	if stub != nil {
+		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	fakeReturns := fake.unpausePipelineReturns
-	return fakeReturns.result1, fakeReturns.result2
+	return fakeReturns.result1
 }
 
 func (fake *FakeCommand) UnpausePipelineCallCount() int {
@@ -515,41 +1854,94 @@ func (fake *FakeCommand) UnpausePipelineCallCount() int {
 	return len(fake.unpausePipelineArgsForCall)
 }
 
-func (fake *FakeCommand) UnpausePipelineCalls(stub func(string) ([]byte, error)) {
+func (fake *FakeCommand) UnpausePipelineCalls(stub func(fly.PipelineRef) error) {
 	fake.unpausePipelineMutex.Lock()
 	defer fake.unpausePipelineMutex.Unlock()
 	fake.UnpausePipelineStub = stub
 }
 
-func (fake *FakeCommand) UnpausePipelineArgsForCall(i int) string {
+func (fake *FakeCommand) UnpausePipelineArgsForCall(i int) fly.PipelineRef {
 	fake.unpausePipelineMutex.RLock()
 	defer fake.unpausePipelineMutex.RUnlock()
 	argsForCall := fake.unpausePipelineArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeCommand) UnpausePipelineReturns(result1 []byte, result2 error) {
+func (fake *FakeCommand) UnpausePipelineReturns(result1 error) {
 	fake.unpausePipelineMutex.Lock()
 	defer fake.unpausePipelineMutex.Unlock()
 	fake.UnpausePipelineStub = nil
 	fake.unpausePipelineReturns = struct {
-		result1 []byte
-		result2 error
-	}{result1, result2}
+		result1 error
+	}{result1}
 }
 
-func (fake *FakeCommand) UnpausePipelineReturnsOnCall(i int, result1 []byte, result2 error) {
+func (fake *FakeCommand) UnpausePipelineReturnsOnCall(i int, result1 error) {
 	fake.unpausePipelineMutex.Lock()
 	defer fake.unpausePipelineMutex.Unlock()
 	fake.UnpausePipelineStub = nil
 	if fake.unpausePipelineReturnsOnCall == nil {
 		fake.unpausePipelineReturnsOnCall = make(map[int]struct {
-			result1 []byte
-			result2 error
+			result1 error
 		})
 	}
 	fake.unpausePipelineReturnsOnCall[i] = struct {
-		result1 []byte
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCommand) UserInfo() (fly.UserInfo, error) {
+	fake.userInfoMutex.Lock()
+	ret, specificReturn := fake.userInfoReturnsOnCall[len(fake.userInfoArgsForCall)]
+	fake.userInfoArgsForCall = append(fake.userInfoArgsForCall, struct {
+	}{})
+	stub := fake.UserInfoStub
+	fakeReturns := fake.userInfoReturns
+	fake.recordInvocation("UserInfo", []interface{}{})
+	fake.userInfoMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCommand) UserInfoCallCount() int {
+	fake.userInfoMutex.RLock()
+	defer fake.userInfoMutex.RUnlock()
+	return len(fake.userInfoArgsForCall)
+}
+
+func (fake *FakeCommand) UserInfoCalls(stub func() (fly.UserInfo, error)) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = stub
+}
+
+func (fake *FakeCommand) UserInfoReturns(result1 fly.UserInfo, result2 error) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = nil
+	fake.userInfoReturns = struct {
+		result1 fly.UserInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCommand) UserInfoReturnsOnCall(i int, result1 fly.UserInfo, result2 error) {
+	fake.userInfoMutex.Lock()
+	defer fake.userInfoMutex.Unlock()
+	fake.UserInfoStub = nil
+	if fake.userInfoReturnsOnCall == nil {
+		fake.userInfoReturnsOnCall = make(map[int]struct {
+			result1 fly.UserInfo
+			result2 error
+		})
+	}
+	fake.userInfoReturnsOnCall[i] = struct {
+		result1 fly.UserInfo
 		result2 error
 	}{result1, result2}
 }
@@ -557,20 +1949,6 @@ func (fake *FakeCommand) UnpausePipelineReturnsOnCall(i int, result1 []byte, res
 func (fake *FakeCommand) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.destroyPipelineMutex.RLock()
-	defer fake.destroyPipelineMutex.RUnlock()
-	fake.exposePipelineMutex.RLock()
-	defer fake.exposePipelineMutex.RUnlock()
-	fake.getPipelineMutex.RLock()
-	defer fake.getPipelineMutex.RUnlock()
-	fake.loginMutex.RLock()
-	defer fake.loginMutex.RUnlock()
-	fake.pipelinesMutex.RLock()
-	defer fake.pipelinesMutex.RUnlock()
-	fake.setPipelineMutex.RLock()
-	defer fake.setPipelineMutex.RUnlock()
-	fake.unpausePipelineMutex.RLock()
-	defer fake.unpausePipelineMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
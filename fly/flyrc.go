@@ -0,0 +1,156 @@
+package fly
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"gopkg.in/yaml.v2"
+)
+
+const flyrcFilename = ".flyrc"
+
+type flyrc struct {
+	Targets map[string]flyrcTarget `yaml:"targets"`
+}
+
+type flyrcTarget struct {
+	API      string      `yaml:"api"`
+	TeamName string      `yaml:"team"`
+	Insecure bool        `yaml:"insecure,omitempty"`
+	Token    *flyrcToken `yaml:"token,omitempty"`
+	CACert   string      `yaml:"ca_cert,omitempty"`
+
+	ClientCertPath string `yaml:"client_cert_path,omitempty"`
+	ClientKeyPath  string `yaml:"client_key_path,omitempty"`
+}
+
+type flyrcToken struct {
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+}
+
+func (f *command) flyrcPath() (string, error) {
+	if f.options.Home != "" {
+		return filepath.Join(f.options.Home, flyrcFilename), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, flyrcFilename), nil
+}
+
+func (f *command) readFlyrc() (flyrc, error) {
+	var rc flyrc
+
+	path, err := f.flyrcPath()
+	if err != nil {
+		return rc, err
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return rc, err
+	}
+
+	err = yaml.Unmarshal(b, &rc)
+	if err != nil {
+		return rc, err
+	}
+
+	if rc.Targets == nil {
+		rc.Targets = make(map[string]flyrcTarget)
+	}
+
+	return rc, nil
+}
+
+// loadTarget returns the target as saved in the flyrc by the most recent
+// login.
+func (f *command) loadTarget() (flyrcTarget, error) {
+	rc, err := f.readFlyrc()
+	if err != nil {
+		return flyrcTarget{}, err
+	}
+
+	target, found := rc.Targets[f.target]
+	if !found {
+		return flyrcTarget{}, fmt.Errorf("target (%s) not found in flyrc - login first", f.target)
+	}
+
+	return target, nil
+}
+
+// saveTarget writes the target into the flyrc, preserving any other targets
+// already present, in the same format fly itself uses after a login.
+func (f *command) saveTarget(target flyrcTarget) error {
+	rc, err := f.readFlyrc()
+	if err != nil {
+		return err
+	}
+
+	rc.Targets[f.target] = target
+
+	b, err := yaml.Marshal(rc)
+	if err != nil {
+		return err
+	}
+
+	path, err := f.flyrcPath()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// WithFlyrcTarget returns a copy of the source in which Target names a target
+// of the flyrc at source.Flyrc, such as one distributed centrally, and is
+// replaced by its URL. The team of the target is logged in to with the token
+// stored for it, and its insecure and CA certificate settings are used.
+func WithFlyrcTarget(source concourse.Source) (concourse.Source, error) {
+	if len(source.Teams) > 0 || len(source.Targets) > 0 {
+		return concourse.Source{}, fmt.Errorf("only one of %s, %s or %s may be provided in source", "flyrc", "teams", "targets")
+	}
+
+	b, err := ioutil.ReadFile(source.Flyrc)
+	if err != nil {
+		return concourse.Source{}, fmt.Errorf("failed to read flyrc: %v", err)
+	}
+
+	var rc flyrc
+	err = yaml.Unmarshal(b, &rc)
+	if err != nil {
+		return concourse.Source{}, fmt.Errorf("failed to parse flyrc: %v", err)
+	}
+
+	target, found := rc.Targets[source.Target]
+	if !found {
+		return concourse.Source{}, fmt.Errorf("target (%s) not found in flyrc: %s", source.Target, source.Flyrc)
+	}
+
+	if target.Token == nil || target.Token.Value == "" {
+		return concourse.Source{}, fmt.Errorf("target (%s) of flyrc has no token - log in with fly first", source.Target)
+	}
+
+	source.Target = target.API
+	source.Teams = []concourse.Team{{
+		Name:  target.TeamName,
+		Token: target.Token.Value,
+	}}
+
+	if target.Insecure {
+		source.Insecure = "true"
+	}
+
+	if source.CACert == "" {
+		source.CACert = target.CACert
+	}
+
+	return source, nil
+}
@@ -0,0 +1,94 @@
+package fly_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithFlyrcTarget", func() {
+	var (
+		tempDir string
+		source  concourse.Source
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		flyrcPath := filepath.Join(tempDir, ".flyrc")
+		err = ioutil.WriteFile(flyrcPath, []byte(`targets:
+  ci:
+    api: https://ci.example.com
+    team: some-team
+    insecure: true
+    token:
+      type: bearer
+      value: some-token
+  logged-out:
+    api: https://ci.example.com
+    team: main
+`), os.ModePerm)
+		Expect(err).NotTo(HaveOccurred())
+
+		source = concourse.Source{
+			Target: "ci",
+			Flyrc:  flyrcPath,
+		}
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("uses the URL, team and token of the named target", func() {
+		resolved, err := fly.WithFlyrcTarget(source)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(resolved.Target).To(Equal("https://ci.example.com"))
+		Expect(resolved.Insecure).To(Equal(concourse.Bool("true")))
+		Expect(resolved.Teams).To(Equal([]concourse.Team{
+			{Name: "some-team", Token: "some-token"},
+		}))
+	})
+
+	Context("when the target is not in the flyrc", func() {
+		BeforeEach(func() {
+			source.Target = "other"
+		})
+
+		It("returns an error", func() {
+			_, err := fly.WithFlyrcTarget(source)
+			Expect(err).To(MatchError(ContainSubstring("target (other) not found in flyrc")))
+		})
+	})
+
+	Context("when the target has no token", func() {
+		BeforeEach(func() {
+			source.Target = "logged-out"
+		})
+
+		It("returns an error", func() {
+			_, err := fly.WithFlyrcTarget(source)
+			Expect(err).To(MatchError(ContainSubstring("has no token")))
+		})
+	})
+
+	Context("when teams are also provided", func() {
+		BeforeEach(func() {
+			source.Teams = []concourse.Team{{Name: "main"}}
+		})
+
+		It("returns an error", func() {
+			_, err := fly.WithFlyrcTarget(source)
+			Expect(err).To(MatchError("only one of flyrc, teams or targets may be provided in source"))
+		})
+	})
+})
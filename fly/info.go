@@ -0,0 +1,29 @@
+package fly
+
+import "encoding/json"
+
+// Info describes the Concourse cluster of the target.
+type Info struct {
+	Version       string `json:"version"`
+	WorkerVersion string `json:"worker_version"`
+	ExternalURL   string `json:"external_url,omitempty"`
+	ClusterName   string `json:"cluster_name,omitempty"`
+}
+
+// Info returns the version and name of the Concourse cluster of the target.
+func (f *command) Info() (Info, error) {
+	resp, err := f.apiRequest("GET", "/info", nil, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	defer closeBody(resp.Body)
+
+	var info Info
+
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return Info{}, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,40 @@
+package fly
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// InstallBinary copies the fly binary at binaryPath into a new directory
+// inside dir, so that syncing it with the target (which replaces the binary)
+// happens inside dir rather than next to the resource executables. It
+// returns the path to the copy.
+func InstallBinary(binaryPath string, dir string) (string, error) {
+	installDir, err := ioutil.TempDir(dir, "fly")
+	if err != nil {
+		return "", err
+	}
+
+	src, err := os.Open(binaryPath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	installedPath := filepath.Join(installDir, filepath.Base(binaryPath))
+
+	dst, err := os.OpenFile(installedPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return "", err
+	}
+
+	return installedPath, dst.Close()
+}
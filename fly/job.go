@@ -0,0 +1,72 @@
+package fly
+
+// Job is a job of a pipeline as returned by the ATC API.
+type Job struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	PipelineName  string `json:"pipeline_name"`
+	TeamName      string `json:"team_name"`
+	Paused        bool   `json:"paused"`
+	HasNewInputs  bool   `json:"has_new_inputs"`
+	FinishedBuild *Build `json:"finished_build"`
+	NextBuild     *Build `json:"next_build"`
+}
+
+// Build is a build as returned by the ATC API.
+type Build struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	JobName      string `json:"job_name"`
+	PipelineName string `json:"pipeline_name"`
+	TeamName     string `json:"team_name"`
+	StartTime    int64  `json:"start_time"`
+	EndTime      int64  `json:"end_time"`
+	CreatedBy    string `json:"created_by,omitempty"`
+}
+
+// Statuses summarising a pipeline, matching those of the ATC's badge.
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusErrored   = "errored"
+	StatusAborted   = "aborted"
+	StatusUnknown   = "unknown"
+)
+
+// PipelineStatus summarises the overall status of a pipeline from the most
+// recently finished build of each of its jobs, in the same way the ATC
+// derives the status shown by the pipeline's badge.
+func PipelineStatus(jobs []Job) string {
+	var failed, errored, aborted, succeeded bool
+
+	for _, j := range jobs {
+		if j.FinishedBuild == nil {
+			continue
+		}
+
+		switch j.FinishedBuild.Status {
+		case StatusFailed:
+			failed = true
+		case StatusErrored:
+			errored = true
+		case StatusAborted:
+			aborted = true
+		case StatusSucceeded:
+			succeeded = true
+		}
+	}
+
+	switch {
+	case failed:
+		return StatusFailed
+	case errored:
+		return StatusErrored
+	case aborted:
+		return StatusAborted
+	case succeeded:
+		return StatusSucceeded
+	default:
+		return StatusUnknown
+	}
+}
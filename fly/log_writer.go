@@ -0,0 +1,58 @@
+package fly
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+)
+
+// logWriter forwards each complete line written to it to the logger, so that
+// fly output appears in the log while the command is still running.
+type logWriter struct {
+	logger logger.Logger
+	prefix string
+	// verbose logs the lines at the info level rather than debug, so that
+	// they are written to the build output by default.
+	verbose bool
+	mutex   *sync.Mutex
+	buf     []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		w.log(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush logs any trailing output that did not end in a newline.
+func (w *logWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.buf) > 0 {
+		w.log(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *logWriter) log(line []byte) {
+	if w.verbose {
+		w.logger.Infof("%s%s\n", w.prefix, line)
+		return
+	}
+	w.logger.Debugf("%s%s\n", w.prefix, line)
+}
@@ -0,0 +1,143 @@
+package fly
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// Authenticator logs in to a team using one kind of credentials.
+type Authenticator interface {
+	Login(flyCommand Command, url string, insecure bool) ([]byte, error)
+}
+
+// AuthenticatorFor selects the Authenticator matching the credentials
+// configured for the team in source. Teams without credentials use basic
+// auth with an empty username and password, which fly treats as no auth.
+func AuthenticatorFor(team concourse.Team) Authenticator {
+	if team.Token != "" {
+		return tokenAuthenticator{
+			teamName: team.Name,
+			token:    team.Token,
+		}
+	}
+
+	if team.ClientID != "" {
+		return clientCredentialsAuthenticator{
+			teamName:     team.Name,
+			clientID:     team.ClientID,
+			clientSecret: team.ClientSecret,
+		}
+	}
+
+	return basicAuthenticator{
+		teamName: team.Name,
+		username: team.Username,
+		password: team.Password,
+	}
+}
+
+// LoginToTeam logs in to the team using whichever credentials are configured
+// for it in source, and checks that they belong to a member of the team. The
+// team may override whether the certificate of the target is verified.
+func LoginToTeam(
+	flyCommand Command,
+	url string,
+	team concourse.Team,
+	insecure bool,
+) ([]byte, error) {
+	if team.Insecure != "" {
+		var err error
+		insecure, err = team.Insecure.Parse()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := AuthenticatorFor(team).Login(flyCommand, url, insecure)
+	if err != nil {
+		return output, loginError(err)
+	}
+
+	return output, loginError(verifyTeamMember(flyCommand, team.Name))
+}
+
+// ExpandTeamPattern returns teams along with every other team whose name
+// matches pattern. Other teams are found, and logged in to, using the
+// credentials of the first of teams, which must therefore be able to see
+// them - typically an admin user of the main team.
+func ExpandTeamPattern(
+	flyCommand Command,
+	url string,
+	teams []concourse.Team,
+	pattern string,
+	insecure bool,
+) ([]concourse.Team, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(teams) == 0 {
+		return nil, fmt.Errorf("a team must be provided to find teams matching %s", pattern)
+	}
+
+	_, err = LoginToTeam(flyCommand, url, teams[0], insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := flyCommand.Teams()
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]bool)
+	for _, team := range teams {
+		configured[team.Name] = true
+	}
+
+	expanded := append([]concourse.Team{}, teams...)
+	for _, name := range names {
+		if configured[name] || !re.MatchString(name) {
+			continue
+		}
+
+		team := teams[0]
+		team.Name = name
+		team.Pipelines = nil
+		expanded = append(expanded, team)
+	}
+
+	return expanded, nil
+}
+
+type basicAuthenticator struct {
+	teamName string
+	username string
+	password string
+}
+
+func (a basicAuthenticator) Login(flyCommand Command, url string, insecure bool) ([]byte, error) {
+	return flyCommand.Login(url, a.teamName, a.username, a.password, insecure)
+}
+
+type clientCredentialsAuthenticator struct {
+	teamName     string
+	clientID     string
+	clientSecret string
+}
+
+func (a clientCredentialsAuthenticator) Login(flyCommand Command, url string, insecure bool) ([]byte, error) {
+	return flyCommand.LoginWithClientCredentials(url, a.teamName, a.clientID, a.clientSecret, insecure)
+}
+
+type tokenAuthenticator struct {
+	teamName string
+	token    string
+}
+
+func (a tokenAuthenticator) Login(flyCommand Command, url string, insecure bool) ([]byte, error) {
+	return flyCommand.LoginWithToken(url, a.teamName, a.token, insecure)
+}
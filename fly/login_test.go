@@ -0,0 +1,224 @@
+package fly_test
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoginToTeam", func() {
+	var (
+		fakeFlyCommand *flyfakes.FakeCommand
+		team           concourse.Team
+	)
+
+	BeforeEach(func() {
+		fakeFlyCommand = &flyfakes.FakeCommand{}
+		fakeFlyCommand.UserInfoReturns(fly.UserInfo{
+			UserName: "some-username",
+			Teams:    map[string][]string{"some-team": {"member"}},
+		}, nil)
+
+		team = concourse.Team{
+			Name:     "some-team",
+			Username: "some-username",
+			Password: "some-password",
+		}
+	})
+
+	It("logs in with basic auth", func() {
+		_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+		url, teamName, username, password, insecure := fakeFlyCommand.LoginArgsForCall(0)
+		Expect(url).To(Equal("some-url"))
+		Expect(teamName).To(Equal("some-team"))
+		Expect(username).To(Equal("some-username"))
+		Expect(password).To(Equal("some-password"))
+		Expect(insecure).To(BeTrue())
+	})
+
+	Context("when the team overrides insecure", func() {
+		BeforeEach(func() {
+			team.Insecure = "false"
+		})
+
+		It("logs in with the team's setting", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, _, _, insecure := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(insecure).To(BeFalse())
+		})
+	})
+
+	Context("when client credentials are configured", func() {
+		BeforeEach(func() {
+			team.Username = ""
+			team.Password = ""
+			team.ClientID = "some-client-id"
+			team.ClientSecret = "some-client-secret"
+		})
+
+		It("logs in with the client credentials", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.LoginWithClientCredentialsCallCount()).To(Equal(1))
+
+			url, teamName, clientID, clientSecret, insecure := fakeFlyCommand.LoginWithClientCredentialsArgsForCall(0)
+			Expect(url).To(Equal("some-url"))
+			Expect(teamName).To(Equal("some-team"))
+			Expect(clientID).To(Equal("some-client-id"))
+			Expect(clientSecret).To(Equal("some-client-secret"))
+			Expect(insecure).To(BeFalse())
+		})
+	})
+
+	Context("when a token is configured", func() {
+		BeforeEach(func() {
+			team.Username = ""
+			team.Password = ""
+			team.Token = "some-token"
+		})
+
+		It("logs in with the token", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.LoginCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.LoginWithTokenCallCount()).To(Equal(1))
+
+			url, teamName, token, insecure := fakeFlyCommand.LoginWithTokenArgsForCall(0)
+			Expect(url).To(Equal("some-url"))
+			Expect(teamName).To(Equal("some-team"))
+			Expect(token).To(Equal("some-token"))
+			Expect(insecure).To(BeFalse())
+		})
+	})
+
+	Context("when the user is not a member of the team", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.UserInfoReturns(fly.UserInfo{
+				UserName: "some-username",
+				Teams:    map[string][]string{"other-team": {"owner"}},
+			}, nil)
+		})
+
+		It("returns an error", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(err).To(MatchError(ContainSubstring(`team some-team as user "some-username"`)))
+			Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodeAuthFailed))
+		})
+
+		Context("when the user is an admin", func() {
+			BeforeEach(func() {
+				fakeFlyCommand.UserInfoReturns(fly.UserInfo{UserName: "some-username", IsAdmin: true}, nil)
+			})
+
+			It("returns without error", func() {
+				_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when the ATC does not report the user", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.UserInfoReturns(fly.UserInfo{}, &fly.Error{Kind: fly.ErrNotFound, Err: errors.New("404")})
+		})
+
+		It("returns without error", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when getting the user fails", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.UserInfoReturns(fly.UserInfo{}, fmt.Errorf("some error"))
+		})
+
+		It("returns an error", func() {
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+
+	DescribeTable("the code of a failure to log in",
+		func(loginErr error, expectedCode concourse.ErrorCode) {
+			fakeFlyCommand.LoginReturns(nil, loginErr)
+
+			_, err := fly.LoginToTeam(fakeFlyCommand, "some-url", team, false)
+			Expect(errors.Is(err, loginErr)).To(BeTrue())
+			Expect(concourse.ErrorCodeOf(err)).To(Equal(expectedCode))
+		},
+		Entry("not authorized", &fly.Error{Kind: fly.ErrUnauthorized, Err: errors.New("not authorized")}, concourse.ErrorCodeAuthFailed),
+		Entry("forbidden", &fly.Error{Kind: fly.ErrForbidden, Err: errors.New("forbidden")}, concourse.ErrorCodeAuthFailed),
+		Entry("team not found", &fly.Error{Kind: fly.ErrNotFound, Err: errors.New("team not found")}, concourse.ErrorCodeTeamNotFound),
+		Entry("fly out of sync", &fly.Error{Kind: fly.ErrVersionMismatch, Err: errors.New("out of sync with the target")}, concourse.ErrorCodeVersionMismatch),
+		Entry("anything else", errors.New("connection refused"), concourse.ErrorCodeUnknown),
+	)
+})
+
+var _ = Describe("ExpandTeamPattern", func() {
+	var (
+		fakeFlyCommand *flyfakes.FakeCommand
+		teams          []concourse.Team
+	)
+
+	BeforeEach(func() {
+		fakeFlyCommand = &flyfakes.FakeCommand{}
+		fakeFlyCommand.UserInfoReturns(fly.UserInfo{IsAdmin: true}, nil)
+		fakeFlyCommand.TeamsReturns([]string{"main", "product-a", "product-b", "other"}, nil)
+
+		teams = []concourse.Team{
+			{Name: "main", Username: "some-username", Password: "some-password"},
+			{Name: "product-b", Username: "other-username", Password: "other-password"},
+		}
+	})
+
+	It("adds teams matching the pattern with the credentials of the first team", func() {
+		expanded, err := fly.ExpandTeamPattern(fakeFlyCommand, "some-url", teams, "^product-", false)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(expanded).To(Equal([]concourse.Team{
+			{Name: "main", Username: "some-username", Password: "some-password"},
+			{Name: "product-b", Username: "other-username", Password: "other-password"},
+			{Name: "product-a", Username: "some-username", Password: "some-password"},
+		}))
+
+		Expect(fakeFlyCommand.LoginCallCount()).To(Equal(1))
+		_, teamName, _, _, _ := fakeFlyCommand.LoginArgsForCall(0)
+		Expect(teamName).To(Equal("main"))
+	})
+
+	It("does not restrict the pipelines of matching teams to those of the first team", func() {
+		teams[0].Pipelines = []string{"some-pipeline"}
+
+		expanded, err := fly.ExpandTeamPattern(fakeFlyCommand, "some-url", teams, "^product-", false)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(expanded[0].Pipelines).To(Equal([]string{"some-pipeline"}))
+		Expect(expanded[2].Pipelines).To(BeNil())
+	})
+
+	Context("when listing teams fails", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.TeamsReturns(nil, fmt.Errorf("some error"))
+		})
+
+		It("returns an error", func() {
+			_, err := fly.ExpandTeamPattern(fakeFlyCommand, "some-url", teams, "^product-", false)
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+})
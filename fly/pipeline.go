@@ -0,0 +1,13 @@
+package fly
+
+// Pipeline is a pipeline as listed by fly pipelines --json.
+type Pipeline struct {
+	ID           int                    `json:"id"`
+	Name         string                 `json:"name"`
+	InstanceVars map[string]interface{} `json:"instance_vars,omitempty"`
+	TeamName     string                 `json:"team_name"`
+	Paused       bool                   `json:"paused"`
+	Public       bool                   `json:"public"`
+	Archived     bool                   `json:"archived"`
+	LastUpdated  int64                  `json:"last_updated"`
+}
@@ -0,0 +1,56 @@
+package fly
+
+import (
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// PipelineConfig is the configuration of a pipeline as returned by
+// fly get-pipeline --json. Each section is kept as generic values so that
+// no fields are lost regardless of the Concourse version.
+type PipelineConfig struct {
+	Groups        []map[string]interface{} `json:"groups,omitempty"`
+	VarSources    []map[string]interface{} `json:"var_sources,omitempty"`
+	Resources     []map[string]interface{} `json:"resources,omitempty"`
+	ResourceTypes []map[string]interface{} `json:"resource_types,omitempty"`
+	Jobs          []map[string]interface{} `json:"jobs,omitempty"`
+	Display       map[string]interface{}   `json:"display,omitempty"`
+}
+
+// ConfigVersion returns the config version of the pipeline, which the ATC
+// increments whenever the pipeline is set.
+func ConfigVersion(command Command, ref PipelineRef) (string, error) {
+	_, version, err := command.GetPipelineConfig(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		return "", fmt.Errorf("no config version was returned for pipeline %s", ref)
+	}
+
+	return version, nil
+}
+
+// PipelineChecksum returns the version of the pipeline: its config version if
+// the version_scheme of the source is config_version, which saves getting its
+// config, or else the checksum of its config. The time taken is recorded as
+// fetching and checksumming the pipeline.
+func PipelineChecksum(command Command, source concourse.Source, ref PipelineRef, timings *concourse.Timings) (string, error) {
+	stopFetch := timings.Start(concourse.PhaseFetch)
+
+	if source.VersionScheme == concourse.VersionSchemeConfigVersion {
+		defer stopFetch()
+		return ConfigVersion(command, ref)
+	}
+
+	config, err := command.GetPipeline(ref.String())
+	stopFetch()
+	if err != nil {
+		return "", err
+	}
+
+	defer timings.Start(concourse.PhaseChecksum)()
+	return source.PipelineChecksum(config)
+}
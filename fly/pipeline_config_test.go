@@ -0,0 +1,81 @@
+package fly_test
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PipelineChecksum", func() {
+	var (
+		fakeFlyCommand *flyfakes.FakeCommand
+		source         concourse.Source
+		ref            fly.PipelineRef
+	)
+
+	BeforeEach(func() {
+		fakeFlyCommand = &flyfakes.FakeCommand{}
+		fakeFlyCommand.GetPipelineReturns([]byte("some-config"), nil)
+		fakeFlyCommand.GetPipelineConfigReturns(fly.PipelineConfig{}, "42", nil)
+
+		source = concourse.Source{Checksum: "md5"}
+		ref = fly.PipelineRef{Name: "some-pipeline", InstanceVars: map[string]interface{}{"branch": "main"}}
+	})
+
+	It("returns the checksum of the config of the pipeline", func() {
+		checksum, err := fly.PipelineChecksum(fakeFlyCommand, source, ref, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(checksum).To(Equal(fmt.Sprintf("%x", md5.Sum([]byte("some-config")))))
+
+		Expect(fakeFlyCommand.GetPipelineArgsForCall(0)).To(Equal(`some-pipeline/branch:"main"`))
+		Expect(fakeFlyCommand.GetPipelineConfigCallCount()).To(Equal(0))
+	})
+
+	It("records the time taken to fetch and checksum the pipeline", func() {
+		timings := concourse.NewTimings()
+
+		_, err := fly.PipelineChecksum(fakeFlyCommand, source, ref, timings)
+		Expect(err).NotTo(HaveOccurred())
+
+		entries := timings.MetadataEntries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Name).To(Equal("fetch duration"))
+		Expect(entries[1].Name).To(Equal("checksum duration"))
+	})
+
+	Context("when the version scheme is config_version", func() {
+		BeforeEach(func() {
+			source.VersionScheme = "config_version"
+		})
+
+		It("returns the config version of the pipeline without getting its config", func() {
+			checksum, err := fly.PipelineChecksum(fakeFlyCommand, source, ref, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checksum).To(Equal("42"))
+
+			Expect(fakeFlyCommand.GetPipelineConfigArgsForCall(0)).To(Equal(ref))
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(0))
+		})
+
+		It("returns an error if the ATC returns no config version", func() {
+			fakeFlyCommand.GetPipelineConfigReturns(fly.PipelineConfig{}, "", nil)
+
+			_, err := fly.PipelineChecksum(fakeFlyCommand, source, ref, nil)
+			Expect(err).To(MatchError(`no config version was returned for pipeline some-pipeline/branch:"main"`))
+		})
+
+		It("returns an error if getting the config version fails", func() {
+			expectedErr := errors.New("some error")
+			fakeFlyCommand.GetPipelineConfigReturns(fly.PipelineConfig{}, "", expectedErr)
+
+			_, err := fly.PipelineChecksum(fakeFlyCommand, source, ref, nil)
+			Expect(err).To(Equal(expectedErr))
+		})
+	})
+})
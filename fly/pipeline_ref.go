@@ -0,0 +1,41 @@
+package fly
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// PipelineRef identifies a pipeline, which for an instanced pipeline
+// includes its instance vars as well as its name.
+type PipelineRef struct {
+	Name         string
+	InstanceVars map[string]interface{}
+}
+
+// Ref returns the reference identifying the pipeline.
+func (p Pipeline) Ref() PipelineRef {
+	return PipelineRef{Name: p.Name, InstanceVars: p.InstanceVars}
+}
+
+// String returns the reference in the form accepted by fly's --pipeline
+// flag, e.g. some-pipeline/branch:main,version:1.
+func (r PipelineRef) String() string {
+	if len(r.InstanceVars) == 0 {
+		return r.Name
+	}
+
+	return r.Name + "/" + concourse.FormatInstanceVars(r.InstanceVars)
+}
+
+// QueryParams returns the query parameters identifying the instance of the
+// pipeline in requests to the ATC API.
+func (r PipelineRef) QueryParams() url.Values {
+	if len(r.InstanceVars) == 0 {
+		return nil
+	}
+
+	b, _ := json.Marshal(r.InstanceVars)
+	return url.Values{"vars": []string{string(b)}}
+}
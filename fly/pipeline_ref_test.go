@@ -0,0 +1,48 @@
+package fly_test
+
+import (
+	"net/url"
+
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PipelineRef", func() {
+	DescribeTable("String",
+		func(ref fly.PipelineRef, expected string) {
+			Expect(ref.String()).To(Equal(expected))
+		},
+		Entry("no instance vars", fly.PipelineRef{Name: "some-pipeline"}, "some-pipeline"),
+		Entry("string instance vars",
+			fly.PipelineRef{Name: "some-pipeline", InstanceVars: map[string]interface{}{"version": "1", "branch": "main"}},
+			`some-pipeline/branch:"main",version:"1"`,
+		),
+		Entry("non-string instance vars",
+			fly.PipelineRef{Name: "some-pipeline", InstanceVars: map[string]interface{}{"number": 1, "flag": true}},
+			"some-pipeline/flag:true,number:1",
+		),
+	)
+
+	Describe("QueryParams", func() {
+		It("returns nil without instance vars", func() {
+			Expect(fly.PipelineRef{Name: "some-pipeline"}.QueryParams()).To(BeNil())
+		})
+
+		It("encodes the instance vars as json", func() {
+			ref := fly.PipelineRef{Name: "some-pipeline", InstanceVars: map[string]interface{}{"branch": "main"}}
+
+			Expect(ref.QueryParams()).To(Equal(url.Values{"vars": []string{`{"branch":"main"}`}}))
+		})
+	})
+
+	It("is returned for a pipeline", func() {
+		pipeline := fly.Pipeline{ID: 1, Name: "some-pipeline", InstanceVars: map[string]interface{}{"branch": "main"}}
+
+		Expect(pipeline.Ref()).To(Equal(fly.PipelineRef{
+			Name:         "some-pipeline",
+			InstanceVars: map[string]interface{}{"branch": "main"},
+		}))
+	})
+})
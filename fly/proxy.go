@@ -0,0 +1,84 @@
+package fly
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc returns the proxy selection of requests made directly to the ATC.
+// Unless any of the proxies are configured, those of the environment are used.
+func proxyFunc(options Options) func(*http.Request) (*url.URL, error) {
+	if options.HTTPProxy == "" && options.HTTPSProxy == "" && options.NoProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := options.HTTPProxy
+		if req.URL.Scheme == "https" {
+			proxy = options.HTTPSProxy
+		}
+
+		if proxy == "" || noProxy(options.NoProxy, req.URL.Hostname()) {
+			return nil, nil
+		}
+
+		return url.Parse(proxy)
+	}
+}
+
+// noProxy reports whether host matches any of the comma-separated entries of
+// noProxyList: * for all hosts, an IP address or CIDR range, or a domain
+// which matches itself and its subdomains.
+func noProxy(noProxyList string, host string) bool {
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(noProxyList, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if entry == "*" {
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+
+		domain := strings.TrimPrefix(entry, ".")
+		host = strings.ToLower(host)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// proxyEnv returns the environment variables passing the configured proxies
+// to fly, in both the upper and lower case forms some tools expect.
+func proxyEnv(options Options) []string {
+	var env []string
+
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", options.HTTPProxy},
+		{"HTTPS_PROXY", options.HTTPSProxy},
+		{"NO_PROXY", options.NoProxy},
+	} {
+		if v.value != "" {
+			env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+		}
+	}
+
+	return env
+}
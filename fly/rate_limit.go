@@ -0,0 +1,76 @@
+package fly
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket limiting how often requests are made to the
+// ATC. It holds up to one second's worth of tokens, so short bursts are
+// allowed while the average rate is kept below the limit.
+type rateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	burst := math.Max(1, math.Floor(perSecond))
+
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    burst,
+		tokens:   burst,
+		last:     time.Now(),
+	}
+}
+
+// wait blocks until a request may be made, or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, which may not be available yet, and returns how long
+// to wait until it is.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.interval))
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// waitForRateLimit blocks until the next request may be made to the ATC, if
+// a rate limit is configured.
+func (f *command) waitForRateLimit() error {
+	if f.limiter == nil {
+		return nil
+	}
+
+	return f.limiter.wait(f.context())
+}
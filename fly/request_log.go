@@ -0,0 +1,66 @@
+package fly
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+)
+
+// maxLoggedBodyLength limits how much of each body is logged, as pipeline
+// configs can be large.
+const maxLoggedBodyLength = 4 * 1024
+
+// requestLogger logs the method, URL, status and latency of each request made
+// directly to the ATC, along with their bodies if bodies is set. Credentials
+// and tokens are redacted by the sanitizer of the logger, and the
+// Authorization header is never logged.
+type requestLogger struct {
+	transport http.RoundTripper
+	logger    logger.Logger
+	bodies    bool
+}
+
+func (r *requestLogger) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.bodies && req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			b, _ := ioutil.ReadAll(body)
+			body.Close()
+			r.logger.Debugf("HTTP %s %s request body: %s\n", req.Method, req.URL, truncateBody(b))
+		}
+	}
+
+	start := time.Now()
+	resp, err := r.transport.RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		r.logger.Debugf("HTTP %s %s failed after %s: %v\n", req.Method, req.URL, latency, err)
+		return nil, err
+	}
+
+	r.logger.Debugf("HTTP %s %s: %s in %s\n", req.Method, req.URL, resp.Status, latency)
+
+	if r.bodies {
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+		r.logger.Debugf("HTTP %s %s response body: %s\n", req.Method, req.URL, truncateBody(b))
+	}
+
+	return resp, nil
+}
+
+func truncateBody(b []byte) string {
+	if len(b) > maxLoggedBodyLength {
+		return string(b[:maxLoggedBodyLength]) + "... (truncated)"
+	}
+
+	return string(b)
+}
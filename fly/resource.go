@@ -0,0 +1,36 @@
+package fly
+
+// Resource is a resource of a pipeline as returned by the ATC API.
+type Resource struct {
+	Name           string            `json:"name"`
+	PipelineName   string            `json:"pipeline_name"`
+	TeamName       string            `json:"team_name"`
+	Type           string            `json:"type"`
+	PinnedVersion  map[string]string `json:"pinned_version,omitempty"`
+	PinnedInConfig bool              `json:"pinned_in_config,omitempty"`
+}
+
+// PausedJobs returns the number of the jobs which are paused.
+func PausedJobs(jobs []Job) int {
+	n := 0
+	for _, j := range jobs {
+		if j.Paused {
+			n++
+		}
+	}
+
+	return n
+}
+
+// PinnedResources returns the number of the resources which are pinned to a
+// version, whether in the config or through the UI.
+func PinnedResources(resources []Resource) int {
+	n := 0
+	for _, r := range resources {
+		if r.PinnedVersion != nil {
+			n++
+		}
+	}
+
+	return n
+}
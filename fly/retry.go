@@ -0,0 +1,63 @@
+package fly
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+)
+
+const defaultRetryBackoff = time.Second
+
+// idempotentCommands are the fly commands which only read from the target,
+// and so are safe to retry.
+var idempotentCommands = map[string]bool{
+	"teams":        true,
+	"pipelines":    true,
+	"get-pipeline": true,
+}
+
+// retry calls attempt until it succeeds, fails permanently, or
+// Options.Retries further attempts have been made, backing off
+// exponentially with jitter between attempts. No retry is made which would
+// start after Options.RetryMaxElapsedTime.
+func (f *command) retry(description string, attempt func() error) error {
+	backoff := f.options.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	start := time.Now()
+	err := attempt()
+
+	for i := 0; err != nil && i < f.options.Retries && isRetryable(err); i++ {
+		wait := backoff << uint(i)
+		wait += time.Duration(rand.Int63n(int64(wait)/2 + 1))
+
+		if f.options.RetryMaxElapsedTime > 0 && time.Since(start)+wait > f.options.RetryMaxElapsedTime {
+			f.logger.Warnf("%s failed, not retrying after %s: %v\n", description, f.options.RetryMaxElapsedTime, err)
+			return err
+		}
+
+		f.logger.Warnf("%s failed, retrying in %s: %v\n", description, wait, err)
+
+		select {
+		case <-time.After(wait):
+		case <-f.context().Done():
+			return err
+		}
+
+		err = attempt()
+	}
+
+	return err
+}
+
+// isRetryable reports whether err may be transient. Errors where the ATC has
+// given a definite answer are not.
+func isRetryable(err error) bool {
+	return !errors.Is(err, ErrUnauthorized) &&
+		!errors.Is(err, ErrForbidden) &&
+		!errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrConflict) &&
+		!errors.Is(err, ErrVersionMismatch)
+}
@@ -0,0 +1,112 @@
+package fly
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+)
+
+const (
+	caCertFilename     = "ca.crt"
+	clientCertFilename = "client.crt"
+	clientKeyFilename  = "client.key"
+)
+
+// tlsConfig returns the TLS configuration of requests made directly to the
+// ATC. The certificate of the ATC is verified against the system roots and
+// Options.CACert unless insecure, and Options.ClientCert is presented to
+// ATCs behind proxies which require one.
+func tlsConfig(insecure bool, options Options) *tls.Config {
+	config := &tls.Config{InsecureSkipVerify: insecure}
+
+	if !insecure && options.CACert != "" {
+		config.RootCAs = caCertPool(options.CACert)
+	}
+
+	if options.ClientCert != "" {
+		// Invalid key pairs are rejected when the source is validated.
+		cert, err := tls.X509KeyPair([]byte(options.ClientCert), []byte(options.ClientKey))
+		if err == nil {
+			config.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	return config
+}
+
+// caCertPool returns the system roots together with the certificates in
+// caCert, so that an ATC with a self-signed certificate can be verified.
+func caCertPool(caCert string) *x509.CertPool {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pool.AppendCertsFromPEM([]byte(caCert))
+
+	return pool
+}
+
+// tlsFiles are the paths of the CA and client certificates, which fly only
+// accepts as files. Paths are empty for certificates which are not given.
+type tlsFiles struct {
+	caCert     string
+	clientCert string
+	clientKey  string
+}
+
+// args returns the flags passing the certificates to fly login.
+func (t tlsFiles) args() []string {
+	var args []string
+
+	if t.caCert != "" {
+		args = append(args, "--ca-cert", t.caCert)
+	}
+
+	if t.clientCert != "" {
+		args = append(args, "--client-cert", t.clientCert, "--client-key", t.clientKey)
+	}
+
+	return args
+}
+
+// writeTLSFiles writes the CA and client certificates next to the flyrc.
+func (f *command) writeTLSFiles() (tlsFiles, error) {
+	var files tlsFiles
+	var err error
+
+	if f.options.CACert != "" {
+		files.caCert, err = f.writeTLSFile(caCertFilename, f.options.CACert)
+		if err != nil {
+			return tlsFiles{}, err
+		}
+	}
+
+	if f.options.ClientCert != "" {
+		files.clientCert, err = f.writeTLSFile(clientCertFilename, f.options.ClientCert)
+		if err != nil {
+			return tlsFiles{}, err
+		}
+
+		files.clientKey, err = f.writeTLSFile(clientKeyFilename, f.options.ClientKey)
+		if err != nil {
+			return tlsFiles{}, err
+		}
+	}
+
+	return files, nil
+}
+
+// writeTLSFile writes the file next to the flyrc, in the fly home if set,
+// rather than to a temporary file, so that the client key is not left
+// behind on the worker once the fly home is removed.
+func (f *command) writeTLSFile(name string, contents string) (string, error) {
+	flyrcPath, err := f.flyrcPath()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(filepath.Dir(flyrcPath), name)
+	return path, ioutil.WriteFile(path, []byte(contents), 0600)
+}
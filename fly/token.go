@@ -0,0 +1,78 @@
+package fly
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	tokenPath  = "/sky/issuer/token"
+	tokenScope = "openid profile email federated:id groups"
+)
+
+type token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// requestClientCredentialsToken performs an OAuth client credentials grant
+// against the token endpoint of the ATC at the given url.
+func requestClientCredentialsToken(
+	ctx context.Context,
+	client *http.Client,
+	userAgent string,
+	atcURL string,
+	clientID string,
+	clientSecret string,
+) (token, error) {
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"scope":      {tokenScope},
+	}
+
+	req, err := http.NewRequest(
+		"POST",
+		strings.TrimRight(atcURL, "/")+tokenPath,
+		strings.NewReader(form.Encode()),
+	)
+	if err != nil {
+		return token{}, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, clientSecret)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return token{}, err
+	}
+	defer closeBody(resp.Body)
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return token{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return token{}, fmt.Errorf("failed to request token: %s - %s", resp.Status, string(body))
+	}
+
+	var t token
+	err = json.Unmarshal(body, &t)
+	if err != nil {
+		return token{}, err
+	}
+
+	if t.AccessToken == "" {
+		return token{}, fmt.Errorf("failed to request token: no access_token in response")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,68 @@
+package fly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UserInfo describes the user that the session of the most recent login
+// belongs to, as returned by the ATC.
+type UserInfo struct {
+	Sub      string              `json:"sub"`
+	Name     string              `json:"name"`
+	UserID   string              `json:"user_id"`
+	UserName string              `json:"user_name"`
+	Email    string              `json:"email"`
+	IsAdmin  bool                `json:"is_admin"`
+	IsSystem bool                `json:"is_system"`
+	Teams    map[string][]string `json:"teams"`
+}
+
+// UserInfo returns the user the most recent login resolved to, along with
+// the teams it is a member of and its roles in each of them.
+func (f *command) UserInfo() (UserInfo, error) {
+	resp, err := f.apiRequest("GET", "/user", nil, nil)
+	if err != nil {
+		return UserInfo{}, err
+	}
+	defer closeBody(resp.Body)
+
+	var info UserInfo
+
+	err = json.NewDecoder(resp.Body).Decode(&info)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	f.logger.Debugf("Logged in as user %q with roles %v\n", info.UserName, info.Teams)
+
+	return info, nil
+}
+
+// verifyTeamMember checks that the user the most recent login resolved to
+// can act on the team, to catch credentials configured for the wrong team.
+// ATCs too old to report the user are not checked.
+func verifyTeamMember(flyCommand Command, teamName string) error {
+	info, err := flyCommand.UserInfo()
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsAdmin || info.IsSystem {
+		return nil
+	}
+
+	if len(info.Teams[teamName]) == 0 {
+		return &Error{Kind: ErrForbidden, Err: fmt.Errorf(
+			"logged in to team %s as user %q, which is not a member of it - check the credentials configured for the team",
+			teamName,
+			info.UserName,
+		)}
+	}
+
+	return nil
+}
@@ -1,109 +1,445 @@
 package in
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/configschema"
 	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/logger"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	apiPrefix = "/api/v1"
+
+	// versionsFilename is the file to which the versions of the pipelines
+	// are written in digest mode.
+	versionsFilename = "versions.json"
+
+	// metadataFilename is the file to which the metadata is written in the
+	// structure of concourse.MetadataDocument.
+	metadataFilename = "metadata.json"
+
+	// changedPipelineFilename is the file to which the key of the pipeline
+	// whose change a version in pipeline mode is for is written.
+	changedPipelineFilename = "changed_pipeline"
 )
 
 type Command struct {
 	logger      logger.Logger
 	flyCommand  fly.Command
 	downloadDir string
+	version     string
+	timings     *concourse.Timings
+	warnings    *concourse.Warnings
 }
 
 func NewCommand(
 	logger logger.Logger,
 	flyCommand fly.Command,
 	downloadDir string,
+	version string,
+	timings *concourse.Timings,
+	warnings *concourse.Warnings,
 ) *Command {
 	return &Command{
 		logger:      logger,
 		flyCommand:  flyCommand,
 		downloadDir: downloadDir,
+		version:     version,
+		timings:     timings,
+		warnings:    warnings,
 	}
 }
 
 func (c *Command) Run(input concourse.InRequest) (concourse.InResponse, error) {
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
+	var metadata []concourse.MetadataEntry
+	var pipelineVersions []concourse.PipelineVersion
+	var errs concourse.PipelineErrors
+
+	for _, target := range input.Source.AllTargets() {
+		targetMetadata, targetVersions, err := c.getTarget(input.Source, target, input.Params, &errs)
+		if err != nil {
+			return concourse.InResponse{}, err
+		}
+
+		metadata = append(metadata, targetMetadata...)
+		pipelineVersions = append(pipelineVersions, targetVersions...)
+	}
+
+	err := errs.Err()
+	if err != nil {
+		return concourse.InResponse{}, err
+	}
+
+	// Versions for a changed pipeline may hold no entries of other pipelines,
+	// so the changed pipeline is given instead of the changes since them.
+	if len(input.Version) > 0 && input.Version[concourse.ChangedKey] == "" {
+		current := input.Source.Emitted(concourse.NewVersion(pipelineVersions))
+		metadata = append(metadata, current.ChangesSince(input.Version).MetadataEntries()...)
+	}
+
+	if input.Source.VersionMode == concourse.VersionModeDigest {
+		err := c.writeVersions(input.Version, concourse.NewVersion(pipelineVersions))
+		if err != nil {
+			return concourse.InResponse{}, err
+		}
+	}
+
+	if changed := input.Version[concourse.ChangedKey]; changed != "" {
+		metadata = append(metadata, concourse.NewMetadataEntry("changed pipeline", changed))
+
+		err := ioutil.WriteFile(filepath.Join(c.downloadDir, changedPipelineFilename), []byte(changed), os.ModePerm)
+		// Untested as it is too hard to force ioutil.WriteFile to error
 		if err != nil {
 			return concourse.InResponse{}, err
 		}
 	}
 
+	metadata = append(metadata, c.warnings.MetadataEntries()...)
+
+	timings := c.timings.MetadataEntries()
+	for _, e := range timings {
+		m := e.Metadata()
+		c.logger.Debugf("%s: %s\n", m.Name, m.Value)
+	}
+	metadata = append(metadata, timings...)
+
+	metadata = append(metadata, concourse.NewMetadataEntry("resource_version", c.version))
+
+	err = c.writeJSON(metadataFilename, concourse.NewMetadataDocument(metadata))
+	if err != nil {
+		return concourse.InResponse{}, err
+	}
+
+	response := concourse.InResponse{
+		Version:  input.Version,
+		Metadata: concourse.MetadataOf(metadata),
+	}
+
+	return response, nil
+}
+
+// getTarget downloads the pipelines of each team of the target included by the
+// source, returning metadata about them and the target, and their versions.
+// Files and metadata of named targets are prefixed with the name. The
+// failure to download any pipeline is added to errs, and the others are
+// still downloaded.
+func (c *Command) getTarget(source concourse.Source, target concourse.Target, params concourse.InParams, errs *concourse.PipelineErrors) ([]concourse.MetadataEntry, []concourse.PipelineVersion, error) {
+	prefix := ""
+	if target.Name != "" {
+		prefix = target.Name + "-"
+	}
+
+	insecure, err := target.InsecureSkipVerify()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metadata []concourse.MetadataEntry
+	var versions []concourse.PipelineVersion
+
+	targetTeams := target.Teams
+	if target.TeamPattern != "" {
+		targetTeams, err = fly.ExpandTeamPattern(
+			c.flyCommand,
+			target.APIEndpoint(),
+			target.Teams,
+			target.TeamPattern,
+			insecure,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	teams := make(map[string]concourse.Team)
 
-	for _, team := range input.Source.Teams {
+	for _, team := range targetTeams {
 		teams[team.Name] = team
 	}
 
 	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
+		teamLogger := c.logger.With(logger.Fields{Team: teamName})
+		teamLogger.Debugf("Performing login\n")
+		_, err := fly.LoginToTeam(
+			c.flyCommand,
+			target.APIEndpoint(),
+			team,
 			insecure,
 		)
 		if err != nil {
-			return concourse.InResponse{}, err
+			return nil, nil, err
 		}
 
-		c.logger.Debugf("Login successful\n")
+		teamLogger.Debugf("Login successful\n")
 
-		pipelines, err := c.flyCommand.Pipelines()
+		stopList := c.timings.Start(concourse.PhaseList)
+		pipelines, err := c.flyCommand.Pipelines(false)
+		stopList()
 		if err != nil {
-			return concourse.InResponse{}, err
+			return nil, nil, concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
 		}
-		c.logger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
+		teamLogger.Debugf("Found pipelines (%s): %+v\n", teamName, pipelines)
 
-		for _, pipelineName := range pipelines {
-			outContents, err := c.flyCommand.GetPipeline(pipelineName)
-			if err != nil {
-				return concourse.InResponse{}, err
+		var included []fly.Pipeline
+		for _, pipeline := range pipelines {
+			if !source.IncludesPipeline(pipeline.Name) || !team.IncludesPipeline(pipeline.Name) {
+				teamLogger.With(logger.Fields{Pipeline: pipeline.Name}).Debugf("Pipeline not included, skipping: %s\n", pipeline.Name)
+				continue
+			}
+			included = append(included, pipeline)
+		}
+
+		if skipped := len(pipelines) - len(included); skipped > 0 {
+			c.warnings.Add("%d pipelines of team %s not included by the source, skipped", skipped, teamName)
+		}
+
+		progress := logger.NewProgress(teamLogger, "fetched", teamName, len(included), logger.ProgressInterval)
+
+		for _, pipeline := range included {
+			pipelineLogger := teamLogger.With(logger.Fields{Pipeline: pipeline.Name})
+			if source.Quiet {
+				pipelineLogger = logger.Demote(pipelineLogger)
+			}
+
+			version, pipelineMetadata, err := c.getPipeline(source, target, prefix, teamName, pipeline, params, pipelineLogger)
+			progress.Add()
+			if errors.Is(err, fly.ErrNotFound) {
+				pipelineLogger.Infof("Pipeline deleted since listing, skipping: %s\n", pipeline.Name)
+				c.warnings.Add("pipeline %s of team %s deleted since listing, skipped", pipeline.Name, teamName)
+				continue
 			}
-			pipelineContentsFilepath := filepath.Join(
-				c.downloadDir,
-				fmt.Sprintf(
-					"%s-%s.yml",
-					teamName,
-					pipelineName,
-				),
-			)
-			c.logger.Debugf(
-				"Writing pipeline contents to: %s\n",
-				pipelineContentsFilepath,
-			)
-			err = ioutil.WriteFile(pipelineContentsFilepath, outContents, os.ModePerm)
-			// Untested as it is too hard to force ioutil.WriteFile to error
 			if err != nil {
-				return concourse.InResponse{}, err
+				pipelineLogger.Errorf("Failed to get pipeline %s: %v\n", pipeline.Name, err)
+				errs.Add(target.Name, teamName, pipeline.Name, concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err))
+				continue
 			}
+
+			versions = append(versions, version)
+			metadata = append(metadata, pipelineMetadata...)
 		}
 	}
 
-	response := concourse.InResponse{
-		Version:  input.Version,
-		Metadata: []concourse.Metadata{},
+	info, err := c.flyCommand.Info()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return response, nil
+	metadata = append(metadata, target.Metadata("concourse_version", info.Version))
+	if info.ClusterName != "" {
+		metadata = append(metadata, target.Metadata("cluster_name", info.ClusterName))
+	}
+
+	flyVersion, err := c.flyCommand.FlyVersion()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata = append(metadata, target.Metadata("fly_version", flyVersion))
+
+	return metadata, versions, nil
+}
+
+// getPipeline downloads the pipeline of the team, returning its version and
+// metadata about it.
+func (c *Command) getPipeline(
+	source concourse.Source,
+	target concourse.Target,
+	prefix string,
+	teamName string,
+	pipeline fly.Pipeline,
+	params concourse.InParams,
+	pipelineLogger logger.Logger,
+) (concourse.PipelineVersion, []concourse.MetadataEntry, error) {
+	pipelineName := pipeline.Name
+
+	stopFetch := c.timings.Start(concourse.PhaseFetch)
+	outContents, err := c.flyCommand.GetPipeline(pipeline.Ref().String())
+	fetched := stopFetch()
+	if err != nil {
+		return concourse.PipelineVersion{}, nil, err
+	}
+	pipelineLogger.Debugf("Fetched in %s\n", fetched)
+
+	pipelineFilepath := filepath.Join(c.downloadDir, pipelineFilename(prefix, teamName, pipeline))
+	pipelineContentsFilepath := pipelineFilepath + ".yml"
+	if params.ValidateSchema {
+		problems, err := configschema.Validate(outContents)
+		if err != nil {
+			return concourse.PipelineVersion{}, nil, fmt.Errorf("validating config: %v", err)
+		}
+
+		for _, p := range problems {
+			pipelineLogger.Warnf("Config does not match the schema: %s\n", p)
+			c.warnings.Add("pipeline %s of team %s: %s", pipelineName, teamName, p)
+		}
+	}
+
+	fileContents := outContents
+	if params.Canonical {
+		fileContents, err = concourse.CanonicalYAML(outContents)
+		if err != nil {
+			return concourse.PipelineVersion{}, nil, fmt.Errorf("canonicalizing config: %v", err)
+		}
+	}
+
+	pipelineLogger.Debugf(
+		"Writing pipeline contents to: %s\n",
+		pipelineContentsFilepath,
+	)
+	err = ioutil.WriteFile(pipelineContentsFilepath, fileContents, os.ModePerm)
+	// Untested as it is too hard to force ioutil.WriteFile to error
+	if err != nil {
+		return concourse.PipelineVersion{}, nil, err
+	}
+
+	if params.Graph != "" {
+		err = c.writeGraph(pipelineFilepath, teamName+"/"+pipeline.Ref().String(), outContents, params.Graph)
+		if err != nil {
+			return concourse.PipelineVersion{}, nil, err
+		}
+	}
+
+	var checksum string
+	if source.VersionScheme == concourse.VersionSchemeConfigVersion {
+		stopFetch := c.timings.Start(concourse.PhaseFetch)
+		checksum, err = fly.ConfigVersion(c.flyCommand, pipeline.Ref())
+		stopFetch()
+	} else {
+		stopChecksum := c.timings.Start(concourse.PhaseChecksum)
+		checksum, err = source.PipelineChecksum(outContents)
+		stopChecksum()
+	}
+	if err != nil {
+		return concourse.PipelineVersion{}, nil, err
+	}
+
+	version := concourse.PipelineVersion{
+		Target:       target.Name,
+		Team:         teamName,
+		Pipeline:     pipelineName,
+		InstanceVars: pipeline.InstanceVars,
+		Checksum:     checksum,
+	}
+
+	var metadata []concourse.MetadataEntry
+
+	pipelineMetadata := func(field string, value interface{}) concourse.MetadataEntry {
+		return target.PipelineMetadata(teamName, pipelineName, pipeline.InstanceVars, field, value)
+	}
+
+	metadata = append(metadata,
+		pipelineMetadata("", target.PipelineURL(teamName, pipelineName, pipeline.InstanceVars)),
+		pipelineMetadata("paused", pipeline.Paused),
+		pipelineMetadata("public", pipeline.Public),
+	)
+
+	var counts configCounts
+	err = yaml.Unmarshal(outContents, &counts)
+	if err != nil {
+		return concourse.PipelineVersion{}, nil, fmt.Errorf("parsing config: %v", err)
+	}
+
+	metadata = append(metadata,
+		pipelineMetadata("jobs", len(counts.Jobs)),
+		pipelineMetadata("resources", len(counts.Resources)),
+		pipelineMetadata("resource types", len(counts.ResourceTypes)),
+	)
+
+	if params.IncludeStatus {
+		jobs, err := c.flyCommand.Jobs(pipeline.Ref())
+		if err != nil {
+			return concourse.PipelineVersion{}, nil, err
+		}
+
+		resources, err := c.flyCommand.Resources(pipeline.Ref())
+		if err != nil {
+			return concourse.PipelineVersion{}, nil, err
+		}
+
+		metadata = append(metadata,
+			pipelineMetadata("status", fly.PipelineStatus(jobs)),
+			pipelineMetadata("paused jobs", fly.PausedJobs(jobs)),
+			pipelineMetadata("pinned resources", fly.PinnedResources(resources)),
+		)
+	}
+
+	return version, metadata, nil
+}
+
+// pipelineFilename returns the name of the files written for the pipeline,
+// without an extension: [prefix]team-pipeline, followed by the instance vars
+// of an instanced pipeline, escaped, so that instances of the same pipeline
+// do not overwrite each other's files.
+func pipelineFilename(prefix string, teamName string, pipeline fly.Pipeline) string {
+	name := fmt.Sprintf("%s%s-%s", prefix, teamName, pipeline.Name)
+	if len(pipeline.InstanceVars) > 0 {
+		name += "-" + url.PathEscape(concourse.FormatInstanceVars(pipeline.InstanceVars))
+	}
+
+	return name
+}
+
+// writeVersions writes the versions of the pipelines, which a version in
+// digest mode does not hold, to versions.json.
+func (c *Command) writeVersions(requested concourse.Version, pipelines concourse.Version) error {
+	if requested[concourse.DigestKey] != "" && requested[concourse.DigestKey] != pipelines.Digest()[concourse.DigestKey] {
+		c.logger.Warnf("Pipelines have changed since version %s was emitted\n", requested[concourse.DigestKey])
+	}
+
+	return c.writeJSON(versionsFilename, pipelines)
+}
+
+// writeGraph writes the graph of the jobs and resources of the pipeline to a
+// .dot file at the path, and renders it to a .svg file too if the format is
+// svg.
+func (c *Command) writeGraph(path string, name string, config []byte, format string) error {
+	graph, err := renderGraph(name, config)
+	if err != nil {
+		return fmt.Errorf("rendering graph: %v", err)
+	}
+
+	dotPath := path + ".dot"
+	err = ioutil.WriteFile(dotPath, graph, os.ModePerm)
+	// Untested as it is too hard to force ioutil.WriteFile to error
+	if err != nil {
+		return err
+	}
+
+	if format == concourse.GraphFormatSVG {
+		return renderSVG(dotPath, path+".svg")
+	}
+
+	return nil
+}
+
+// writeJSON writes the value as JSON to the file of the download directory.
+func (c *Command) writeJSON(filename string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		// Untested as only values which always marshal are written
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(c.downloadDir, filename), b, os.ModePerm)
+}
+
+// configCounts holds enough of a pipeline config to count its jobs,
+// resources and resource types.
+type configCounts struct {
+	Jobs          []interface{} `yaml:"jobs"`
+	Resources     []interface{} `yaml:"resources"`
+	ResourceTypes []interface{} `yaml:"resource_types"`
 }
 
 type pipelineWithContent struct {
@@ -1,12 +1,16 @@
 package in_test
 
 import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/in"
 	"github.com/concourse/concourse-pipeline-resource/logger"
@@ -28,8 +32,9 @@ var _ = Describe("In", func() {
 		command   *in.Command
 
 		fakeFlyCommand *flyfakes.FakeCommand
+		timings        *concourse.Timings
 
-		pipelines        []string
+		pipelines        []fly.Pipeline
 		pipelineVersions []string
 
 		pipelinesErr error
@@ -39,6 +44,10 @@ var _ = Describe("In", func() {
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		timings = nil
+		fakeFlyCommand.UserInfoReturns(fly.UserInfo{IsAdmin: true}, nil)
+		fakeFlyCommand.FlyVersionReturns("7.9.1", nil)
+		fakeFlyCommand.InfoReturns(fly.Info{Version: "7.9.1"}, nil)
 
 		var err error
 		downloadDir, err = ioutil.TempDir("", "")
@@ -54,25 +63,35 @@ var _ = Describe("In", func() {
 		}
 
 		pipelinesErr = nil
-		pipelines = []string{"pipeline-1", "pipeline-2"}
-		pipelineVersions = []string{"1234", "2345"}
+		pipelines = []fly.Pipeline{{Name: "pipeline-1", Paused: true}, {Name: "pipeline-2", Public: true}}
 		pipelineContents = make([]string, 2)
 
 		pipelineContents[0] = `---
-pipeline1: foo
+resources:
+- name: repo
+  type: git
+jobs:
+- name: job-1
+- name: job-2
 `
 
 		pipelineContents[1] = `---
 pipeline2: foo
 `
 
+		pipelineVersions = []string{
+			fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
+			fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+		}
+
 		inRequest = concourse.InRequest{
 			Source: concourse.Source{
 				Target: target,
 				Teams:  teams,
 			},
 			Version: concourse.Version{
-				pipelines[0]: pipelineVersions[0],
+				"main/" + pipelines[0].Name: pipelineVersions[0],
+				"main/" + pipelines[1].Name: pipelineVersions[1],
 			},
 		}
 
@@ -80,9 +99,9 @@ pipeline2: foo
 			ginkgoLogger.Debugf("GetPipelineStub for: %s\n", name)
 
 			switch name {
-			case pipelines[0]:
+			case pipelines[0].Name:
 				return []byte(pipelineContents[0]), nil
-			case pipelines[1]:
+			case pipelines[1].Name:
 				return []byte(pipelineContents[1]), nil
 			default:
 				Fail("Unexpected invocation of flyCommand.GetPipeline")
@@ -99,7 +118,7 @@ pipeline2: foo
 
 		ginkgoLogger = logger.NewLogger(sanitizer)
 
-		command = in.NewCommand(ginkgoLogger, fakeFlyCommand, downloadDir)
+		command = in.NewCommand(ginkgoLogger, fakeFlyCommand, downloadDir, "1.2.3", timings, concourse.NewWarnings())
 	})
 
 	AfterEach(func() {
@@ -115,14 +134,14 @@ pipeline2: foo
 		files, err := ioutil.ReadDir(downloadDir)
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(files).To(HaveLen(len(pipelines)))
-		Expect(files[0].Name()).To(MatchRegexp("%s.yml", pipelines[0]))
+		Expect(files).To(HaveLen(len(pipelines) + 1))
+		Expect(files[0].Name()).To(MatchRegexp("%s.yml", pipelines[0].Name))
 
 		contents, err := ioutil.ReadFile(filepath.Join(downloadDir, files[0].Name()))
 		Expect(err).NotTo(HaveOccurred())
 		Expect(string(contents)).To(Equal(pipelineContents[0]))
 
-		Expect(files[1].Name()).To(MatchRegexp("%s.yml", pipelines[1]))
+		Expect(files[1].Name()).To(MatchRegexp("%s.yml", pipelines[1].Name))
 
 		contents, err = ioutil.ReadFile(filepath.Join(downloadDir, files[1].Name()))
 		Expect(err).NotTo(HaveOccurred())
@@ -134,7 +153,7 @@ pipeline2: foo
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Version[pipelines[0]]).To(Equal(pipelineVersions[0]))
+		Expect(response.Version).To(Equal(inRequest.Version))
 	})
 
 	It("returns metadata", func() {
@@ -142,7 +161,331 @@ pipeline2: foo
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Metadata).NotTo(BeNil())
+		Expect(response.Metadata).To(Equal([]concourse.Metadata{
+			{Name: "main/pipeline-1", Value: "some target/teams/main/pipelines/pipeline-1"},
+			{Name: "main/pipeline-1 paused", Value: "true"},
+			{Name: "main/pipeline-1 public", Value: "false"},
+			{Name: "main/pipeline-1 jobs", Value: "2"},
+			{Name: "main/pipeline-1 resources", Value: "1"},
+			{Name: "main/pipeline-1 resource types", Value: "0"},
+			{Name: "main/pipeline-2", Value: "some target/teams/main/pipelines/pipeline-2"},
+			{Name: "main/pipeline-2 paused", Value: "false"},
+			{Name: "main/pipeline-2 public", Value: "true"},
+			{Name: "main/pipeline-2 jobs", Value: "0"},
+			{Name: "main/pipeline-2 resources", Value: "0"},
+			{Name: "main/pipeline-2 resource types", Value: "0"},
+			{Name: "concourse_version", Value: "7.9.1"},
+			{Name: "fly_version", Value: "7.9.1"},
+			{Name: "resource_version", Value: "1.2.3"},
+		}))
+	})
+
+	It("writes the metadata to metadata.json", func() {
+		_, err := command.Run(inRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		b, err := ioutil.ReadFile(filepath.Join(downloadDir, "metadata.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var document concourse.MetadataDocument
+		err = json.Unmarshal(b, &document)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(document.SchemaVersion).To(Equal(1))
+		Expect(document.Entries).To(ContainElement(concourse.MetadataEntry{
+			Name:     "main/pipeline-1 jobs",
+			Type:     "int",
+			Value:    float64(2),
+			Team:     "main",
+			Pipeline: "pipeline-1",
+		}))
+		Expect(document.Entries).To(ContainElement(concourse.MetadataEntry{
+			Name:     "main/pipeline-1 paused",
+			Type:     "bool",
+			Value:    true,
+			Team:     "main",
+			Pipeline: "pipeline-1",
+		}))
+		Expect(document.Entries).To(ContainElement(concourse.MetadataEntry{
+			Name:  "concourse_version",
+			Type:  "string",
+			Value: "7.9.1",
+		}))
+	})
+
+	Context("when pipelines are excluded", func() {
+		BeforeEach(func() {
+			inRequest.Source.Exclude = []string{pipelines[0].Name}
+		})
+
+		It("does not download them", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := ioutil.ReadDir(downloadDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].Name()).To(MatchRegexp("%s.yml", pipelines[1].Name))
+		})
+
+		It("warns that they were skipped in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "warning",
+				Value: "1 pipelines of team main not included by the source, skipped",
+			}))
+		})
+	})
+
+	Context("when an API URL is configured", func() {
+		BeforeEach(func() {
+			inRequest.Source.APIURL = "http://web.internal:8080"
+		})
+
+		It("links to the pipelines at the target", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "main/pipeline-1",
+				Value: "some target/teams/main/pipelines/pipeline-1",
+			}))
+		})
+	})
+
+	Context("when the cluster is named", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.InfoReturns(fly.Info{Version: "7.9.1", ClusterName: "some-cluster"}, nil)
+		})
+
+		It("includes the cluster name in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "cluster_name", Value: "some-cluster"}))
+		})
+	})
+
+	Context("when getting the cluster info returns an error", func() {
+		var (
+			expectedErr error
+		)
+
+		BeforeEach(func() {
+			expectedErr = fmt.Errorf("some error")
+			fakeFlyCommand.InfoReturns(fly.Info{}, expectedErr)
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).To(Equal(expectedErr))
+		})
+	})
+
+	Context("when pipelines have changed since the version was emitted", func() {
+		BeforeEach(func() {
+			inRequest.Version = concourse.Version{
+				"main/" + pipelines[0].Name: "some-old-checksum",
+				"main/pipeline-0":           "some-checksum",
+			}
+		})
+
+		It("summarizes the changes in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "pipelines added", Value: "main/pipeline-2"}))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "pipelines removed", Value: "main/pipeline-0"}))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "pipelines changed", Value: "main/pipeline-1"}))
+		})
+	})
+
+	Context("when a pipeline config cannot be parsed", func() {
+		BeforeEach(func() {
+			pipelineContents[1] = "jobs: [\n"
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).To(MatchError(ContainSubstring("pipeline main/pipeline-2: parsing config")))
+		})
+	})
+
+	Context("when the version mode is digest", func() {
+		BeforeEach(func() {
+			inRequest.Source.VersionMode = "digest"
+		})
+
+		It("writes the versions of the pipelines to versions.json", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := ioutil.ReadFile(filepath.Join(downloadDir, "versions.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var versions concourse.Version
+			err = json.Unmarshal(b, &versions)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versions).To(Equal(concourse.Version{
+				"main/pipeline-1": fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[0]))),
+				"main/pipeline-2": fmt.Sprintf("%x", md5.Sum([]byte(pipelineContents[1]))),
+			}))
+		})
+	})
+
+	Context("when the version scheme is config_version", func() {
+		BeforeEach(func() {
+			inRequest.Source.VersionScheme = "config_version"
+			inRequest.Source.VersionMode = "digest"
+			fakeFlyCommand.GetPipelineConfigReturns(fly.PipelineConfig{}, "5", nil)
+		})
+
+		It("writes the config versions of the pipelines to versions.json", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := ioutil.ReadFile(filepath.Join(downloadDir, "versions.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			var versions concourse.Version
+			err = json.Unmarshal(b, &versions)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versions).To(Equal(concourse.Version{
+				"main/pipeline-1": "5",
+				"main/pipeline-2": "5",
+			}))
+		})
+	})
+
+	Context("when timings are recorded", func() {
+		BeforeEach(func() {
+			timings = concourse.NewTimings()
+		})
+
+		It("returns the time spent in each phase in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, m := range response.Metadata {
+				names = append(names, m.Name)
+			}
+			Expect(names).To(ContainElement("list duration"))
+			Expect(names).To(ContainElement("fetch duration"))
+			Expect(names).To(ContainElement("checksum duration"))
+		})
+	})
+
+	Context("when the version is for a changed pipeline", func() {
+		BeforeEach(func() {
+			inRequest.Version = concourse.Version{
+				concourse.ChangedKey: "main/pipeline-2",
+				"main/pipeline-2":    "some-checksum",
+			}
+		})
+
+		It("writes the key of the pipeline to changed_pipeline", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			b, err := ioutil.ReadFile(filepath.Join(downloadDir, "changed_pipeline"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal("main/pipeline-2"))
+		})
+
+		It("returns the key of the pipeline in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "changed pipeline", Value: "main/pipeline-2"}))
+			Expect(response.Metadata).NotTo(ContainElement(WithTransform(func(m concourse.Metadata) string {
+				return m.Name
+			}, HavePrefix("pipelines "))))
+		})
+	})
+
+	Context("when getting the fly version returns an error", func() {
+		var (
+			expectedErr error
+		)
+
+		BeforeEach(func() {
+			expectedErr = fmt.Errorf("some error")
+			fakeFlyCommand.FlyVersionReturns("", expectedErr)
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).To(Equal(expectedErr))
+		})
+	})
+
+	Context("when multiple targets are configured", func() {
+		BeforeEach(func() {
+			inRequest.Source.Teams = nil
+			inRequest.Source.Targets = []concourse.Target{
+				{Name: "eu", Target: "some eu target", Teams: teams},
+				{Name: "us", Target: "some us target", Teams: teams},
+			}
+			inRequest.Version = concourse.Version{
+				"eu/main/" + pipelines[0].Name: pipelineVersions[0],
+				"eu/main/" + pipelines[1].Name: pipelineVersions[1],
+				"us/main/" + pipelines[0].Name: pipelineVersions[0],
+				"us/main/" + pipelines[1].Name: pipelineVersions[1],
+			}
+		})
+
+		It("downloads the pipeline configs of each target, prefixed with the target name", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, name := range []string{"eu-main-pipeline-1.yml", "us-main-pipeline-2.yml"} {
+				_, err := os.Stat(filepath.Join(downloadDir, name))
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("returns metadata for each target", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(Equal([]concourse.Metadata{
+				{Name: "eu/main/pipeline-1", Value: "some eu target/teams/main/pipelines/pipeline-1"},
+				{Name: "eu/main/pipeline-1 paused", Value: "true"},
+				{Name: "eu/main/pipeline-1 public", Value: "false"},
+				{Name: "eu/main/pipeline-1 jobs", Value: "2"},
+				{Name: "eu/main/pipeline-1 resources", Value: "1"},
+				{Name: "eu/main/pipeline-1 resource types", Value: "0"},
+				{Name: "eu/main/pipeline-2", Value: "some eu target/teams/main/pipelines/pipeline-2"},
+				{Name: "eu/main/pipeline-2 paused", Value: "false"},
+				{Name: "eu/main/pipeline-2 public", Value: "true"},
+				{Name: "eu/main/pipeline-2 jobs", Value: "0"},
+				{Name: "eu/main/pipeline-2 resources", Value: "0"},
+				{Name: "eu/main/pipeline-2 resource types", Value: "0"},
+				{Name: "eu/concourse_version", Value: "7.9.1"},
+				{Name: "eu/fly_version", Value: "7.9.1"},
+				{Name: "us/main/pipeline-1", Value: "some us target/teams/main/pipelines/pipeline-1"},
+				{Name: "us/main/pipeline-1 paused", Value: "true"},
+				{Name: "us/main/pipeline-1 public", Value: "false"},
+				{Name: "us/main/pipeline-1 jobs", Value: "2"},
+				{Name: "us/main/pipeline-1 resources", Value: "1"},
+				{Name: "us/main/pipeline-1 resource types", Value: "0"},
+				{Name: "us/main/pipeline-2", Value: "some us target/teams/main/pipelines/pipeline-2"},
+				{Name: "us/main/pipeline-2 paused", Value: "false"},
+				{Name: "us/main/pipeline-2 public", Value: "true"},
+				{Name: "us/main/pipeline-2 jobs", Value: "0"},
+				{Name: "us/main/pipeline-2 resources", Value: "0"},
+				{Name: "us/main/pipeline-2 resource types", Value: "0"},
+				{Name: "us/concourse_version", Value: "7.9.1"},
+				{Name: "us/fly_version", Value: "7.9.1"},
+				{Name: "resource_version", Value: "1.2.3"},
+			}))
+		})
 	})
 
 	Context("when insecure parses as true", func() {
@@ -199,7 +542,8 @@ pipeline2: foo
 			_, err := command.Run(inRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err).To(Equal(pipelinesErr))
+			Expect(errors.Is(err, pipelinesErr)).To(BeTrue())
+			Expect(concourse.ErrorCodeOf(err)).To(Equal(concourse.ErrorCodePipelineFetchFailed))
 		})
 	})
 
@@ -215,7 +559,257 @@ pipeline2: foo
 
 		It("returns an error", func() {
 			_, err := command.Run(inRequest)
-			Expect(err).To(Equal(expectedErr))
+			Expect(errors.Is(err, expectedErr)).To(BeTrue())
+		})
+	})
+
+	Context("when getting several pipelines returns an error", func() {
+		BeforeEach(func() {
+			fakeFlyCommand.GetPipelineReturns(nil, fmt.Errorf("some error"))
+		})
+
+		It("tries to get every pipeline, returning an error listing each failure", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).To(MatchError("2 pipelines failed:\n  - pipeline main/pipeline-1: some error\n  - pipeline main/pipeline-2: some error"))
+
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when pipelines are instances of the same pipeline", func() {
+		BeforeEach(func() {
+			pipelines = []fly.Pipeline{
+				{Name: "pipeline-1", InstanceVars: map[string]interface{}{"branch": "main"}},
+				{Name: "pipeline-1", InstanceVars: map[string]interface{}{"branch": "feature/x"}},
+			}
+			inRequest.Version = nil
+			inRequest.Params.Graph = concourse.GraphFormatDOT
+
+			fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+				switch name {
+				case `pipeline-1/branch:"main"`:
+					return []byte(pipelineContents[0]), nil
+				case `pipeline-1/branch:"feature/x"`:
+					return []byte(pipelineContents[1]), nil
+				default:
+					Fail("Unexpected invocation of flyCommand.GetPipeline: " + name)
+					return nil, nil
+				}
+			}
+		})
+
+		It("writes the files of each instance, named after its instance vars", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main-pipeline-1-branch:%22main%22.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(pipelineContents[0]))
+
+			contents, err = ioutil.ReadFile(filepath.Join(downloadDir, "main-pipeline-1-branch:%22feature%2Fx%22.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(pipelineContents[1]))
+
+			Expect(filepath.Join(downloadDir, "main-pipeline-1-branch:%22main%22.dot")).To(BeAnExistingFile())
+			Expect(filepath.Join(downloadDir, "main-pipeline-1-branch:%22feature%2Fx%22.dot")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("when canonical is set", func() {
+		BeforeEach(func() {
+			inRequest.Params.Canonical = true
+		})
+
+		It("writes each pipeline config as canonical YAML, keeping the version of the config as got", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main-pipeline-1.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`jobs:
+- name: job-1
+- name: job-2
+resources:
+- name: repo
+  type: git
+`))
+
+			Expect(response.Version).To(Equal(inRequest.Version))
+		})
+	})
+
+	Context("when validate_schema is set", func() {
+		BeforeEach(func() {
+			inRequest.Params.ValidateSchema = true
+		})
+
+		It("warns of each field of each config which does not match the schema in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "warning",
+				Value: "pipeline pipeline-1 of team main: config.jobs[0].plan must be provided",
+			}))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "warning",
+				Value: "pipeline pipeline-1 of team main: config.jobs[1].plan must be provided",
+			}))
+			Expect(response.Metadata).NotTo(ContainElement(WithTransform(func(m concourse.Metadata) string {
+				return m.Name + ": " + m.Value
+			}, HavePrefix("warning: pipeline pipeline-2"))))
+		})
+	})
+
+	Context("when graph is dot", func() {
+		BeforeEach(func() {
+			inRequest.Params.Graph = concourse.GraphFormatDOT
+
+			pipelineContents[0] = `---
+resources:
+- name: repo
+  type: git
+- name: image
+  type: registry-image
+jobs:
+- name: build
+  plan:
+  - in_parallel:
+    - get: repo
+      trigger: true
+  - put: image
+- name: deploy
+  plan:
+  - do:
+    - get: image
+      passed: [build]
+  on_failure:
+    put: repo
+`
+		})
+
+		It("writes the graph of the jobs and resources of each pipeline", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(downloadDir, "main-pipeline-1.dot"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`digraph "main/pipeline-1" {
+  rankdir=LR;
+  "resource:repo" [label="repo", shape=box, tooltip="git"];
+  "resource:image" [label="image", shape=box, tooltip="registry-image"];
+  "job:build" [label="build", shape=box, style="rounded,filled"];
+  "job:deploy" [label="deploy", shape=box, style="rounded,filled"];
+  "resource:repo" -> "job:build";
+  "job:build" -> "resource:image";
+  "job:build" -> "job:deploy" [label="image"];
+  "job:deploy" -> "resource:repo";
+}
+`))
+
+			_, err = os.Stat(filepath.Join(downloadDir, "main-pipeline-2.dot"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when include_status is set", func() {
+		BeforeEach(func() {
+			inRequest.Params.IncludeStatus = true
+
+			fakeFlyCommand.JobsStub = func(ref fly.PipelineRef) ([]fly.Job, error) {
+				switch ref.Name {
+				case pipelines[0].Name:
+					return []fly.Job{
+						{Name: "job-1", FinishedBuild: &fly.Build{Status: "succeeded"}},
+						{Name: "job-2", FinishedBuild: &fly.Build{Status: "failed"}, Paused: true},
+					}, nil
+				default:
+					return []fly.Job{
+						{Name: "job-1", FinishedBuild: &fly.Build{Status: "succeeded"}},
+					}, nil
+				}
+			}
+
+			fakeFlyCommand.ResourcesStub = func(ref fly.PipelineRef) ([]fly.Resource, error) {
+				if ref.Name == pipelines[1].Name {
+					return []fly.Resource{{Name: "repo", PinnedVersion: map[string]string{"ref": "abc"}}, {Name: "timer"}}, nil
+				}
+				return []fly.Resource{{Name: "repo"}}, nil
+			}
+		})
+
+		It("includes the status and health of each pipeline in the metadata", func() {
+			response, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.JobsCallCount()).To(Equal(2))
+			Expect(response.Metadata).To(Equal([]concourse.Metadata{
+				{Name: "main/pipeline-1", Value: "some target/teams/main/pipelines/pipeline-1"},
+				{Name: "main/pipeline-1 paused", Value: "true"},
+				{Name: "main/pipeline-1 public", Value: "false"},
+				{Name: "main/pipeline-1 jobs", Value: "2"},
+				{Name: "main/pipeline-1 resources", Value: "1"},
+				{Name: "main/pipeline-1 resource types", Value: "0"},
+				{Name: "main/pipeline-1 status", Value: "failed"},
+				{Name: "main/pipeline-1 paused jobs", Value: "1"},
+				{Name: "main/pipeline-1 pinned resources", Value: "0"},
+				{Name: "main/pipeline-2", Value: "some target/teams/main/pipelines/pipeline-2"},
+				{Name: "main/pipeline-2 paused", Value: "false"},
+				{Name: "main/pipeline-2 public", Value: "true"},
+				{Name: "main/pipeline-2 jobs", Value: "0"},
+				{Name: "main/pipeline-2 resources", Value: "0"},
+				{Name: "main/pipeline-2 resource types", Value: "0"},
+				{Name: "main/pipeline-2 status", Value: "succeeded"},
+				{Name: "main/pipeline-2 paused jobs", Value: "0"},
+				{Name: "main/pipeline-2 pinned resources", Value: "1"},
+				{Name: "concourse_version", Value: "7.9.1"},
+				{Name: "fly_version", Value: "7.9.1"},
+				{Name: "resource_version", Value: "1.2.3"},
+			}))
+		})
+
+		Context("when getting jobs returns an error", func() {
+			var (
+				expectedErr error
+			)
+
+			BeforeEach(func() {
+				expectedErr = fmt.Errorf("some error")
+				fakeFlyCommand.JobsStub = nil
+				fakeFlyCommand.JobsReturns(nil, expectedErr)
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(inRequest)
+				Expect(errors.Is(err, expectedErr)).To(BeTrue())
+			})
+		})
+
+		Context("when getting resources returns an error", func() {
+			var (
+				expectedErr error
+			)
+
+			BeforeEach(func() {
+				expectedErr = fmt.Errorf("some error")
+				fakeFlyCommand.ResourcesStub = nil
+				fakeFlyCommand.ResourcesReturns(nil, expectedErr)
+			})
+
+			It("returns an error", func() {
+				_, err := command.Run(inRequest)
+				Expect(errors.Is(err, expectedErr)).To(BeTrue())
+			})
+		})
+	})
+
+	Context("when include_status is not set", func() {
+		It("does not get the jobs of any pipeline", func() {
+			_, err := command.Run(inRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.JobsCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.ResourcesCallCount()).To(Equal(0))
 		})
 	})
 })
@@ -0,0 +1,181 @@
+package in
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// graphConfig holds enough of a pipeline config to draw the graph of its jobs
+// and resources.
+type graphConfig struct {
+	Resources []struct {
+		Name string `yaml:"name"`
+		Type string `yaml:"type"`
+	} `yaml:"resources"`
+	Jobs []struct {
+		Name      string        `yaml:"name"`
+		Plan      []interface{} `yaml:"plan"`
+		OnSuccess interface{}   `yaml:"on_success"`
+		OnFailure interface{}   `yaml:"on_failure"`
+		OnError   interface{}   `yaml:"on_error"`
+		OnAbort   interface{}   `yaml:"on_abort"`
+		Ensure    interface{}   `yaml:"ensure"`
+	} `yaml:"jobs"`
+}
+
+// graphEdge is an edge between two nodes of the graph, labelled with the
+// resource passed between jobs, if any.
+type graphEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+// renderGraph returns the graph of the jobs and resources of the pipeline in
+// the DOT language, drawn as Concourse does: resources feed the jobs which
+// get them, unless they must have passed other jobs, which then feed those
+// jobs, and jobs feed the resources they put.
+func renderGraph(name string, config []byte) ([]byte, error) {
+	var c graphConfig
+	err := yaml.Unmarshal(config, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	var edges []graphEdge
+	seen := make(map[graphEdge]bool)
+	addEdge := func(e graphEdge) {
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+
+	for _, job := range c.Jobs {
+		plan := append(job.Plan, job.OnSuccess, job.OnFailure, job.OnError, job.OnAbort, job.Ensure)
+		for _, step := range planSteps(plan) {
+			switch {
+			case step.get != "" && len(step.passed) == 0:
+				addEdge(graphEdge{from: resourceNode(step.get), to: jobNode(job.Name)})
+			case step.get != "":
+				for _, passed := range step.passed {
+					addEdge(graphEdge{from: jobNode(passed), to: jobNode(job.Name), label: step.get})
+				}
+			case step.put != "":
+				addEdge(graphEdge{from: jobNode(job.Name), to: resourceNode(step.put)})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "digraph %s {\n", strconv.Quote(name))
+	fmt.Fprintf(&buf, "  rankdir=LR;\n")
+	for _, r := range c.Resources {
+		fmt.Fprintf(&buf, "  %s [label=%s, shape=box, tooltip=%s];\n", strconv.Quote(resourceNode(r.Name)), strconv.Quote(r.Name), strconv.Quote(r.Type))
+	}
+	for _, j := range c.Jobs {
+		fmt.Fprintf(&buf, "  %s [label=%s, shape=box, style=\"rounded,filled\"];\n", strconv.Quote(jobNode(j.Name)), strconv.Quote(j.Name))
+	}
+	for _, e := range edges {
+		if e.label != "" {
+			fmt.Fprintf(&buf, "  %s -> %s [label=%s];\n", strconv.Quote(e.from), strconv.Quote(e.to), strconv.Quote(e.label))
+		} else {
+			fmt.Fprintf(&buf, "  %s -> %s;\n", strconv.Quote(e.from), strconv.Quote(e.to))
+		}
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	return buf.Bytes(), nil
+}
+
+// renderSVG renders the graph in the DOT file to the SVG file with Graphviz,
+// which must be installed.
+func renderSVG(dotPath string, svgPath string) error {
+	output, err := exec.Command("dot", "-Tsvg", "-o", svgPath, dotPath).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rendering %s requires Graphviz's dot: %v - %s", svgPath, err, output)
+	}
+
+	return nil
+}
+
+func resourceNode(name string) string {
+	return "resource:" + name
+}
+
+func jobNode(name string) string {
+	return "job:" + name
+}
+
+// graphStep is a get or put of a resource found in the plan of a job.
+type graphStep struct {
+	get    string
+	put    string
+	passed []string
+}
+
+// planSteps returns the gets and puts of the plan, including those nested in
+// other steps and hooks.
+func planSteps(plan []interface{}) []graphStep {
+	var steps []graphStep
+	for _, s := range plan {
+		steps = append(steps, stepSteps(s)...)
+	}
+
+	return steps
+}
+
+func stepSteps(s interface{}) []graphStep {
+	step, ok := s.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+
+	var steps []graphStep
+
+	if name, ok := step["get"].(string); ok {
+		get := graphStep{get: name}
+		if resource, ok := step["resource"].(string); ok {
+			get.get = resource
+		}
+		if passed, ok := step["passed"].([]interface{}); ok {
+			for _, p := range passed {
+				if job, ok := p.(string); ok {
+					get.passed = append(get.passed, job)
+				}
+			}
+		}
+		steps = append(steps, get)
+	}
+
+	if name, ok := step["put"].(string); ok {
+		put := graphStep{put: name}
+		if resource, ok := step["resource"].(string); ok {
+			put.put = resource
+		}
+		steps = append(steps, put)
+	}
+
+	for _, key := range []string{"do", "aggregate", "in_parallel"} {
+		switch nested := step[key].(type) {
+		case []interface{}:
+			steps = append(steps, planSteps(nested)...)
+		case map[interface{}]interface{}:
+			if inner, ok := nested["steps"].([]interface{}); ok {
+				steps = append(steps, planSteps(inner)...)
+			}
+		}
+	}
+
+	for _, key := range []string{"try", "on_success", "on_failure", "on_error", "on_abort", "ensure"} {
+		if nested, ok := step[key]; ok {
+			steps = append(steps, stepSteps(nested)...)
+		}
+	}
+
+	return steps
+}
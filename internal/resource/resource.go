@@ -0,0 +1,135 @@
+// Package resource holds what the check, in and out commands share to set
+// up and tear down a run of the resource.
+package resource
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/tracing"
+)
+
+// Logger is the logger of a run of the resource. It must be flushed before
+// exiting, as its sanitizers hold back the end of what was logged while it
+// could be the start of a value to sanitize.
+type Logger struct {
+	logger.Logger
+
+	// mutex serialises the lines logged, and flushing the sanitizers, as the
+	// logger is also used on receiving a signal.
+	mutex      sync.Mutex
+	sanitizers []logger.Sanitizer
+}
+
+// NewLogger returns the logger of the component writing every line to the
+// log file, and those at or above the output log level of the source to
+// stderr, i.e. the build output. Both are sanitized of the sanitized values
+// and of tokens, unless the sanitizer is disabled for debugging.
+func NewLogger(component string, source concourse.Source, sanitized map[string]string, logFile io.Writer) *Logger {
+	if source.Debug && source.DisableSanitizerForDebug {
+		fmt.Fprintf(os.Stderr, "WARNING: disable_sanitizer_for_debug is set - passwords, secrets and tokens are logged unredacted\n")
+	}
+
+	l := &Logger{}
+	l.Logger = formatLogger(component, source, l.sanitize(source, sanitized, logFile))
+
+	// Left for the validator to reject if invalid
+	threshold, err := logger.ParseLevel(source.OutputLogLevel())
+	if err == nil {
+		l.Logger = logger.Tee(l.Logger, logger.Threshold(formatLogger(component, source, l.sanitize(source, sanitized, os.Stderr)), threshold))
+	}
+
+	l.Logger = logger.Synchronized(l.Logger, &l.mutex)
+
+	return l
+}
+
+// Flush writes what the sanitizers of the logger hold back.
+func (l *Logger) Flush() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, s := range l.sanitizers {
+		err := s.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fatal flushes the logger, then exits with the error as log.Fatalln does,
+// without running deferred functions.
+func (l *Logger) Fatal(err error) {
+	l.Flush()
+	log.Fatalln(err)
+}
+
+// sanitize returns the sink sanitized of the sanitized values and of tokens,
+// unless the sanitizer is disabled for debugging.
+func (l *Logger) sanitize(source concourse.Source, sanitized map[string]string, sink io.Writer) io.Writer {
+	if source.Debug && source.DisableSanitizerForDebug {
+		return sink
+	}
+
+	if source.LogFormat == concourse.LogFormatJSON {
+		sanitized = logger.JSONSanitized(sanitized)
+	}
+
+	s := logger.NewSanitizer(sanitized, sink)
+	l.sanitizers = append(l.sanitizers, s)
+
+	return logger.NewTokenSanitizer(s)
+}
+
+// formatLogger returns the logger of the component writing to the sink in
+// the log format of the source.
+func formatLogger(component string, source concourse.Source, sink io.Writer) logger.Logger {
+	if source.LogFormat == concourse.LogFormatJSON {
+		return logger.NewJSONLogger(sink, component)
+	}
+
+	return logger.NewLogger(sink)
+}
+
+// ExportTraces exports the spans of the run, if traced, with only the class
+// of the error it failed with. Failing to export them is only logged, so that
+// it never fails the step.
+func ExportTraces(l logger.Logger, tracer *tracing.Tracer, err error) {
+	exportErr := tracer.Export(fly.ErrorClass(err))
+	if exportErr != nil {
+		l.Warnf("Failed to export traces: %v\n", exportErr)
+	}
+}
+
+// CreateStepFile creates the file, and its directories, at the path relative
+// to the directory of the step, e.g. to also write the log or the audit to.
+func CreateStepFile(dir string, path string) (*os.File, error) {
+	path = filepath.Join(dir, path)
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
+
+// PrepareWorkDir ensures the work dir exists and makes it the default
+// location for temporary files, including those created by fly.
+func PrepareWorkDir(workDir string) error {
+	err := os.MkdirAll(workDir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.Setenv("TMPDIR", workDir)
+}
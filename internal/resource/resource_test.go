@@ -0,0 +1,122 @@
+package resource_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/internal/resource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resource", func() {
+	var tempDir string
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(tempDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewLogger", func() {
+		var (
+			source  concourse.Source
+			logFile *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			source = concourse.Source{LogLevel: "error"}
+			logFile = &bytes.Buffer{}
+		})
+
+		It("writes every line to the log file, sanitized", func() {
+			l := resource.NewLogger("check", source, map[string]string{"some-password": "***REDACTED-PASSWORD***"}, logFile)
+
+			l.Debugf("Logging in with some-password\n")
+
+			Expect(logFile.String()).To(Equal("Logging in with ***REDACTED-PASSWORD***\n"))
+		})
+
+		It("writes a partial final line once flushed", func() {
+			l := resource.NewLogger("check", source, map[string]string{"some-password": "***REDACTED-PASSWORD***"}, logFile)
+
+			l.Debugf("Logging in with some-pass")
+			Expect(logFile.String()).To(Equal("Logging in with "))
+
+			err := l.Flush()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logFile.String()).To(Equal("Logging in with some-pass"))
+		})
+
+		Context("when logging as JSON", func() {
+			BeforeEach(func() {
+				source.LogFormat = concourse.LogFormatJSON
+			})
+
+			It("writes lines of the component", func() {
+				l := resource.NewLogger("check", source, nil, logFile)
+
+				l.Debugf("Logging in\n")
+
+				Expect(logFile.String()).To(ContainSubstring(`"component":"check"`))
+			})
+		})
+
+		Context("when the sanitizer is disabled for debugging", func() {
+			BeforeEach(func() {
+				source.Debug = true
+				source.DisableSanitizerForDebug = true
+			})
+
+			It("writes lines unsanitized", func() {
+				l := resource.NewLogger("check", source, map[string]string{"some-password": "***REDACTED-PASSWORD***"}, logFile)
+
+				l.Debugf("Logging in with some-password\n")
+
+				Expect(logFile.String()).To(Equal("Logging in with some-password\n"))
+			})
+		})
+	})
+
+	Describe("CreateStepFile", func() {
+		It("creates the file and its directories relative to the directory", func() {
+			f, err := resource.CreateStepFile(tempDir, "logs/out.log")
+			Expect(err).NotTo(HaveOccurred())
+			defer f.Close()
+
+			Expect(f.Name()).To(Equal(filepath.Join(tempDir, "logs", "out.log")))
+			Expect(f.Name()).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("PrepareWorkDir", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			tmpDir = os.Getenv("TMPDIR")
+		})
+
+		AfterEach(func() {
+			os.Setenv("TMPDIR", tmpDir)
+		})
+
+		It("creates the work dir and makes it the location of temporary files", func() {
+			workDir := filepath.Join(tempDir, "work")
+
+			err := resource.PrepareWorkDir(workDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(workDir).To(BeADirectory())
+			Expect(os.TempDir()).To(Equal(workDir))
+		})
+	})
+})
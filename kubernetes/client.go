@@ -0,0 +1,135 @@
+// Package kubernetes reads secrets from the Kubernetes API, as the service
+// account of the pod the resource runs in.
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ServiceAccountDir is where Kubernetes mounts the token, CA certificate and
+// namespace of the service account of a pod.
+const ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Client reads secrets from the API of a Kubernetes cluster.
+type Client struct {
+	apiURL     string
+	token      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the API at apiURL authenticating with the
+// bearer token. Secrets are read from the namespace unless another is given.
+func NewClient(apiURL string, token string, namespace string, httpClient *http.Client) *Client {
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		token:      token,
+		namespace:  namespace,
+		httpClient: httpClient,
+	}
+}
+
+// NewInClusterClient returns a client of the API of the cluster the resource
+// runs in, found with KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT,
+// authenticating as the service account of its pod and reading secrets from
+// the namespace of the pod unless another is given.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	token, err := ioutil.ReadFile(filepath.Join(ServiceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("reading the token of the service account: %v", err)
+	}
+
+	caCert, err := ioutil.ReadFile(filepath.Join(ServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("reading the CA certificate of the cluster: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in %s", filepath.Join(ServiceAccountDir, "ca.crt"))
+	}
+
+	namespace, err := ioutil.ReadFile(filepath.Join(ServiceAccountDir, "namespace"))
+	if err != nil {
+		return nil, fmt.Errorf("reading the namespace of the pod: %v", err)
+	}
+
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+
+	apiURL := "https://" + net.JoinHostPort(host, port)
+
+	return NewClient(apiURL, strings.TrimSpace(string(token)), strings.TrimSpace(string(namespace)), httpClient), nil
+}
+
+// Secret returns the data of the secret, decoded. The namespace of the client
+// is used if namespace is empty.
+func (c *Client) Secret(namespace string, name string) (map[string]string, error) {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+
+	secretURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", c.apiURL, url.PathEscape(namespace), url.PathEscape(name))
+
+	req, err := http.NewRequest("GET", secretURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %s/%s: %v", namespace, name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&status)
+
+		return nil, fmt.Errorf("reading secret %s/%s: %s: %s", namespace, name, resp.Status, status.Message)
+	}
+
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&secret)
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %s/%s: %v", namespace, name, err)
+	}
+
+	data := make(map[string]string, len(secret.Data))
+	for k, v := range secret.Data {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("reading secret %s/%s: key %s is not base64: %v", namespace, name, k, err)
+		}
+		data[k] = string(decoded)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package kubernetes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/concourse-pipeline-resource/kubernetes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		status   int
+		body     string
+
+		client *kubernetes.Client
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		status = http.StatusOK
+		body = `{"kind": "Secret", "data": {"username": "YWRtaW4=", "password": "c29tZS1wYXNzd29yZA=="}}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+
+		client = kubernetes.NewClient(server.URL, "some-token", "concourse", server.Client())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the decoded data of the secret", func() {
+		data, err := client.Secret("ci", "main-team")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(data).To(Equal(map[string]string{"username": "admin", "password": "some-password"}))
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].URL.Path).To(Equal("/api/v1/namespaces/ci/secrets/main-team"))
+		Expect(requests[0].Header.Get("Authorization")).To(Equal("Bearer some-token"))
+	})
+
+	It("reads from the namespace of the client if none is given", func() {
+		_, err := client.Secret("", "main-team")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(requests[0].URL.Path).To(Equal("/api/v1/namespaces/concourse/secrets/main-team"))
+	})
+
+	Context("when the secret cannot be read", func() {
+		BeforeEach(func() {
+			status = http.StatusForbidden
+			body = `{"kind": "Status", "message": "secrets \"main-team\" is forbidden"}`
+		})
+
+		It("returns the message of the API", func() {
+			_, err := client.Secret("ci", "main-team")
+			Expect(err).To(MatchError(`reading secret ci/main-team: 403 Forbidden: secrets "main-team" is forbidden`))
+		})
+	})
+
+	Context("when the data is not base64", func() {
+		BeforeEach(func() {
+			body = `{"data": {"token": "not base64!"}}`
+		})
+
+		It("returns an error", func() {
+			_, err := client.Secret("ci", "main-team")
+			Expect(err).To(MatchError(ContainSubstring("reading secret ci/main-team: key token is not base64")))
+		})
+	})
+})
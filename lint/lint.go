@@ -0,0 +1,114 @@
+// Package lint checks pipeline configs against configurable rules, such as
+// naming conventions, before they are set.
+package lint
+
+import (
+	"gopkg.in/yaml.v2"
+)
+
+// Rule is a check of pipeline configs.
+type Rule interface {
+	// Name identifies the rule, as configured in the source.
+	Name() string
+
+	// Check returns a description of each way in which the config breaks
+	// the rule.
+	Check(config Config) []string
+}
+
+// Config is the part of a pipeline config checked by the rules.
+type Config struct {
+	Jobs          []Job          `yaml:"jobs"`
+	Resources     []Resource     `yaml:"resources"`
+	ResourceTypes []ResourceType `yaml:"resource_types"`
+}
+
+type Job struct {
+	Name      string        `yaml:"name"`
+	Plan      []interface{} `yaml:"plan"`
+	OnSuccess interface{}   `yaml:"on_success"`
+	OnFailure interface{}   `yaml:"on_failure"`
+	OnError   interface{}   `yaml:"on_error"`
+	OnAbort   interface{}   `yaml:"on_abort"`
+	Ensure    interface{}   `yaml:"ensure"`
+}
+
+type Resource struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+}
+
+type ResourceType struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+
+	// Privileged may be a var, so is only taken to be set when true.
+	Privileged interface{} `yaml:"privileged"`
+}
+
+// Problem is a way in which a config breaks a rule.
+type Problem struct {
+	Rule    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Rule + ": " + p.Message
+}
+
+// Lint returns the problems with the config found by each of the rules.
+func Lint(rules []Rule, config []byte) ([]Problem, error) {
+	var c Config
+	err := yaml.Unmarshal(config, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	for _, r := range rules {
+		for _, message := range r.Check(c) {
+			problems = append(problems, Problem{Rule: r.Name(), Message: message})
+		}
+	}
+
+	return problems, nil
+}
+
+// Steps returns every step of the job, including those nested in other
+// steps and in hooks.
+func (j Job) Steps() []map[interface{}]interface{} {
+	var steps []map[interface{}]interface{}
+	for _, s := range append(j.Plan, j.OnSuccess, j.OnFailure, j.OnError, j.OnAbort, j.Ensure) {
+		steps = append(steps, nestedSteps(s)...)
+	}
+
+	return steps
+}
+
+func nestedSteps(s interface{}) []map[interface{}]interface{} {
+	step, ok := s.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+
+	steps := []map[interface{}]interface{}{step}
+
+	for _, key := range []string{"do", "aggregate", "in_parallel"} {
+		nested := step[key]
+		if m, ok := nested.(map[interface{}]interface{}); ok {
+			nested = m["steps"]
+		}
+
+		if list, ok := nested.([]interface{}); ok {
+			for _, n := range list {
+				steps = append(steps, nestedSteps(n)...)
+			}
+		}
+	}
+
+	for _, key := range []string{"try", "on_success", "on_failure", "on_error", "on_abort", "ensure"} {
+		steps = append(steps, nestedSteps(step[key])...)
+	}
+
+	return steps
+}
@@ -0,0 +1,89 @@
+package lint_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/lint"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+const config = `---
+resource_types:
+- name: slack
+  type: registry-image
+  privileged: true
+resources:
+- name: Repo
+  type: git
+jobs:
+- name: build
+  plan:
+  - get: Repo
+  - in_parallel:
+    - task: unit
+      privileged: true
+  ensure:
+    task: cleanup
+    privileged: ((privileged))
+- name: Deploy
+  plan: []
+`
+
+var _ = Describe("Lint", func() {
+	DescribeTable("checking the rules",
+		func(l concourse.Lint, expected ...string) {
+			rules, err := lint.Rules(l)
+			Expect(err).NotTo(HaveOccurred())
+
+			problems, err := lint.Lint(rules, []byte(config))
+			Expect(err).NotTo(HaveOccurred())
+
+			messages := []string{}
+			for _, p := range problems {
+				messages = append(messages, p.String())
+			}
+			Expect(messages).To(ConsistOf(expected))
+		},
+		Entry("required_resource_types",
+			concourse.Lint{RequiredResourceTypes: []string{"slack", "registry-image"}},
+			"required_resource_types: resource type registry-image is not declared"),
+		Entry("forbid_privileged",
+			concourse.Lint{ForbidPrivileged: true},
+			"forbid_privileged: task unit of job build is privileged",
+			"forbid_privileged: resource type slack is privileged"),
+		Entry("job_name_pattern",
+			concourse.Lint{JobNamePattern: "^[a-z-]+$"},
+			"job_name_pattern: job Deploy does not match ^[a-z-]+$"),
+		Entry("resource_name_pattern",
+			concourse.Lint{ResourceNamePattern: "^[a-z-]+$"},
+			"resource_name_pattern: resource Repo does not match ^[a-z-]+$"),
+		Entry("max_jobs",
+			concourse.Lint{MaxJobs: 1},
+			"max_jobs: 2 jobs, more than 1"),
+		Entry("max_jobs not reached",
+			concourse.Lint{MaxJobs: 2}),
+	)
+
+	It("checks rules other than those configured", func() {
+		problems, err := lint.Lint([]lint.Rule{noGroups{}}, []byte(config))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(problems).To(Equal([]lint.Problem{{Rule: "no_groups", Message: "pipeline has no groups"}}))
+	})
+
+	It("fails for configs which are not YAML", func() {
+		_, err := lint.Lint(nil, []byte("jobs: ["))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+type noGroups struct{}
+
+func (noGroups) Name() string {
+	return "no_groups"
+}
+
+func (noGroups) Check(config lint.Config) []string {
+	return []string{"pipeline has no groups"}
+}
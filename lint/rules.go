@@ -0,0 +1,153 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+)
+
+// Rules returns the rules configured by the source.
+func Rules(l concourse.Lint) ([]Rule, error) {
+	var rules []Rule
+
+	if len(l.RequiredResourceTypes) > 0 {
+		rules = append(rules, requiredResourceTypes{names: l.RequiredResourceTypes})
+	}
+
+	if l.ForbidPrivileged {
+		rules = append(rules, forbidPrivileged{})
+	}
+
+	if l.JobNamePattern != "" {
+		re, err := regexp.Compile(l.JobNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("lint.job_name_pattern must be a regular expression: %v", err)
+		}
+		rules = append(rules, jobNamePattern{re: re})
+	}
+
+	if l.ResourceNamePattern != "" {
+		re, err := regexp.Compile(l.ResourceNamePattern)
+		if err != nil {
+			return nil, fmt.Errorf("lint.resource_name_pattern must be a regular expression: %v", err)
+		}
+		rules = append(rules, resourceNamePattern{re: re})
+	}
+
+	if l.MaxJobs > 0 {
+		rules = append(rules, maxJobs{max: l.MaxJobs})
+	}
+
+	return rules, nil
+}
+
+// requiredResourceTypes requires each of the resource types to be declared,
+// e.g. so that pipelines use a vetted version of a resource.
+type requiredResourceTypes struct {
+	names []string
+}
+
+func (r requiredResourceTypes) Name() string {
+	return "required_resource_types"
+}
+
+func (r requiredResourceTypes) Check(config Config) []string {
+	declared := make(map[string]bool)
+	for _, t := range config.ResourceTypes {
+		declared[t.Name] = true
+	}
+
+	var problems []string
+	for _, name := range r.names {
+		if !declared[name] {
+			problems = append(problems, fmt.Sprintf("resource type %s is not declared", name))
+		}
+	}
+
+	return problems
+}
+
+// forbidPrivileged forbids privileged tasks and resource types.
+type forbidPrivileged struct{}
+
+func (r forbidPrivileged) Name() string {
+	return "forbid_privileged"
+}
+
+func (r forbidPrivileged) Check(config Config) []string {
+	var problems []string
+
+	for _, j := range config.Jobs {
+		for _, step := range j.Steps() {
+			if task, ok := step["task"]; ok && step["privileged"] == true {
+				problems = append(problems, fmt.Sprintf("task %v of job %s is privileged", task, j.Name))
+			}
+		}
+	}
+
+	for _, t := range config.ResourceTypes {
+		if t.Privileged == true {
+			problems = append(problems, fmt.Sprintf("resource type %s is privileged", t.Name))
+		}
+	}
+
+	return problems
+}
+
+// jobNamePattern requires the names of jobs to match the pattern.
+type jobNamePattern struct {
+	re *regexp.Regexp
+}
+
+func (r jobNamePattern) Name() string {
+	return "job_name_pattern"
+}
+
+func (r jobNamePattern) Check(config Config) []string {
+	var problems []string
+	for _, j := range config.Jobs {
+		if !r.re.MatchString(j.Name) {
+			problems = append(problems, fmt.Sprintf("job %s does not match %s", j.Name, r.re))
+		}
+	}
+
+	return problems
+}
+
+// resourceNamePattern requires the names of resources to match the pattern.
+type resourceNamePattern struct {
+	re *regexp.Regexp
+}
+
+func (r resourceNamePattern) Name() string {
+	return "resource_name_pattern"
+}
+
+func (r resourceNamePattern) Check(config Config) []string {
+	var problems []string
+	for _, res := range config.Resources {
+		if !r.re.MatchString(res.Name) {
+			problems = append(problems, fmt.Sprintf("resource %s does not match %s", res.Name, r.re))
+		}
+	}
+
+	return problems
+}
+
+// maxJobs limits the number of jobs, e.g. to keep pipelines readable.
+type maxJobs struct {
+	max int
+}
+
+func (r maxJobs) Name() string {
+	return "max_jobs"
+}
+
+func (r maxJobs) Check(config Config) []string {
+	if len(config.Jobs) > r.max {
+		return []string{fmt.Sprintf("%d jobs, more than %d", len(config.Jobs), r.max)}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type jsonLogger struct {
+	sink      io.Writer
+	component string
+	fields    Fields
+}
+
+// jsonLine is a line logged by the JSON logger.
+type jsonLine struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Component string `json:"component"`
+	Message   string `json:"message"`
+	Team      string `json:"team,omitempty"`
+	Pipeline  string `json:"pipeline,omitempty"`
+}
+
+// NewJSONLogger returns a logger writing each message as a line of JSON, for
+// log aggregators to index, along with the time, the component logging it and
+// its fields.
+func NewJSONLogger(sink io.Writer, component string) Logger {
+	return &jsonLogger{
+		sink:      sink,
+		component: component,
+	}
+}
+
+func (l jsonLogger) Debugf(format string, a ...interface{}) (int, error) {
+	return l.log(LevelDebug, format, a...)
+}
+
+func (l jsonLogger) Infof(format string, a ...interface{}) (int, error) {
+	return l.log(LevelInfo, format, a...)
+}
+
+func (l jsonLogger) Warnf(format string, a ...interface{}) (int, error) {
+	return l.log(LevelWarn, format, a...)
+}
+
+func (l jsonLogger) Errorf(format string, a ...interface{}) (int, error) {
+	return l.log(LevelError, format, a...)
+}
+
+func (l jsonLogger) log(level Level, format string, a ...interface{}) (int, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(jsonLine{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     level.String(),
+		Component: l.component,
+		Message:   strings.TrimRight(fmt.Sprintf(format, a...), "\n"),
+		Team:      l.fields.Team,
+		Pipeline:  l.fields.Pipeline,
+	})
+	if err != nil {
+		// Untested as the line always encodes
+		return 0, err
+	}
+
+	return l.sink.Write(buf.Bytes())
+}
+
+func (l *jsonLogger) With(fields Fields) Logger {
+	with := *l
+	if fields.Team != "" {
+		with.fields.Team = fields.Team
+	}
+	if fields.Pipeline != "" {
+		with.fields.Pipeline = fields.Pipeline
+	}
+
+	return &with
+}
+
+// JSONSanitized returns the values to sanitize along with each of them as it
+// is escaped within a JSON string, so that they are sanitized from the output
+// of the JSON logger too.
+func JSONSanitized(sanitized map[string]string) map[string]string {
+	s := make(map[string]string, len(sanitized))
+	for value, replacement := range sanitized {
+		s[value] = replacement
+
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.Encode(value)
+
+		escaped := strings.TrimSuffix(buf.String(), "\n")
+		s[escaped[1:len(escaped)-1]] = replacement
+	}
+
+	return s
+}
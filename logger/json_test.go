@@ -0,0 +1,91 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON Logger", func() {
+	var (
+		sink *bytes.Buffer
+		l    logger.Logger
+	)
+
+	BeforeEach(func() {
+		sink = &bytes.Buffer{}
+		l = logger.NewJSONLogger(sink, "check")
+	})
+
+	lines := func() []map[string]interface{} {
+		var result []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSuffix(sink.String(), "\n"), "\n") {
+			var fields map[string]interface{}
+			err := json.Unmarshal([]byte(line), &fields)
+			Expect(err).NotTo(HaveOccurred())
+			result = append(result, fields)
+		}
+		return result
+	}
+
+	It("logs each message as a line of JSON", func() {
+		_, err := l.Debugf("found pipelines: %s\n", "<a> & <b>")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = l.Debugf("line one\nline two\n")
+		Expect(err).NotTo(HaveOccurred())
+
+		logged := lines()
+		Expect(logged).To(HaveLen(2))
+		Expect(logged[0]).To(HaveKeyWithValue("level", "debug"))
+		Expect(logged[0]).To(HaveKeyWithValue("component", "check"))
+		Expect(logged[0]).To(HaveKeyWithValue("message", "found pipelines: <a> & <b>"))
+		Expect(logged[0]).NotTo(HaveKey("team"))
+		Expect(logged[0]).NotTo(HaveKey("pipeline"))
+		Expect(logged[1]).To(HaveKeyWithValue("message", "line one\nline two"))
+
+		timestamp, err := time.Parse(time.RFC3339Nano, logged[0]["timestamp"].(string))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamp).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("logs the level of each line", func() {
+		l.Infof("some info")
+		l.Warnf("some warning")
+		l.Errorf("some error")
+
+		logged := lines()
+		Expect(logged[0]).To(HaveKeyWithValue("level", "info"))
+		Expect(logged[1]).To(HaveKeyWithValue("level", "warn"))
+		Expect(logged[2]).To(HaveKeyWithValue("level", "error"))
+	})
+
+	It("logs the fields it is given", func() {
+		teamLogger := l.With(logger.Fields{Team: "some-team"})
+		_, err := teamLogger.With(logger.Fields{Pipeline: "some-pipeline"}).Debugf("some message")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = teamLogger.Debugf("some other message")
+		Expect(err).NotTo(HaveOccurred())
+
+		logged := lines()
+		Expect(logged[0]).To(HaveKeyWithValue("team", "some-team"))
+		Expect(logged[0]).To(HaveKeyWithValue("pipeline", "some-pipeline"))
+		Expect(logged[1]).To(HaveKeyWithValue("team", "some-team"))
+		Expect(logged[1]).NotTo(HaveKey("pipeline"))
+	})
+})
+
+var _ = Describe("JSONSanitized", func() {
+	It("adds each value as it is escaped in JSON", func() {
+		Expect(logger.JSONSanitized(map[string]string{
+			`pass"word<>`: "***REDACTED***",
+		})).To(Equal(map[string]string{
+			`pass"word<>`:  "***REDACTED***",
+			`pass\"word<>`: "***REDACTED***",
+		}))
+	})
+})
@@ -0,0 +1,154 @@
+package logger
+
+import "fmt"
+
+// Level is the severity of a logged line.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of the given name: debug, info, warn or error.
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if n == name {
+			return Level(i), nil
+		}
+	}
+
+	return LevelDebug, fmt.Errorf("must be one of debug, info, warn or error: %s", name)
+}
+
+type thresholdLogger struct {
+	logger    Logger
+	threshold Level
+}
+
+// Threshold returns a logger which drops the lines below the threshold, and
+// logs the others with the given logger.
+func Threshold(logger Logger, threshold Level) Logger {
+	return &thresholdLogger{
+		logger:    logger,
+		threshold: threshold,
+	}
+}
+
+func (l thresholdLogger) Debugf(format string, a ...interface{}) (int, error) {
+	if l.threshold > LevelDebug {
+		return 0, nil
+	}
+	return l.logger.Debugf(format, a...)
+}
+
+func (l thresholdLogger) Infof(format string, a ...interface{}) (int, error) {
+	if l.threshold > LevelInfo {
+		return 0, nil
+	}
+	return l.logger.Infof(format, a...)
+}
+
+func (l thresholdLogger) Warnf(format string, a ...interface{}) (int, error) {
+	if l.threshold > LevelWarn {
+		return 0, nil
+	}
+	return l.logger.Warnf(format, a...)
+}
+
+func (l thresholdLogger) Errorf(format string, a ...interface{}) (int, error) {
+	return l.logger.Errorf(format, a...)
+}
+
+func (l *thresholdLogger) With(fields Fields) Logger {
+	return Threshold(l.logger.With(fields), l.threshold)
+}
+
+type demotedLogger struct {
+	logger Logger
+}
+
+// Demote returns a logger which logs the lines at the info level at the debug
+// level instead, e.g. to leave the lines about each pipeline out of the build
+// output in quiet mode.
+func Demote(logger Logger) Logger {
+	return &demotedLogger{logger: logger}
+}
+
+func (l demotedLogger) Debugf(format string, a ...interface{}) (int, error) {
+	return l.logger.Debugf(format, a...)
+}
+
+func (l demotedLogger) Infof(format string, a ...interface{}) (int, error) {
+	return l.logger.Debugf(format, a...)
+}
+
+func (l demotedLogger) Warnf(format string, a ...interface{}) (int, error) {
+	return l.logger.Warnf(format, a...)
+}
+
+func (l demotedLogger) Errorf(format string, a ...interface{}) (int, error) {
+	return l.logger.Errorf(format, a...)
+}
+
+func (l *demotedLogger) With(fields Fields) Logger {
+	return Demote(l.logger.With(fields))
+}
+
+type teeLogger []Logger
+
+// Tee returns a logger logging each line with every one of the loggers,
+// returning the result of the first.
+func Tee(loggers ...Logger) Logger {
+	return teeLogger(loggers)
+}
+
+func (t teeLogger) each(log func(Logger) (int, error)) (int, error) {
+	var n int
+	var err error
+	for i, l := range t {
+		ln, lerr := log(l)
+		if i == 0 {
+			n, err = ln, lerr
+		}
+	}
+
+	return n, err
+}
+
+func (t teeLogger) Debugf(format string, a ...interface{}) (int, error) {
+	return t.each(func(l Logger) (int, error) { return l.Debugf(format, a...) })
+}
+
+func (t teeLogger) Infof(format string, a ...interface{}) (int, error) {
+	return t.each(func(l Logger) (int, error) { return l.Infof(format, a...) })
+}
+
+func (t teeLogger) Warnf(format string, a ...interface{}) (int, error) {
+	return t.each(func(l Logger) (int, error) { return l.Warnf(format, a...) })
+}
+
+func (t teeLogger) Errorf(format string, a ...interface{}) (int, error) {
+	return t.each(func(l Logger) (int, error) { return l.Errorf(format, a...) })
+}
+
+func (t teeLogger) With(fields Fields) Logger {
+	with := make(teeLogger, len(t))
+	for i, l := range t {
+		with[i] = l.With(fields)
+	}
+
+	return with
+}
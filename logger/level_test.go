@@ -0,0 +1,88 @@
+package logger_test
+
+import (
+	"bytes"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Levels", func() {
+	DescribeTable("parsing levels",
+		func(name string, expected logger.Level) {
+			level, err := logger.ParseLevel(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(level).To(Equal(expected))
+			Expect(level.String()).To(Equal(name))
+		},
+		Entry("debug", "debug", logger.LevelDebug),
+		Entry("info", "info", logger.LevelInfo),
+		Entry("warn", "warn", logger.LevelWarn),
+		Entry("error", "error", logger.LevelError),
+	)
+
+	It("rejects unknown levels", func() {
+		_, err := logger.ParseLevel("verbose")
+		Expect(err).To(MatchError("must be one of debug, info, warn or error: verbose"))
+	})
+
+	It("prefixes warnings and errors in text", func() {
+		sink := &bytes.Buffer{}
+		l := logger.NewLogger(sink)
+
+		l.Debugf("some debug\n")
+		l.Infof("some info\n")
+		l.Warnf("some warning\n")
+		l.Errorf("some error\n")
+
+		Expect(sink.String()).To(Equal("some debug\nsome info\nWARNING: some warning\nERROR: some error\n"))
+	})
+
+	Describe("Threshold", func() {
+		It("drops lines below the threshold", func() {
+			sink := &bytes.Buffer{}
+			l := logger.Threshold(logger.NewLogger(sink), logger.LevelWarn)
+
+			l.Debugf("some debug\n")
+			l.Infof("some info\n")
+			l.With(logger.Fields{Team: "some-team"}).Warnf("some warning\n")
+			l.Errorf("some error\n")
+
+			Expect(sink.String()).To(Equal("WARNING: some warning\nERROR: some error\n"))
+		})
+	})
+
+	Describe("Demote", func() {
+		It("logs the lines at the info level at the debug level", func() {
+			sink := &bytes.Buffer{}
+			l := logger.Demote(logger.Threshold(logger.NewLogger(sink), logger.LevelInfo))
+
+			l.Debugf("some debug\n")
+			l.Infof("some info\n")
+			l.With(logger.Fields{Pipeline: "some-pipeline"}).Infof("more info\n")
+			l.Warnf("some warning\n")
+			l.Errorf("some error\n")
+
+			Expect(sink.String()).To(Equal("WARNING: some warning\nERROR: some error\n"))
+		})
+	})
+
+	Describe("Tee", func() {
+		It("logs each line with every logger", func() {
+			all := &bytes.Buffer{}
+			errors := &bytes.Buffer{}
+			l := logger.Tee(logger.NewLogger(all), logger.Threshold(logger.NewLogger(errors), logger.LevelError))
+
+			n, err := l.Infof("some info\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(len("some info\n")))
+
+			l.With(logger.Fields{Pipeline: "some-pipeline"}).Errorf("some error\n")
+
+			Expect(all.String()).To(Equal("some info\nERROR: some error\n"))
+			Expect(errors.String()).To(Equal("ERROR: some error\n"))
+		})
+	})
+})
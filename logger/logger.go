@@ -9,6 +9,17 @@ import (
 
 type Logger interface {
 	Debugf(format string, a ...interface{}) (n int, err error)
+	Infof(format string, a ...interface{}) (n int, err error)
+	Warnf(format string, a ...interface{}) (n int, err error)
+	Errorf(format string, a ...interface{}) (n int, err error)
+	With(fields Fields) Logger
+}
+
+// Fields identify what is being logged about. They are written as fields by
+// structured loggers, and left to the messages by the text logger.
+type Fields struct {
+	Team     string
+	Pipeline string
 }
 
 type logger struct {
@@ -24,3 +35,21 @@ func NewLogger(sink io.Writer) Logger {
 func (l logger) Debugf(format string, a ...interface{}) (int, error) {
 	return fmt.Fprintf(l.sink, format, a...)
 }
+
+func (l logger) Infof(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(l.sink, format, a...)
+}
+
+// Warnf and Errorf prefix the line with its level, as the resource has
+// always done for warnings written to the build output.
+func (l logger) Warnf(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(l.sink, "WARNING: "+format, a...)
+}
+
+func (l logger) Errorf(format string, a ...interface{}) (int, error) {
+	return fmt.Fprintf(l.sink, "ERROR: "+format, a...)
+}
+
+func (l *logger) With(fields Fields) Logger {
+	return l
+}
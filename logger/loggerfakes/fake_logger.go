@@ -8,11 +8,11 @@ import (
 )
 
 type FakeLogger struct {
-	DebugfStub        func(format string, a ...interface{}) (n int, err error)
+	DebugfStub        func(string, ...interface{}) (int, error)
 	debugfMutex       sync.RWMutex
 	debugfArgsForCall []struct {
-		format string
-		a      []interface{}
+		arg1 string
+		arg2 []interface{}
 	}
 	debugfReturns struct {
 		result1 int
@@ -22,26 +22,86 @@ type FakeLogger struct {
 		result1 int
 		result2 error
 	}
+	ErrorfStub        func(string, ...interface{}) (int, error)
+	errorfMutex       sync.RWMutex
+	errorfArgsForCall []struct {
+		arg1 string
+		arg2 []interface{}
+	}
+	errorfReturns struct {
+		result1 int
+		result2 error
+	}
+	errorfReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	InfofStub        func(string, ...interface{}) (int, error)
+	infofMutex       sync.RWMutex
+	infofArgsForCall []struct {
+		arg1 string
+		arg2 []interface{}
+	}
+	infofReturns struct {
+		result1 int
+		result2 error
+	}
+	infofReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	WarnfStub        func(string, ...interface{}) (int, error)
+	warnfMutex       sync.RWMutex
+	warnfArgsForCall []struct {
+		arg1 string
+		arg2 []interface{}
+	}
+	warnfReturns struct {
+		result1 int
+		result2 error
+	}
+	warnfReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	WithStub        func(logger.Fields) logger.Logger
+	withMutex       sync.RWMutex
+	withArgsForCall []struct {
+		arg1 logger.Fields
+	}
+	withReturns struct {
+		result1 logger.Logger
+	}
+	withReturnsOnCall map[int]struct {
+		result1 logger.Logger
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeLogger) Debugf(format string, a ...interface{}) (n int, err error) {
+func (fake *FakeLogger) Debugf(arg1 string, arg2 ...interface{}) (int, error) {
+	var arg2Copy []interface{}
+	if arg2 != nil {
+		arg2Copy = make([]interface{}, len(arg2))
+		copy(arg2Copy, arg2)
+	}
 	fake.debugfMutex.Lock()
 	ret, specificReturn := fake.debugfReturnsOnCall[len(fake.debugfArgsForCall)]
 	fake.debugfArgsForCall = append(fake.debugfArgsForCall, struct {
-		format string
-		a      []interface{}
-	}{format, a})
-	fake.recordInvocation("Debugf", []interface{}{format, a})
+		arg1 string
+		arg2 []interface{}
+	}{arg1, arg2Copy})
+	stub := fake.DebugfStub
+	fakeReturns := fake.debugfReturns
+	fake.recordInvocation("Debugf", []interface{}{arg1, arg2Copy})
 	fake.debugfMutex.Unlock()
-	if fake.DebugfStub != nil {
-		return fake.DebugfStub(format, a...)
+	if stub != nil {
+		return stub(arg1, arg2...)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
 	}
-	return fake.debugfReturns.result1, fake.debugfReturns.result2
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeLogger) DebugfCallCount() int {
@@ -50,13 +110,22 @@ func (fake *FakeLogger) DebugfCallCount() int {
 	return len(fake.debugfArgsForCall)
 }
 
+func (fake *FakeLogger) DebugfCalls(stub func(string, ...interface{}) (int, error)) {
+	fake.debugfMutex.Lock()
+	defer fake.debugfMutex.Unlock()
+	fake.DebugfStub = stub
+}
+
 func (fake *FakeLogger) DebugfArgsForCall(i int) (string, []interface{}) {
 	fake.debugfMutex.RLock()
 	defer fake.debugfMutex.RUnlock()
-	return fake.debugfArgsForCall[i].format, fake.debugfArgsForCall[i].a
+	argsForCall := fake.debugfArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeLogger) DebugfReturns(result1 int, result2 error) {
+	fake.debugfMutex.Lock()
+	defer fake.debugfMutex.Unlock()
 	fake.DebugfStub = nil
 	fake.debugfReturns = struct {
 		result1 int
@@ -65,6 +134,8 @@ func (fake *FakeLogger) DebugfReturns(result1 int, result2 error) {
 }
 
 func (fake *FakeLogger) DebugfReturnsOnCall(i int, result1 int, result2 error) {
+	fake.debugfMutex.Lock()
+	defer fake.debugfMutex.Unlock()
 	fake.DebugfStub = nil
 	if fake.debugfReturnsOnCall == nil {
 		fake.debugfReturnsOnCall = make(map[int]struct {
@@ -78,11 +149,280 @@ func (fake *FakeLogger) DebugfReturnsOnCall(i int, result1 int, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeLogger) Errorf(arg1 string, arg2 ...interface{}) (int, error) {
+	var arg2Copy []interface{}
+	if arg2 != nil {
+		arg2Copy = make([]interface{}, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.errorfMutex.Lock()
+	ret, specificReturn := fake.errorfReturnsOnCall[len(fake.errorfArgsForCall)]
+	fake.errorfArgsForCall = append(fake.errorfArgsForCall, struct {
+		arg1 string
+		arg2 []interface{}
+	}{arg1, arg2Copy})
+	stub := fake.ErrorfStub
+	fakeReturns := fake.errorfReturns
+	fake.recordInvocation("Errorf", []interface{}{arg1, arg2Copy})
+	fake.errorfMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLogger) ErrorfCallCount() int {
+	fake.errorfMutex.RLock()
+	defer fake.errorfMutex.RUnlock()
+	return len(fake.errorfArgsForCall)
+}
+
+func (fake *FakeLogger) ErrorfCalls(stub func(string, ...interface{}) (int, error)) {
+	fake.errorfMutex.Lock()
+	defer fake.errorfMutex.Unlock()
+	fake.ErrorfStub = stub
+}
+
+func (fake *FakeLogger) ErrorfArgsForCall(i int) (string, []interface{}) {
+	fake.errorfMutex.RLock()
+	defer fake.errorfMutex.RUnlock()
+	argsForCall := fake.errorfArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogger) ErrorfReturns(result1 int, result2 error) {
+	fake.errorfMutex.Lock()
+	defer fake.errorfMutex.Unlock()
+	fake.ErrorfStub = nil
+	fake.errorfReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) ErrorfReturnsOnCall(i int, result1 int, result2 error) {
+	fake.errorfMutex.Lock()
+	defer fake.errorfMutex.Unlock()
+	fake.ErrorfStub = nil
+	if fake.errorfReturnsOnCall == nil {
+		fake.errorfReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.errorfReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) Infof(arg1 string, arg2 ...interface{}) (int, error) {
+	var arg2Copy []interface{}
+	if arg2 != nil {
+		arg2Copy = make([]interface{}, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.infofMutex.Lock()
+	ret, specificReturn := fake.infofReturnsOnCall[len(fake.infofArgsForCall)]
+	fake.infofArgsForCall = append(fake.infofArgsForCall, struct {
+		arg1 string
+		arg2 []interface{}
+	}{arg1, arg2Copy})
+	stub := fake.InfofStub
+	fakeReturns := fake.infofReturns
+	fake.recordInvocation("Infof", []interface{}{arg1, arg2Copy})
+	fake.infofMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLogger) InfofCallCount() int {
+	fake.infofMutex.RLock()
+	defer fake.infofMutex.RUnlock()
+	return len(fake.infofArgsForCall)
+}
+
+func (fake *FakeLogger) InfofCalls(stub func(string, ...interface{}) (int, error)) {
+	fake.infofMutex.Lock()
+	defer fake.infofMutex.Unlock()
+	fake.InfofStub = stub
+}
+
+func (fake *FakeLogger) InfofArgsForCall(i int) (string, []interface{}) {
+	fake.infofMutex.RLock()
+	defer fake.infofMutex.RUnlock()
+	argsForCall := fake.infofArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogger) InfofReturns(result1 int, result2 error) {
+	fake.infofMutex.Lock()
+	defer fake.infofMutex.Unlock()
+	fake.InfofStub = nil
+	fake.infofReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) InfofReturnsOnCall(i int, result1 int, result2 error) {
+	fake.infofMutex.Lock()
+	defer fake.infofMutex.Unlock()
+	fake.InfofStub = nil
+	if fake.infofReturnsOnCall == nil {
+		fake.infofReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.infofReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) Warnf(arg1 string, arg2 ...interface{}) (int, error) {
+	var arg2Copy []interface{}
+	if arg2 != nil {
+		arg2Copy = make([]interface{}, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.warnfMutex.Lock()
+	ret, specificReturn := fake.warnfReturnsOnCall[len(fake.warnfArgsForCall)]
+	fake.warnfArgsForCall = append(fake.warnfArgsForCall, struct {
+		arg1 string
+		arg2 []interface{}
+	}{arg1, arg2Copy})
+	stub := fake.WarnfStub
+	fakeReturns := fake.warnfReturns
+	fake.recordInvocation("Warnf", []interface{}{arg1, arg2Copy})
+	fake.warnfMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2...)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeLogger) WarnfCallCount() int {
+	fake.warnfMutex.RLock()
+	defer fake.warnfMutex.RUnlock()
+	return len(fake.warnfArgsForCall)
+}
+
+func (fake *FakeLogger) WarnfCalls(stub func(string, ...interface{}) (int, error)) {
+	fake.warnfMutex.Lock()
+	defer fake.warnfMutex.Unlock()
+	fake.WarnfStub = stub
+}
+
+func (fake *FakeLogger) WarnfArgsForCall(i int) (string, []interface{}) {
+	fake.warnfMutex.RLock()
+	defer fake.warnfMutex.RUnlock()
+	argsForCall := fake.warnfArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogger) WarnfReturns(result1 int, result2 error) {
+	fake.warnfMutex.Lock()
+	defer fake.warnfMutex.Unlock()
+	fake.WarnfStub = nil
+	fake.warnfReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) WarnfReturnsOnCall(i int, result1 int, result2 error) {
+	fake.warnfMutex.Lock()
+	defer fake.warnfMutex.Unlock()
+	fake.WarnfStub = nil
+	if fake.warnfReturnsOnCall == nil {
+		fake.warnfReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.warnfReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLogger) With(arg1 logger.Fields) logger.Logger {
+	fake.withMutex.Lock()
+	ret, specificReturn := fake.withReturnsOnCall[len(fake.withArgsForCall)]
+	fake.withArgsForCall = append(fake.withArgsForCall, struct {
+		arg1 logger.Fields
+	}{arg1})
+	stub := fake.WithStub
+	fakeReturns := fake.withReturns
+	fake.recordInvocation("With", []interface{}{arg1})
+	fake.withMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeLogger) WithCallCount() int {
+	fake.withMutex.RLock()
+	defer fake.withMutex.RUnlock()
+	return len(fake.withArgsForCall)
+}
+
+func (fake *FakeLogger) WithCalls(stub func(logger.Fields) logger.Logger) {
+	fake.withMutex.Lock()
+	defer fake.withMutex.Unlock()
+	fake.WithStub = stub
+}
+
+func (fake *FakeLogger) WithArgsForCall(i int) logger.Fields {
+	fake.withMutex.RLock()
+	defer fake.withMutex.RUnlock()
+	argsForCall := fake.withArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeLogger) WithReturns(result1 logger.Logger) {
+	fake.withMutex.Lock()
+	defer fake.withMutex.Unlock()
+	fake.WithStub = nil
+	fake.withReturns = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
+func (fake *FakeLogger) WithReturnsOnCall(i int, result1 logger.Logger) {
+	fake.withMutex.Lock()
+	defer fake.withMutex.Unlock()
+	fake.WithStub = nil
+	if fake.withReturnsOnCall == nil {
+		fake.withReturnsOnCall = make(map[int]struct {
+			result1 logger.Logger
+		})
+	}
+	fake.withReturnsOnCall[i] = struct {
+		result1 logger.Logger
+	}{result1}
+}
+
 func (fake *FakeLogger) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.debugfMutex.RLock()
-	defer fake.debugfMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
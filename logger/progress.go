@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"time"
+)
+
+// ProgressThreshold is the number of pipelines from which progress is
+// reported, as handling fewer does not take long enough to seem hung.
+const ProgressThreshold = 100
+
+// ProgressInterval is how often progress is reported.
+const ProgressInterval = 10 * time.Second
+
+// Progress reports how many of a number of pipelines have been handled, e.g.
+// "fetched 40/250 pipelines", at most once per interval and once all have
+// been, so that operators watching the build can tell it is not hung. It is
+// reported at the info level.
+type Progress struct {
+	logger   Logger
+	action   string
+	team     string
+	total    int
+	interval time.Duration
+
+	done     int
+	reported time.Time
+}
+
+// NewProgress returns the progress of handling the total number of pipelines
+// of the team, or of all teams if team is empty. Nothing is reported if the
+// total is below ProgressThreshold.
+func NewProgress(logger Logger, action string, team string, total int, interval time.Duration) *Progress {
+	return &Progress{
+		logger:   logger,
+		action:   action,
+		team:     team,
+		total:    total,
+		interval: interval,
+		reported: time.Now(),
+	}
+}
+
+// Add records that another pipeline has been handled.
+func (p *Progress) Add() {
+	p.done++
+
+	if p.total < ProgressThreshold {
+		return
+	}
+
+	if p.done < p.total && time.Since(p.reported) < p.interval {
+		return
+	}
+
+	p.reported = time.Now()
+
+	if p.team != "" {
+		p.logger.Infof("%s %d/%d pipelines of team %s\n", p.action, p.done, p.total, p.team)
+		return
+	}
+	p.logger.Infof("%s %d/%d pipelines\n", p.action, p.done, p.total)
+}
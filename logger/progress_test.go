@@ -0,0 +1,57 @@
+package logger_test
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Progress", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	It("reports the number of pipelines handled once the interval has passed", func() {
+		progress := logger.NewProgress(logger.NewLogger(buf), "fetched", "main", 250, 0)
+
+		progress.Add()
+		progress.Add()
+
+		Expect(buf.String()).To(Equal("fetched 1/250 pipelines of team main\nfetched 2/250 pipelines of team main\n"))
+	})
+
+	It("reports only once all are handled within the interval", func() {
+		progress := logger.NewProgress(logger.NewLogger(buf), "set", "", 100, time.Hour)
+
+		for i := 0; i < 99; i++ {
+			progress.Add()
+		}
+		Expect(buf.String()).To(BeEmpty())
+
+		progress.Add()
+		Expect(buf.String()).To(Equal("set 100/100 pipelines\n"))
+	})
+
+	It("reports through the logger", func() {
+		progress := logger.NewProgress(logger.NewJSONLogger(buf, "check").With(logger.Fields{Team: "main"}), "fetched", "main", 250, 0)
+
+		progress.Add()
+
+		Expect(buf.String()).To(ContainSubstring(`"level":"info","component":"check","message":"fetched 1/250 pipelines of team main","team":"main"}`))
+	})
+
+	It("reports nothing for fewer pipelines than the threshold", func() {
+		progress := logger.NewProgress(logger.NewLogger(buf), "fetched", "main", logger.ProgressThreshold-1, 0)
+
+		for i := 0; i < logger.ProgressThreshold-1; i++ {
+			progress.Add()
+		}
+
+		Expect(buf.String()).To(BeEmpty())
+	})
+})
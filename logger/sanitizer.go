@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// Sanitizer is a writer which sanitizes what is written to it.
+type Sanitizer interface {
+	io.Writer
+
+	// Flush writes anything held back by the last write.
+	Flush() error
+}
+
+type sanitizer struct {
+	sink  io.Writer
+	mutex sync.Mutex
+
+	// keys holds the values to sanitize by their first byte, longest first,
+	// so that the longest value matching at a position is replaced.
+	keys         map[byte][]string
+	replacements map[string]string
+
+	// pending holds the end of the previous writes which may be the start of
+	// a value to sanitize, until a write shows whether it is.
+	pending []byte
+}
+
+// NewSanitizer returns a writer replacing each of the keys of sanitized with
+// its value, streaming the output rather than buffering it. Values split
+// across writes are still replaced, by holding back the end of a write which
+// could be the start of a value until the next write, or Flush.
+func NewSanitizer(sanitized map[string]string, sink io.Writer) Sanitizer {
+	s := &sanitizer{
+		sink:         sink,
+		keys:         make(map[byte][]string),
+		replacements: make(map[string]string, len(sanitized)),
+	}
+
+	for k, v := range sanitized {
+		if k == "" {
+			continue
+		}
+		s.keys[k[0]] = append(s.keys[k[0]], k)
+		s.replacements[k] = v
+	}
+
+	for _, keys := range s.keys {
+		sort.Slice(keys, func(i, j int) bool {
+			if len(keys[i]) != len(keys[j]) {
+				return len(keys[i]) > len(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+	}
+
+	return s
+}
+
+func (s *sanitizer) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := p
+	if len(s.pending) > 0 {
+		data = append(s.pending, p...)
+		s.pending = nil
+	}
+
+	err := s.sanitize(data, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (s *sanitizer) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data := s.pending
+	s.pending = nil
+
+	return s.sanitize(data, true)
+}
+
+// sanitize writes the data with the values replaced. Unless final, it holds
+// back the end of the data if it is the start of a value.
+func (s *sanitizer) sanitize(data []byte, final bool) error {
+	var out []byte
+	replaced := false
+	start, end := 0, len(data)
+
+	i := 0
+scan:
+	for i < len(data) {
+		for _, k := range s.keys[data[i]] {
+			rest := data[i:]
+
+			if len(rest) < len(k) {
+				if !final && string(rest) == k[:len(rest)] {
+					s.pending = append([]byte(nil), rest...)
+					end = i
+					break scan
+				}
+				continue
+			}
+
+			if string(rest[:len(k)]) == k {
+				out = append(out, data[start:i]...)
+				out = append(out, s.replacements[k]...)
+				replaced = true
+				i += len(k)
+				start = i
+				continue scan
+			}
+		}
+
+		i++
+	}
+
+	if replaced {
+		out = append(out, data[start:end]...)
+	} else {
+		// Written as it is, rather than copied, as nothing was replaced
+		out = data[:end]
+	}
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	_, err := s.sink.Write(out)
+	return err
+}
@@ -0,0 +1,79 @@
+package logger_test
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sanitizer", func() {
+	var (
+		sink      *bytes.Buffer
+		sanitizer logger.Sanitizer
+	)
+
+	BeforeEach(func() {
+		sink = &bytes.Buffer{}
+		sanitizer = logger.NewSanitizer(map[string]string{
+			"some-password":      "***REDACTED-PASSWORD***",
+			"some-password-long": "***REDACTED-LONG-PASSWORD***",
+			"":                   "***EMPTY***",
+		}, sink)
+	})
+
+	write := func(s string) {
+		n, err := sanitizer.Write([]byte(s))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(len(s)))
+	}
+
+	It("replaces each value", func() {
+		write("login with some-password, then some-password again\n")
+
+		Expect(sink.String()).To(Equal("login with ***REDACTED-PASSWORD***, then ***REDACTED-PASSWORD*** again\n"))
+	})
+
+	It("replaces the longest value matching", func() {
+		write("some-password-long and some-password-longer\n")
+
+		Expect(sink.String()).To(Equal("***REDACTED-LONG-PASSWORD*** and ***REDACTED-LONG-PASSWORD***er\n"))
+	})
+
+	It("replaces values split across writes", func() {
+		write("login with some-pa")
+		Expect(sink.String()).To(Equal("login with "))
+
+		write("ssword\n")
+		Expect(sink.String()).To(Equal("login with ***REDACTED-PASSWORD***\n"))
+	})
+
+	It("writes what was held back once it is not a value", func() {
+		write("some-pass")
+		write("port\n")
+
+		Expect(sink.String()).To(Equal("some-passport\n"))
+	})
+
+	It("writes what was held back when flushed", func() {
+		write("ends with some-")
+
+		err := sanitizer.Flush()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sink.String()).To(Equal("ends with some-"))
+	})
+
+	It("streams large outputs", func() {
+		line := strings.Repeat("x", 1023) + "\n"
+		for i := 0; i < 4096; i++ {
+			write(line)
+		}
+		write("some-password\n")
+
+		Expect(sink.Len()).To(Equal(4096*1024 + len("***REDACTED-PASSWORD***\n")))
+		Expect(strings.HasSuffix(sink.String(), "x\n***REDACTED-PASSWORD***\n")).To(BeTrue())
+	})
+})
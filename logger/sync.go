@@ -0,0 +1,46 @@
+package logger
+
+import "sync"
+
+type syncLogger struct {
+	logger Logger
+	lock   sync.Locker
+}
+
+// Synchronized returns a logger which only logs with the given logger while
+// holding the lock, so that several goroutines can log at once, e.g. on
+// receiving a signal while a command is running.
+func Synchronized(logger Logger, lock sync.Locker) Logger {
+	return &syncLogger{
+		logger: logger,
+		lock:   lock,
+	}
+}
+
+func (l syncLogger) Debugf(format string, a ...interface{}) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.logger.Debugf(format, a...)
+}
+
+func (l syncLogger) Infof(format string, a ...interface{}) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.logger.Infof(format, a...)
+}
+
+func (l syncLogger) Warnf(format string, a ...interface{}) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.logger.Warnf(format, a...)
+}
+
+func (l syncLogger) Errorf(format string, a ...interface{}) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.logger.Errorf(format, a...)
+}
+
+func (l *syncLogger) With(fields Fields) Logger {
+	return Synchronized(l.logger.With(fields), l.lock)
+}
@@ -0,0 +1,36 @@
+package logger_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synchronized", func() {
+	It("logs whole lines when logging from several goroutines", func() {
+		sink := &bytes.Buffer{}
+		l := logger.Synchronized(logger.NewLogger(sink), &sync.Mutex{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					l.With(logger.Fields{Team: "some-team"}).Infof("line %d\n", i)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		lines := strings.Split(strings.TrimSuffix(sink.String(), "\n"), "\n")
+		Expect(lines).To(HaveLen(1000))
+		for _, line := range lines {
+			Expect(line).To(MatchRegexp(`^line \d$`))
+		}
+	})
+})
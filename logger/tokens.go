@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"io"
+	"regexp"
+)
+
+var (
+	bearerTokenRegexp = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+	// The quotes may be escaped, e.g. by the JSON logger.
+	oauthTokenRegexp = regexp.MustCompile(`(\\?"(?:access_token|id_token|refresh_token)\\?"\s*:\s*\\?")[^"\\]*`)
+)
+
+type tokenSanitizer struct {
+	sink io.Writer
+}
+
+// NewTokenSanitizer returns a writer redacting bearer tokens and the tokens of
+// OAuth responses, such as those obtained by fly when logging in, which
+// cannot be sanitized by value as they are not known in advance. Each write is
+// sanitized on its own, so tokens split across writes are not redacted.
+func NewTokenSanitizer(sink io.Writer) io.Writer {
+	return &tokenSanitizer{
+		sink: sink,
+	}
+}
+
+func (t tokenSanitizer) Write(p []byte) (int, error) {
+	sanitized := bearerTokenRegexp.ReplaceAll(p, []byte("${1}***REDACTED-BEARER-TOKEN***"))
+	sanitized = oauthTokenRegexp.ReplaceAll(sanitized, []byte("${1}***REDACTED-TOKEN***"))
+
+	_, err := t.sink.Write(sanitized)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
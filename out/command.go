@@ -1,14 +1,20 @@
 package out
 
 import (
-	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/configschema"
 	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/lint"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 )
 
@@ -20,134 +26,492 @@ type Command struct {
 	logger     logger.Logger
 	flyCommand fly.Command
 	sourcesDir string
+	version    string
+	timings    *concourse.Timings
+	warnings   *concourse.Warnings
+
+	// audit receives an AuditEntry, as a line of JSON, for each action which
+	// changes a pipeline. If nil, the actions are only logged.
+	audit io.Writer
 }
 
 func NewCommand(
 	logger logger.Logger,
 	flyCommand fly.Command,
 	sourcesDir string,
+	version string,
+	timings *concourse.Timings,
+	warnings *concourse.Warnings,
+	audit io.Writer,
 ) *Command {
 	return &Command{
 		logger:     logger,
 		flyCommand: flyCommand,
 		sourcesDir: sourcesDir,
+		version:    version,
+		timings:    timings,
+		warnings:   warnings,
+		audit:      audit,
 	}
 }
 
 func (c *Command) Run(input concourse.OutRequest) (concourse.OutResponse, error) {
 	c.logger.Debugf("Received input: %+v\n", input)
 
-	insecure := false
-	if input.Source.Insecure != "" {
-		var err error
-		insecure, err = strconv.ParseBool(input.Source.Insecure)
-		if err != nil {
-			return concourse.OutResponse{}, err
-		}
-	}
-
-	teams := make(map[string]concourse.Team)
+	targets := make(map[string]concourse.Target)
 
-	for _, team := range input.Source.Teams {
-		teams[team.Name] = team
+	for _, target := range input.Source.AllTargets() {
+		targets[target.Name] = target
 	}
 
 	pipelines := input.Params.Pipelines
 
 	c.logger.Debugf("Input pipelines: %+v\n", pipelines)
 
-	c.logger.Debugf("Setting pipelines\n")
 	for _, p := range pipelines {
-		team, found := teams[p.TeamName]
-		if !found {
-			return concourse.OutResponse{}, fmt.Errorf("team (%s) configuration not found for pipeline (%s)", p.TeamName, p.Name)
+		if !input.Source.IncludesPipeline(p.Name) {
+			return concourse.OutResponse{}, fmt.Errorf("pipeline (%s) is not included by the include and exclude lists of the source", p.Name)
 		}
 
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			p.TeamName,
-			team.Username,
-			team.Password,
-			insecure,
-		)
+		team := teamsByName(targets[p.Target].Teams)[p.TeamName]
+		if !team.IncludesPipeline(p.Name) {
+			return concourse.OutResponse{}, fmt.Errorf("pipeline (%s) is not one of the pipelines of team (%s)", p.Name, p.TeamName)
+		}
+	}
+
+	// checksums holds the checksum of each pipeline once set, keyed by
+	// pipelineKey, so that it need not be fetched again for the version.
+	checksums := make(map[string]string)
+
+	var lintRules []lint.Rule
+	if input.Source.Lint != nil {
+		var err error
+		lintRules, err = lint.Rules(*input.Source.Lint)
 		if err != nil {
 			return concourse.OutResponse{}, err
 		}
+	}
 
-		c.logger.Debugf("Login successful\n")
+	var errs concourse.PipelineErrors
 
-		configFilepath := filepath.Join(c.sourcesDir, p.ConfigFile)
+	// In a dry run, the changes to each pipeline are only logged, and those
+	// which would change are listed in the metadata.
+	action := "set"
+	toChange := []string{}
+	var sanitized map[string]string
+	if input.Params.DryRun {
+		action = "diffed"
+		sanitized = concourse.SanitizedOutRequest(input)
+		c.logger.Infof("Diffing pipelines (dry run)\n")
+	} else {
+		c.logger.Infof("Setting pipelines\n")
+	}
 
-		var varsFilepaths []string
-		for _, v := range p.VarsFiles {
-			varFilepath := filepath.Join(c.sourcesDir, v)
-			varsFilepaths = append(varsFilepaths, varFilepath)
+	progress := logger.NewProgress(c.logger, action, "", len(pipelines), logger.ProgressInterval)
+	for _, p := range pipelines {
+		pipelineLogger := c.logger.With(logger.Fields{Team: p.TeamName, Pipeline: p.Name})
+		if input.Source.Quiet {
+			pipelineLogger = logger.Demote(pipelineLogger)
 		}
 
-		var setOutput []byte
-		setOutput, err = c.flyCommand.SetPipeline(p.Name, configFilepath, varsFilepaths, p.Vars)
-		c.logger.Debugf("pipeline '%s' set; output:\n\n%s\n", p.Name, string(setOutput))
-		fmt.Fprintf(os.Stderr, "pipeline '%s' set; output:\n\n%s\n", p.Name, string(setOutput))
-		if err != nil {
-			return concourse.OutResponse{}, err
+		target, found := targets[p.Target]
+		if !found {
+			errs.Add(p.Target, p.TeamName, p.Name, fmt.Errorf("target (%s) configuration not found for pipeline (%s)", p.Target, p.Name))
+			progress.Add()
+			continue
 		}
 
-		if p.Exposed {
-			_, err = c.flyCommand.ExposePipeline(p.Name)
+		if input.Params.ValidateSchema || len(lintRules) > 0 {
+			err := c.checkConfig(p, input.Params.ValidateSchema, lintRules, input.Source.Lint.Enforced(), pipelineLogger)
 			if err != nil {
-				return concourse.OutResponse{}, err
+				pipelineLogger.Errorf("Not setting pipeline %s: %v\n", p.Name, err)
+				errs.Add(target.Name, p.TeamName, p.Name, err)
+				progress.Add()
+				continue
 			}
 		}
 
-		if p.Unpaused {
-			_, err = c.flyCommand.UnpausePipeline(p.Name)
+		if input.Params.DryRun {
+			changed, err := c.diffPipeline(input.Source, target, p, sanitized, pipelineLogger)
+			progress.Add()
 			if err != nil {
-				return concourse.OutResponse{}, err
+				pipelineLogger.Errorf("Failed to diff pipeline %s: %v\n", p.Name, err)
+				errs.Add(target.Name, p.TeamName, p.Name, err)
+				continue
+			}
+			if changed {
+				toChange = append(toChange, concourse.PipelineVersion{Target: target.Name, Team: p.TeamName, Pipeline: p.Name}.Key())
 			}
+			continue
 		}
+
+		checksum, err := c.setPipeline(input.Source, target, p, pipelineLogger)
+		progress.Add()
+		if err != nil {
+			pipelineLogger.Errorf("Failed to set pipeline %s: %v\n", p.Name, err)
+			errs.Add(target.Name, p.TeamName, p.Name, err)
+			continue
+		}
+		checksums[pipelineKey(target.Name, p.TeamName, p.Name)] = checksum
+	}
+	if input.Params.DryRun {
+		c.logger.Infof("Dry run complete: %d of %d pipelines would change\n", len(toChange), len(pipelines))
+	} else {
+		c.logger.Infof("Setting pipelines complete: %d of %d pipelines set\n", len(checksums), len(pipelines))
 	}
-	c.logger.Debugf("Setting pipelines complete\n")
 
-	pipelineVersions := make(map[string]string)
+	err := errs.Err()
+	if err != nil {
+		return concourse.OutResponse{}, err
+	}
+
+	var pipelineVersions []concourse.PipelineVersion
+	var metadata []concourse.MetadataEntry
 
-	for teamName, team := range teams {
-		c.logger.Debugf("Performing login\n")
-		_, err := c.flyCommand.Login(
-			input.Source.Target,
-			teamName,
-			team.Username,
-			team.Password,
-			insecure,
-		)
+	// In the order of the source, so that the metadata is the same for each put.
+	for _, target := range input.Source.AllTargets() {
+		insecure, err := target.InsecureSkipVerify()
 		if err != nil {
 			return concourse.OutResponse{}, err
 		}
 
-		c.logger.Debugf("Login successful\n")
-
-		for _, pipeline := range pipelines {
-			if pipeline.TeamName != teamName {
+		byName := teamsByName(target.Teams)
+		seen := make(map[string]bool)
+		for _, t := range target.Teams {
+			if seen[t.Name] {
 				continue
 			}
-			c.logger.Debugf("Getting pipeline: %s\n", pipeline.Name)
-			outBytes, err := c.flyCommand.GetPipeline(pipeline.Name)
+			seen[t.Name] = true
+
+			teamName, team := t.Name, byName[t.Name]
+			teamLogger := c.logger.With(logger.Fields{Team: teamName})
+			teamLogger.Debugf("Performing login\n")
+			_, err := fly.LoginToTeam(
+				c.flyCommand,
+				target.APIEndpoint(),
+				team,
+				insecure,
+			)
 			if err != nil {
 				return concourse.OutResponse{}, err
 			}
 
-			version := fmt.Sprintf(
-				"%x",
-				md5.Sum(outBytes),
-			)
-			pipelineVersions[pipeline.Name] = version
+			teamLogger.Debugf("Login successful\n")
+
+			for _, pipeline := range pipelines {
+				pipelineLogger := teamLogger.With(logger.Fields{Pipeline: pipeline.Name})
+				if pipeline.Target != target.Name || pipeline.TeamName != teamName {
+					continue
+				}
+				checksum, ok := checksums[pipelineKey(target.Name, teamName, pipeline.Name)]
+				if !ok {
+					pipelineLogger.Debugf("Getting pipeline: %s\n", pipeline.Name)
+					checksum, err = fly.PipelineChecksum(c.flyCommand, input.Source, fly.PipelineRef{Name: pipeline.Name}, c.timings)
+					// Pipelines which a dry run would create are not in the version
+					if input.Params.DryRun && errors.Is(err, fly.ErrNotFound) {
+						continue
+					}
+					if err != nil {
+						return concourse.OutResponse{}, err
+					}
+				}
+
+				pipelineVersions = append(pipelineVersions, concourse.PipelineVersion{
+					Target:   target.Name,
+					Team:     teamName,
+					Pipeline: pipeline.Name,
+					Checksum: checksum,
+				})
+
+				metadata = append(metadata, target.PipelineMetadata(
+					teamName,
+					pipeline.Name,
+					nil,
+					"",
+					target.PipelineURL(teamName, pipeline.Name, nil),
+				))
+			}
+		}
+
+		info, err := c.flyCommand.Info()
+		if err != nil {
+			return concourse.OutResponse{}, err
+		}
+
+		metadata = append(metadata, target.Metadata("concourse_version", info.Version))
+		if info.ClusterName != "" {
+			metadata = append(metadata, target.Metadata("cluster_name", info.ClusterName))
+		}
+
+		flyVersion, err := c.flyCommand.FlyVersion()
+		if err != nil {
+			return concourse.OutResponse{}, err
 		}
+
+		metadata = append(metadata, target.Metadata("fly_version", flyVersion))
+	}
+
+	if input.Params.DryRun {
+		metadata = append(metadata, concourse.NewMetadataEntry("pipelines to change", toChange))
+	}
+
+	metadata = append(metadata, c.warnings.MetadataEntries()...)
+
+	timings := c.timings.MetadataEntries()
+	for _, e := range timings {
+		m := e.Metadata()
+		c.logger.Debugf("%s: %s\n", m.Name, m.Value)
+	}
+	metadata = append(metadata, timings...)
+
+	metadata = append(metadata, concourse.NewMetadataEntry("resource_version", c.version))
+
+	// out has no directory to write the metadata document to, unlike in.
+	document, err := json.Marshal(concourse.NewMetadataDocument(metadata))
+	if err != nil {
+		// Untested as the document always marshals
+		return concourse.OutResponse{}, err
+	}
+
+	version := input.Source.Emitted(concourse.NewVersion(pipelineVersions))
+	if input.Source.VersionSequence {
+		version = version.WithSequence(nil, time.Now())
 	}
 
 	response := concourse.OutResponse{
-		Version:  pipelineVersions,
-		Metadata: []concourse.Metadata{},
+		Version: version,
+		Metadata: append(
+			concourse.MetadataOf(metadata),
+			concourse.Metadata{Name: "metadata.json", Value: string(document)},
+		),
 	}
 
 	return response, nil
 }
+
+// checkConfig returns an error listing each field of the config of the
+// pipeline which does not match the schema of pipeline configs, if it is
+// validated, or else each lint rule it breaks, if they are enforced. Lint
+// rules which are not enforced are only warned of.
+func (c *Command) checkConfig(p concourse.Pipeline, validateSchema bool, rules []lint.Rule, enforced bool, pipelineLogger logger.Logger) error {
+	config, err := ioutil.ReadFile(filepath.Join(c.sourcesDir, p.ConfigFile))
+	if err != nil {
+		return err
+	}
+
+	if validateSchema {
+		problems, err := configschema.Validate(config)
+		if err != nil {
+			return fmt.Errorf("validating config: %v", err)
+		}
+
+		if len(problems) > 0 {
+			return fmt.Errorf("config does not match the schema:\n    - %s", strings.Join(problems, "\n    - "))
+		}
+	}
+
+	problems, err := lint.Lint(rules, config)
+	if err != nil {
+		return fmt.Errorf("linting config: %v", err)
+	}
+
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.String()
+	}
+
+	if enforced && len(messages) > 0 {
+		return fmt.Errorf("config breaks lint rules:\n    - %s", strings.Join(messages, "\n    - "))
+	}
+
+	for _, m := range messages {
+		pipelineLogger.Warnf("Config breaks lint rule %s\n", m)
+		c.warnings.Add("pipeline %s of team %s: %s", p.Name, p.TeamName, m)
+	}
+
+	return nil
+}
+
+// setPipeline sets the pipeline on the target, exposing and unpausing it if
+// asked to, and returns the checksum of its config once set.
+func (c *Command) setPipeline(source concourse.Source, target concourse.Target, p concourse.Pipeline, pipelineLogger logger.Logger) (string, error) {
+	configFilepath, varsFilepaths, cleanup, err := c.preparePipeline(source, target, p, pipelineLogger)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	ref := fly.PipelineRef{Name: p.Name}
+
+	action := concourse.AuditActionUpdate
+	previousChecksum, err := fly.PipelineChecksum(c.flyCommand, source, ref, c.timings)
+	if errors.Is(err, fly.ErrNotFound) {
+		action = concourse.AuditActionCreate
+	} else if err != nil {
+		return "", concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
+	}
+
+	var setOutput []byte
+	stopSet := c.timings.Start(concourse.PhaseSet)
+	setOutput, err = c.flyCommand.SetPipeline(p.Name, configFilepath, varsFilepaths, p.Vars)
+	pipelineLogger.Debugf("Set in %s\n", stopSet())
+	pipelineLogger.Infof("pipeline '%s' set; output:\n\n%s\n", p.Name, string(setOutput))
+	if errors.Is(err, fly.ErrVersionMismatch) {
+		return "", concourse.WithErrorCode(concourse.ErrorCodeVersionMismatch, err)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	for _, w := range fly.ConfigWarnings(setOutput) {
+		c.warnings.Add("pipeline %s of team %s: %s", p.Name, p.TeamName, w)
+	}
+
+	checksum, err := fly.PipelineChecksum(c.flyCommand, source, ref, c.timings)
+	if err != nil {
+		return "", concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
+	}
+
+	entry := concourse.AuditEntry{
+		Action:   action,
+		Target:   target.Name,
+		Team:     p.TeamName,
+		Pipeline: p.Name,
+		Checksum: checksum,
+	}
+	if action == concourse.AuditActionUpdate {
+		entry.PreviousChecksum = previousChecksum
+	}
+	err = c.recordAudit(pipelineLogger, entry)
+	if err != nil {
+		return "", err
+	}
+
+	if p.Exposed {
+		err = c.flyCommand.ExposePipeline(ref)
+		if err != nil {
+			return "", err
+		}
+
+		entry.Action = concourse.AuditActionExpose
+		entry.PreviousChecksum = ""
+		err = c.recordAudit(pipelineLogger, entry)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if p.Unpaused {
+		err = c.flyCommand.UnpausePipeline(ref)
+		if err != nil {
+			return "", err
+		}
+
+		entry.Action = concourse.AuditActionUnpause
+		entry.PreviousChecksum = ""
+		err = c.recordAudit(pipelineLogger, entry)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return checksum, nil
+}
+
+// preparePipeline logs in to the team of the pipeline on the target, and
+// returns the paths of the config and vars files of the pipeline, including
+// a file holding the vars of the team, which cleanup removes.
+func (c *Command) preparePipeline(source concourse.Source, target concourse.Target, p concourse.Pipeline, pipelineLogger logger.Logger) (string, []string, func(), error) {
+	insecure, err := target.InsecureSkipVerify()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	team, found := teamsByName(target.Teams)[p.TeamName]
+	if !found {
+		return "", nil, nil, fmt.Errorf("team (%s) configuration not found for pipeline (%s)", p.TeamName, p.Name)
+	}
+
+	pipelineLogger.Debugf("Performing login\n")
+	_, err = fly.LoginToTeam(
+		c.flyCommand,
+		target.APIEndpoint(),
+		team,
+		insecure,
+	)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	pipelineLogger.Debugf("Login successful\n")
+
+	configFilepath := filepath.Join(c.sourcesDir, p.ConfigFile)
+
+	cleanup := func() {}
+	var varsFilepaths []string
+	if len(team.Vars) > 0 {
+		teamVarsFilepath, err := writeTeamVars(source.WorkDir, team.Vars)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		cleanup = func() { os.Remove(teamVarsFilepath) }
+
+		varsFilepaths = append(varsFilepaths, teamVarsFilepath)
+	}
+
+	for _, v := range p.VarsFiles {
+		varFilepath := filepath.Join(c.sourcesDir, v)
+		varsFilepaths = append(varsFilepaths, varFilepath)
+	}
+
+	return configFilepath, varsFilepaths, cleanup, nil
+}
+
+// recordAudit logs the entry, timestamped now, and writes it to the audit
+// log.
+func (c *Command) recordAudit(pipelineLogger logger.Logger, entry concourse.AuditEntry) error {
+	entry.Time = time.Now().UTC()
+
+	pipelineLogger.Infof("Audit: %s pipeline '%s' of team '%s' (checksum: %s)\n", entry.Action, entry.Pipeline, entry.Team, entry.Checksum)
+
+	if c.audit == nil {
+		return nil
+	}
+
+	return json.NewEncoder(c.audit).Encode(entry)
+}
+
+// pipelineKey identifies a pipeline of a team of a target.
+func pipelineKey(targetName string, teamName string, pipelineName string) string {
+	return targetName + "/" + teamName + "/" + pipelineName
+}
+
+// writeTeamVars writes the vars of a team to a file in the work dir, or else
+// the temp dir, which is passed to fly before the vars files of the pipeline
+// so that they can override it. The caller must remove the file.
+func writeTeamVars(workDir string, vars map[string]interface{}) (string, error) {
+	file, err := ioutil.TempFile(workDir, "team-vars")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// JSON is valid YAML, and unlike YAML can encode any decoded vars.
+	err = json.NewEncoder(file).Encode(vars)
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+func teamsByName(teams []concourse.Team) map[string]concourse.Team {
+	byName := make(map[string]concourse.Team)
+
+	for _, team := range teams {
+		byName[team.Name] = team
+	}
+
+	return byName
+}
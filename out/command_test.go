@@ -1,12 +1,17 @@
 package out_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
 	"github.com/concourse/concourse-pipeline-resource/fly/flyfakes"
 	"github.com/concourse/concourse-pipeline-resource/logger"
 	"github.com/concourse/concourse-pipeline-resource/out"
@@ -40,10 +45,17 @@ var _ = Describe("Out", func() {
 		command       *out.Command
 
 		fakeFlyCommand *flyfakes.FakeCommand
+		timings        *concourse.Timings
+		audit          *bytes.Buffer
 	)
 
 	BeforeEach(func() {
 		fakeFlyCommand = &flyfakes.FakeCommand{}
+		timings = nil
+		audit = &bytes.Buffer{}
+		fakeFlyCommand.UserInfoReturns(fly.UserInfo{IsAdmin: true}, nil)
+		fakeFlyCommand.FlyVersionReturns("7.9.1", nil)
+		fakeFlyCommand.InfoReturns(fly.Info{Version: "7.9.1", ClusterName: "some-cluster"}, nil)
 
 		var err error
 		sourcesDir, err = ioutil.TempDir("", "")
@@ -164,7 +176,7 @@ pipeline3: foo
 
 		ginkgoLogger = logger.NewLogger(sanitizer)
 
-		command = out.NewCommand(ginkgoLogger, fakeFlyCommand, sourcesDir)
+		command = out.NewCommand(ginkgoLogger, fakeFlyCommand, sourcesDir, "1.2.3", timings, concourse.NewWarnings(), audit)
 	})
 
 	AfterEach(func() {
@@ -193,8 +205,8 @@ pipeline3: foo
 
 			// the second pipeline has Unpaused and Exposed set to true
 			if i == 1 {
-				name := fakeFlyCommand.UnpausePipelineArgsForCall(0)
-				Expect(name).To(Equal(p.Name))
+				ref := fakeFlyCommand.UnpausePipelineArgsForCall(0)
+				Expect(ref.Name).To(Equal(p.Name))
 				Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(1))
 				Expect(fakeFlyCommand.ExposePipelineCallCount()).To(Equal(1))
 			}
@@ -212,7 +224,7 @@ pipeline3: foo
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Version[apiPipelines[0]]).To(Equal("4f4bd60b18bf697cc68dac9cb95537c2"))
+		Expect(response.Version["main/"+apiPipelines[0]]).To(Equal("4f4bd60b18bf697cc68dac9cb95537c2"))
 	})
 
 	It("returns metadata", func() {
@@ -220,7 +232,304 @@ pipeline3: foo
 
 		Expect(err).NotTo(HaveOccurred())
 
-		Expect(response.Metadata).NotTo(BeNil())
+		Expect(response.Metadata[:len(response.Metadata)-1]).To(ConsistOf([]concourse.Metadata{
+			{Name: "main/pipeline-1", Value: "some target/teams/main/pipelines/pipeline-1"},
+			{Name: "main/pipeline-2", Value: "some target/teams/main/pipelines/pipeline-2"},
+			{Name: "some-other-team/pipeline-3", Value: "some target/teams/some-other-team/pipelines/pipeline-3"},
+			{Name: "concourse_version", Value: "7.9.1"},
+			{Name: "cluster_name", Value: "some-cluster"},
+			{Name: "fly_version", Value: "7.9.1"},
+			{Name: "resource_version", Value: "1.2.3"},
+		}))
+	})
+
+	It("returns the metadata as a document", func() {
+		response, err := command.Run(outRequest)
+		Expect(err).NotTo(HaveOccurred())
+
+		last := response.Metadata[len(response.Metadata)-1]
+		Expect(last.Name).To(Equal("metadata.json"))
+
+		var document concourse.MetadataDocument
+		err = json.Unmarshal([]byte(last.Value), &document)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(document.SchemaVersion).To(Equal(1))
+		Expect(document.Entries).To(HaveLen(len(response.Metadata) - 1))
+		Expect(document.Entries).To(ContainElement(concourse.MetadataEntry{
+			Name:     "main/pipeline-1",
+			Type:     "string",
+			Value:    "some target/teams/main/pipelines/pipeline-1",
+			Team:     "main",
+			Pipeline: "pipeline-1",
+		}))
+	})
+
+	Context("when several teams are configured", func() {
+		BeforeEach(func() {
+			outRequest.Source.Teams = nil
+			outRequest.Params.Pipelines = nil
+			for _, name := range []string{"team-e", "team-b", "team-d", "team-a", "team-c"} {
+				outRequest.Source.Teams = append(outRequest.Source.Teams, concourse.Team{Name: name, Username: username, Password: password})
+				outRequest.Params.Pipelines = append(outRequest.Params.Pipelines, concourse.Pipeline{Name: "pipeline", ConfigFile: "pipeline.yml", TeamName: name})
+			}
+
+			fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+				return []byte(pipelineContents[0]), nil
+			}
+		})
+
+		It("returns the metadata of the pipelines in the order of the teams", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, m := range response.Metadata {
+				if strings.HasSuffix(m.Name, "/pipeline") {
+					names = append(names, m.Name)
+				}
+			}
+
+			Expect(names).To(Equal([]string{"team-e/pipeline", "team-b/pipeline", "team-d/pipeline", "team-a/pipeline", "team-c/pipeline"}))
+		})
+	})
+
+	Context("when logging as JSON", func() {
+		var logged *bytes.Buffer
+
+		JustBeforeEach(func() {
+			logged = &bytes.Buffer{}
+			fakeFlyCommand.SetPipelineReturns([]byte("jobs:\n  job some-job has changed\n"), nil)
+
+			command = out.NewCommand(logger.NewJSONLogger(logged, "out"), fakeFlyCommand, sourcesDir, "1.2.3", timings, concourse.NewWarnings(), audit)
+		})
+
+		It("logs the output of setting each pipeline once, as JSON", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(strings.Count(logged.String(), "job some-job has changed")).To(Equal(len(pipelines)))
+			Expect(logged.String()).To(ContainSubstring(`"message":"pipeline 'pipeline-1' set; output:\n\njobs:\n  job some-job has changed","team":"main","pipeline":"pipeline-1"}`))
+		})
+
+		It("logs how many pipelines were set", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logged.String()).To(ContainSubstring(`"level":"info","component":"out","message":"Setting pipelines complete: 3 of 3 pipelines set"}`))
+		})
+
+		Context("when quiet is set", func() {
+			BeforeEach(func() {
+				outRequest.Source.Quiet = true
+			})
+
+			It("logs the output of setting each pipeline at the debug level", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logged.String()).To(ContainSubstring(`"level":"debug","component":"out","message":"pipeline 'pipeline-1' set; output:`))
+				Expect(logged.String()).NotTo(ContainSubstring(`"level":"info","component":"out","message":"pipeline 'pipeline-1' set; output:`))
+			})
+
+			It("logs the audit of each pipeline at the debug level", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logged.String()).To(ContainSubstring(`"level":"debug","component":"out","message":"Audit: `))
+				Expect(logged.String()).NotTo(ContainSubstring(`"level":"info","component":"out","message":"Audit: `))
+			})
+		})
+	})
+
+	Context("when dry_run is set", func() {
+		var logged *bytes.Buffer
+
+		BeforeEach(func() {
+			outRequest.Params.DryRun = true
+			outRequest.Source.SecretVars = []string{"token"}
+			outRequest.Params.Pipelines[0].Vars = map[string]interface{}{"token": "p@ss: w'rd"}
+
+			fakeFlyCommand.RenderPipelineStub = func(configFilepath string, varsFilepaths []string, vars map[string]interface{}) ([]byte, error) {
+				switch filepath.Base(configFilepath) {
+				case "pipeline_1.yml":
+					return []byte("pipeline1: bar\npassword: 'p@ss: w''rd'\n"), nil
+				case "pipeline_2.yml":
+					return []byte(pipelineContents[1]), nil
+				default:
+					return []byte("pipeline3: foo\n"), nil
+				}
+			}
+
+			getPipeline := fakeFlyCommand.GetPipelineStub
+			fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+				if name == apiPipelines[2] {
+					return nil, &fly.Error{Kind: fly.ErrNotFound, Err: errors.New("pipeline not found")}
+				}
+				return getPipeline(name)
+			}
+		})
+
+		JustBeforeEach(func() {
+			logged = &bytes.Buffer{}
+			command = out.NewCommand(logger.NewLogger(logged), fakeFlyCommand, sourcesDir, "1.2.3", timings, concourse.NewWarnings(), audit)
+		})
+
+		It("does not change any pipeline", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.RenderPipelineCallCount()).To(Equal(len(pipelines)))
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.UnpausePipelineCallCount()).To(Equal(0))
+			Expect(fakeFlyCommand.ExposePipelineCallCount()).To(Equal(0))
+			Expect(audit.String()).To(BeEmpty())
+		})
+
+		It("logs the diff of each pipeline which would change", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logged.String()).To(ContainSubstring("pipeline 'pipeline-1' would change (1 added, 1 changed):\n\n--- pipeline-1 (current)\n+++ pipeline-1 (new)\n@@ -1 +1,2 @@\n-pipeline1: foo\n+password: '***REDACTED-VAR-token***'\n+pipeline1: bar\n"))
+			Expect(logged.String()).To(ContainSubstring("pipeline 'pipeline-2' is up to date\n"))
+			Expect(logged.String()).To(ContainSubstring("pipeline 'pipeline-3' would change (1 added):"))
+			Expect(logged.String()).To(ContainSubstring("Dry run complete: 2 of 3 pipelines would change\n"))
+		})
+
+		It("lists the pipelines which would change in the metadata, and versions those which exist", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "pipelines to change", Value: "main/pipeline-1, some-other-team/pipeline-3"}))
+			Expect(response.Version).To(HaveKey("main/pipeline-1"))
+			Expect(response.Version).To(HaveKey("main/pipeline-2"))
+			Expect(response.Version).NotTo(HaveKey("some-other-team/pipeline-3"))
+		})
+	})
+
+	Context("when the ATC warns about a config", func() {
+		It("adds the warnings to the metadata", func() {
+			fakeFlyCommand.SetPipelineReturns([]byte("configuration updated\n\nWARNING:\n  - invalid identifier: job 'Build'\n\n"), nil)
+
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "warning",
+				Value: "pipeline pipeline-1 of team main: invalid identifier: job 'Build'",
+			}))
+		})
+	})
+
+	Describe("audit", func() {
+		auditEntries := func() []concourse.AuditEntry {
+			var entries []concourse.AuditEntry
+
+			decoder := json.NewDecoder(audit)
+			for decoder.More() {
+				var entry concourse.AuditEntry
+				Expect(decoder.Decode(&entry)).To(Succeed())
+				entries = append(entries, entry)
+			}
+
+			return entries
+		}
+
+		It("records each action performed on the pipelines, with the checksum of their config", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries := auditEntries()
+			Expect(entries).To(HaveLen(5))
+
+			Expect(entries[0].Action).To(Equal(concourse.AuditActionUpdate))
+			Expect(entries[0].Team).To(Equal(teamName))
+			Expect(entries[0].Pipeline).To(Equal(apiPipelines[0]))
+			Expect(entries[0].Checksum).NotTo(BeEmpty())
+			Expect(entries[0].PreviousChecksum).To(Equal(entries[0].Checksum))
+			Expect(entries[0].Time).NotTo(BeZero())
+
+			var actions []string
+			for _, e := range entries[1:4] {
+				Expect(e.Pipeline).To(Equal(apiPipelines[1]))
+				actions = append(actions, e.Action)
+			}
+			Expect(actions).To(Equal([]string{
+				concourse.AuditActionUpdate,
+				concourse.AuditActionExpose,
+				concourse.AuditActionUnpause,
+			}))
+
+			Expect(entries[4].Team).To(Equal(otherTeamName))
+		})
+
+		Context("when a pipeline does not exist yet", func() {
+			BeforeEach(func() {
+				stub := fakeFlyCommand.GetPipelineStub
+				fakeFlyCommand.GetPipelineStub = func(name string) ([]byte, error) {
+					if name == apiPipelines[0] && fakeFlyCommand.SetPipelineCallCount() == 0 {
+						return nil, fly.ErrNotFound
+					}
+					return stub(name)
+				}
+			})
+
+			It("records that it was created", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).NotTo(HaveOccurred())
+
+				entries := auditEntries()
+				Expect(entries[0].Action).To(Equal(concourse.AuditActionCreate))
+				Expect(entries[0].PreviousChecksum).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when timings are recorded", func() {
+		BeforeEach(func() {
+			timings = concourse.NewTimings()
+		})
+
+		It("returns the time spent in each phase in the metadata", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, m := range response.Metadata {
+				names = append(names, m.Name)
+			}
+			Expect(names).To(ContainElement("set duration"))
+			Expect(names).To(ContainElement("fetch duration"))
+			Expect(names).To(ContainElement("checksum duration"))
+		})
+	})
+
+	Context("when version_sequence is set", func() {
+		BeforeEach(func() {
+			outRequest.Source.VersionSequence = true
+		})
+
+		It("adds a sequence to the version", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version).To(HaveKey(concourse.SequenceKey))
+		})
+	})
+
+	Context("when the version scheme is config_version", func() {
+		BeforeEach(func() {
+			outRequest.Source.VersionScheme = "config_version"
+			fakeFlyCommand.GetPipelineConfigReturns(fly.PipelineConfig{}, "12", nil)
+		})
+
+		It("returns the config versions of the pipelines set", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version["main/"+apiPipelines[0]]).To(Equal("12"))
+			Expect(fakeFlyCommand.GetPipelineCallCount()).To(Equal(0))
+		})
 	})
 
 	Context("when insecure parses as true", func() {
@@ -250,6 +559,62 @@ pipeline3: foo
 		})
 	})
 
+	Context("when multiple targets are configured", func() {
+		BeforeEach(func() {
+			outRequest.Source.Targets = []concourse.Target{
+				{
+					Name:   "eu",
+					Target: "some eu target",
+					Teams:  outRequest.Source.Teams,
+				},
+				{
+					Name:   "us",
+					Target: "some us target",
+					Teams:  outRequest.Source.Teams,
+				},
+			}
+			outRequest.Source.Teams = nil
+
+			outRequest.Params.Pipelines[0].Target = "eu"
+			outRequest.Params.Pipelines[1].Target = "us"
+			outRequest.Params.Pipelines[2].Target = "us"
+		})
+
+		It("sets each pipeline on its target", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			url, _, _, _, _ := fakeFlyCommand.LoginArgsForCall(0)
+			Expect(url).To(Equal("some eu target"))
+
+			url, _, _, _, _ = fakeFlyCommand.LoginArgsForCall(1)
+			Expect(url).To(Equal("some us target"))
+		})
+
+		It("returns versions prefixed with the target name", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Version).To(HaveLen(3))
+			Expect(response.Version["eu/main/"+apiPipelines[0]]).To(Equal("4f4bd60b18bf697cc68dac9cb95537c2"))
+			Expect(response.Version).To(HaveKey("us/main/" + apiPipelines[1]))
+			Expect(response.Version).To(HaveKey("us/some-other-team/" + apiPipelines[2]))
+		})
+
+		It("returns the version and cluster name of each target", func() {
+			fakeFlyCommand.InfoReturnsOnCall(0, fly.Info{Version: "7.9.1", ClusterName: "eu-cluster"}, nil)
+			fakeFlyCommand.InfoReturnsOnCall(1, fly.Info{Version: "7.8.0"}, nil)
+
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "eu/concourse_version", Value: "7.9.1"}))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "eu/cluster_name", Value: "eu-cluster"}))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{Name: "us/concourse_version", Value: "7.8.0"}))
+			Expect(response.Metadata).NotTo(ContainElement(concourse.Metadata{Name: "us/cluster_name", Value: ""}))
+		})
+	})
+
 	Context("when setting a pipeline that belongs to another team", func() {
 		It("returns an error", func() {
 			_, err := command.Run(badOutRequest)
@@ -257,6 +622,85 @@ pipeline3: foo
 		})
 	})
 
+	Context("when setting a pipeline that is not included", func() {
+		BeforeEach(func() {
+			outRequest.Source.Include = []string{pipelines[0].Name}
+		})
+
+		It("returns an error without setting any pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError(ContainSubstring(pipelines[1].Name)))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when setting a pipeline that is not one of the pipelines of its team", func() {
+		BeforeEach(func() {
+			outRequest.Source.Teams[0].Pipelines = []string{"some-other-pipeline"}
+		})
+
+		It("returns an error without setting any pipelines", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("team (%s)", teamName)))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when a team has vars", func() {
+		BeforeEach(func() {
+			outRequest.Source.Teams[0].Vars = map[string]interface{}{
+				"team":    "main",
+				"channel": "#ci",
+			}
+		})
+
+		It("passes them to fly in a vars file before those of the pipeline", func() {
+			var teamVars []string
+			fakeFlyCommand.SetPipelineStub = func(name string, _ string, varsFilepaths []string, _ map[string]interface{}) ([]byte, error) {
+				defer GinkgoRecover()
+
+				if name != apiPipelines[0] {
+					return nil, nil
+				}
+
+				Expect(varsFilepaths).To(HaveLen(3))
+				Expect(varsFilepaths[1]).To(Equal(filepath.Join(sourcesDir, "vars_1.yml")))
+
+				b, err := ioutil.ReadFile(varsFilepaths[0])
+				Expect(err).NotTo(HaveOccurred())
+				teamVars = append(teamVars, string(b))
+
+				return nil, nil
+			}
+
+			_, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(teamVars).To(HaveLen(1))
+			Expect(teamVars[0]).To(MatchJSON(`{"team":"main","channel":"#ci"}`))
+
+			_, _, varsFilepaths, _ := fakeFlyCommand.SetPipelineArgsForCall(2)
+			Expect(varsFilepaths).To(BeEmpty())
+		})
+
+		It("writes them to the work dir, removing the file once the pipeline is set", func() {
+			workDir, err := ioutil.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(workDir)
+
+			outRequest.Source.WorkDir = workDir
+
+			_, err = command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, varsFilepaths, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+			Expect(filepath.Dir(varsFilepaths[0])).To(Equal(workDir))
+			Expect(varsFilepaths[0]).NotTo(BeAnExistingFile())
+		})
+	})
+
 	Context("when login returns an error", func() {
 		var (
 			expectedErr error
@@ -271,7 +715,7 @@ pipeline3: foo
 			_, err := command.Run(outRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err).To(Equal(expectedErr))
+			Expect(errors.Is(err, expectedErr)).To(BeTrue())
 		})
 	})
 
@@ -284,7 +728,95 @@ pipeline3: foo
 			_, err := command.Run(outRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err).To(Equal(setPipelinesErr))
+			Expect(errors.Is(err, setPipelinesErr)).To(BeTrue())
+		})
+	})
+
+	Context("when setting several pipelines returns an error", func() {
+		BeforeEach(func() {
+			setPipelinesErr = fmt.Errorf("some error")
+		})
+
+		It("tries to set every pipeline, returning an error listing each failure", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("3 pipelines failed:")))
+			Expect(err).To(MatchError(ContainSubstring("pipeline some-other-team/pipeline-3: some error")))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(3))
+		})
+	})
+
+	Context("when validate_schema is set", func() {
+		BeforeEach(func() {
+			outRequest.Params.ValidateSchema = true
+
+			configs := map[string]string{
+				"pipeline_1.yml": "jobs: [{name: build, plan: [{get: repo}]}]\n",
+				"pipeline_2.yml": "jobs: [{plan: [{get: repo, trigger: always}]}]\n",
+				"pipeline_3.yml": "resources: [{name: repo, type: git}]\n",
+			}
+			for name, config := range configs {
+				err := ioutil.WriteFile(filepath.Join(sourcesDir, name), []byte(config), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("does not set pipelines whose config does not match the schema, listing each problem", func() {
+			_, err := command.Run(outRequest)
+			Expect(err).To(MatchError("pipeline main/pipeline-2: config does not match the schema:\n" +
+				"    - config.jobs[0].name must be provided\n" +
+				"    - config.jobs[0].plan[0].trigger must be a boolean"))
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+			name, _, _, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+			Expect(name).To(Equal("pipeline-1"))
+			name, _, _, _ = fakeFlyCommand.SetPipelineArgsForCall(1)
+			Expect(name).To(Equal("pipeline-3"))
+		})
+	})
+
+	Context("when lint rules are configured", func() {
+		BeforeEach(func() {
+			outRequest.Source.Lint = &concourse.Lint{MaxJobs: 1}
+
+			configs := map[string]string{
+				"pipeline_1.yml": "jobs: [{name: build, plan: []}]\n",
+				"pipeline_2.yml": "jobs: [{name: build, plan: []}, {name: deploy, plan: []}]\n",
+				"pipeline_3.yml": "resources: [{name: repo, type: git}]\n",
+			}
+			for name, config := range configs {
+				err := ioutil.WriteFile(filepath.Join(sourcesDir, name), []byte(config), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("sets every pipeline, adding the problems to the metadata as warnings", func() {
+			response, err := command.Run(outRequest)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(3))
+			Expect(response.Metadata).To(ContainElement(concourse.Metadata{
+				Name:  "warning",
+				Value: "pipeline pipeline-2 of team main: max_jobs: 2 jobs, more than 1",
+			}))
+		})
+
+		Context("when the lint rules are enforced", func() {
+			BeforeEach(func() {
+				outRequest.Source.Lint.Mode = concourse.LintModeEnforce
+			})
+
+			It("does not set pipelines whose config breaks them", func() {
+				_, err := command.Run(outRequest)
+				Expect(err).To(MatchError("pipeline main/pipeline-2: config breaks lint rules:\n" +
+					"    - max_jobs: 2 jobs, more than 1"))
+
+				Expect(fakeFlyCommand.SetPipelineCallCount()).To(Equal(2))
+				name, _, _, _ := fakeFlyCommand.SetPipelineArgsForCall(0)
+				Expect(name).To(Equal("pipeline-1"))
+				name, _, _, _ = fakeFlyCommand.SetPipelineArgsForCall(1)
+				Expect(name).To(Equal("pipeline-3"))
+			})
 		})
 	})
 
@@ -303,6 +835,23 @@ pipeline3: foo
 			_, err := command.Run(outRequest)
 			Expect(err).To(HaveOccurred())
 
+			Expect(errors.Is(err, expectedErr)).To(BeTrue())
+		})
+	})
+
+	Context("when getting the info of the target returns an error", func() {
+		var (
+			expectedErr error
+		)
+
+		BeforeEach(func() {
+			expectedErr = fmt.Errorf("some error")
+
+			fakeFlyCommand.InfoReturns(fly.Info{}, expectedErr)
+		})
+
+		It("returns an error", func() {
+			_, err := command.Run(outRequest)
 			Expect(err).To(Equal(expectedErr))
 		})
 	})
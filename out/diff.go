@@ -0,0 +1,129 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/fly"
+	"github.com/concourse/concourse-pipeline-resource/logger"
+	"github.com/concourse/concourse-pipeline-resource/pipelinediff"
+	"gopkg.in/yaml.v2"
+)
+
+// diffContext is the number of lines of context around each change in the
+// diffs logged in a dry run.
+const diffContext = 3
+
+// diffPipeline logs the changes which setting the pipeline would make to its
+// config on the target, with the sanitized values redacted, and returns
+// whether there are any.
+func (c *Command) diffPipeline(source concourse.Source, target concourse.Target, p concourse.Pipeline, sanitized map[string]string, pipelineLogger logger.Logger) (bool, error) {
+	configFilepath, varsFilepaths, cleanup, err := c.preparePipeline(source, target, p, pipelineLogger)
+	if err != nil {
+		return false, err
+	}
+	defer cleanup()
+
+	rendered, err := c.flyCommand.RenderPipeline(configFilepath, varsFilepaths, p.Vars)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := c.flyCommand.GetPipeline(p.Name)
+	if errors.Is(err, fly.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		return false, concourse.WithErrorCode(concourse.ErrorCodePipelineFetchFailed, err)
+	}
+
+	// Both are redacted before being compared, as the vars are interpolated
+	// into them and YAML may quote them differently from the sanitizer.
+	current, err = redactedConfig(current, sanitized)
+	if err != nil {
+		return false, fmt.Errorf("parsing current config: %v", err)
+	}
+
+	rendered, err = redactedConfig(rendered, sanitized)
+	if err != nil {
+		return false, fmt.Errorf("parsing new config: %v", err)
+	}
+
+	changes, err := pipelinediff.Changes(current, rendered)
+	if err != nil {
+		return false, err
+	}
+
+	if len(changes) == 0 {
+		pipelineLogger.Infof("pipeline '%s' is up to date\n", p.Name)
+		return false, nil
+	}
+
+	diff, err := pipelinediff.Unified(p.Name+" (current)", current, p.Name+" (new)", rendered, diffContext)
+	if err != nil {
+		return false, err
+	}
+
+	pipelineLogger.Infof("pipeline '%s' would change (%s):\n\n%s\n", p.Name, changesSummary(changes), diff)
+
+	return true, nil
+}
+
+// changesSummary returns how many values would be added, changed and removed,
+// e.g. "2 added, 1 changed".
+func changesSummary(changes []pipelinediff.Change) string {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+
+	var parts []string
+	for _, kind := range []string{pipelinediff.KindAdded, pipelinediff.KindChanged, pipelinediff.KindRemoved} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// redactedConfig returns the config with each sanitized value in its strings
+// replaced by the value it is sanitized with.
+func redactedConfig(config []byte, sanitized map[string]string) ([]byte, error) {
+	if len(config) == 0 || len(sanitized) == 0 {
+		return config, nil
+	}
+
+	var v interface{}
+	err := yaml.Unmarshal(config, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(redacted(v, sanitized))
+}
+
+func redacted(v interface{}, sanitized map[string]string) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			m[k] = redacted(e, sanitized)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = redacted(e, sanitized)
+		}
+		return s
+	case string:
+		for value, replacement := range sanitized {
+			v = strings.Replace(v, value, replacement, -1)
+		}
+		return v
+	default:
+		return v
+	}
+}
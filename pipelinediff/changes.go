@@ -0,0 +1,207 @@
+// Package pipelinediff compares pipeline configs, both as the changes to the
+// values at each path of the configs and as a unified diff of the configs in
+// canonical form, e.g. to show what setting a pipeline would change.
+package pipelinediff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Kinds of Change.
+const (
+	KindAdded   = "added"
+	KindRemoved = "removed"
+	KindChanged = "changed"
+)
+
+// Change is a difference between two configs at a path, e.g.
+// jobs[name=build].plan[0].get. Items of lists which all have a name, such as
+// jobs and resources, are matched by name rather than by position, so that
+// adding one does not change all of those after it.
+type Change struct {
+	Path string      `json:"path"`
+	Kind string      `json:"kind"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Changes returns the changes from the old config to the new one, in the order
+// of the new config, with the keys of maps sorted. Either config may be empty,
+// e.g. for a pipeline which does not exist yet.
+func Changes(old []byte, new []byte) ([]Change, error) {
+	oldValue, err := parse(old)
+	if err != nil {
+		return nil, fmt.Errorf("parsing old config: %v", err)
+	}
+
+	newValue, err := parse(new)
+	if err != nil {
+		return nil, fmt.Errorf("parsing new config: %v", err)
+	}
+
+	var changes []Change
+	compare("", oldValue, newValue, &changes)
+
+	return changes, nil
+}
+
+// parse returns the config with the keys of its maps as strings, so that its
+// values can be compared and encoded as JSON.
+func parse(config []byte) (interface{}, error) {
+	var v interface{}
+	err := yaml.Unmarshal(config, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalized(v), nil
+}
+
+func normalized(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalized(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalized(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func compare(path string, old interface{}, new interface{}, changes *[]Change) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		*changes = append(*changes, Change{Path: path, Kind: KindAdded, New: new})
+		return
+	case new == nil:
+		*changes = append(*changes, Change{Path: path, Kind: KindRemoved, Old: old})
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		compareMaps(path, oldMap, newMap, changes)
+		return
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		if oldNames, ok := names(oldList); ok {
+			if newNames, ok := names(newList); ok {
+				compareNamed(path, oldList, oldNames, newList, newNames, changes)
+				return
+			}
+		}
+
+		compareLists(path, oldList, newList, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Kind: KindChanged, Old: old, New: new})
+	}
+}
+
+func compareMaps(path string, old map[string]interface{}, new map[string]interface{}, changes *[]Change) {
+	keys := make([]string, 0, len(old)+len(new))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := k
+		if path != "" {
+			child = path + "." + k
+		}
+
+		compare(child, old[k], new[k], changes)
+	}
+}
+
+func compareLists(path string, old []interface{}, new []interface{}, changes *[]Change) {
+	for i := 0; i < len(old) || i < len(new); i++ {
+		var o, n interface{}
+		if i < len(old) {
+			o = old[i]
+		}
+		if i < len(new) {
+			n = new[i]
+		}
+
+		compare(fmt.Sprintf("%s[%d]", path, i), o, n, changes)
+	}
+}
+
+func compareNamed(path string, old []interface{}, oldNames map[string]int, new []interface{}, newNames map[string]int, changes *[]Change) {
+	for _, n := range new {
+		name := itemName(n)
+		var o interface{}
+		if i, ok := oldNames[name]; ok {
+			o = old[i]
+		}
+
+		compare(fmt.Sprintf("%s[name=%s]", path, name), o, n, changes)
+	}
+
+	for _, o := range old {
+		name := itemName(o)
+		if _, ok := newNames[name]; !ok {
+			compare(fmt.Sprintf("%s[name=%s]", path, name), o, nil, changes)
+		}
+	}
+}
+
+// names returns the index of each item of the list by its name, if every item
+// has a distinct one.
+func names(list []interface{}) (map[string]int, bool) {
+	result := make(map[string]int, len(list))
+	for i, item := range list {
+		name := itemName(item)
+		if name == "" {
+			return nil, false
+		}
+		if _, ok := result[name]; ok {
+			return nil, false
+		}
+
+		result[name] = i
+	}
+
+	return result, true
+}
+
+func itemName(item interface{}) string {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	name, ok := m["name"].(string)
+	if !ok {
+		return ""
+	}
+
+	return name
+}
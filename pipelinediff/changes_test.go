@@ -0,0 +1,73 @@
+package pipelinediff_test
+
+import (
+	"github.com/concourse/concourse-pipeline-resource/pipelinediff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const oldConfig = `---
+resources:
+- name: repo
+  type: git
+  source:
+    uri: https://example.com/repo.git
+- name: image
+  type: registry-image
+jobs:
+- name: build
+  plan:
+  - get: repo
+  - task: unit
+    file: repo/ci/unit.yml
+`
+
+const newConfig = `---
+jobs:
+- name: build
+  plan:
+  - get: repo
+    trigger: true
+  - task: unit
+    file: repo/ci/test.yml
+resources:
+- name: repo
+  type: git
+  source:
+    uri: https://example.com/repo.git
+- name: version
+  type: semver
+`
+
+var _ = Describe("Changes", func() {
+	It("returns the changes at each path, matching named items by name", func() {
+		changes, err := pipelinediff.Changes([]byte(oldConfig), []byte(newConfig))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(changes).To(Equal([]pipelinediff.Change{
+			{Path: "jobs[name=build].plan[0].trigger", Kind: pipelinediff.KindAdded, New: true},
+			{Path: "jobs[name=build].plan[1].file", Kind: pipelinediff.KindChanged, Old: "repo/ci/unit.yml", New: "repo/ci/test.yml"},
+			{Path: "resources[name=version]", Kind: pipelinediff.KindAdded, New: map[string]interface{}{"name": "version", "type": "semver"}},
+			{Path: "resources[name=image]", Kind: pipelinediff.KindRemoved, Old: map[string]interface{}{"name": "image", "type": "registry-image"}},
+		}))
+	})
+
+	It("returns no changes for configs differing only in formatting", func() {
+		changes, err := pipelinediff.Changes([]byte("jobs: []\nresources: []\n"), []byte("resources: []\n\njobs: []\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(BeEmpty())
+	})
+
+	It("returns the whole config as added when there is no old one", func() {
+		changes, err := pipelinediff.Changes(nil, []byte("jobs: []\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(changes).To(Equal([]pipelinediff.Change{
+			{Path: "", Kind: pipelinediff.KindAdded, New: map[string]interface{}{"jobs": []interface{}{}}},
+		}))
+	})
+
+	It("returns an error if a config is not valid YAML", func() {
+		_, err := pipelinediff.Changes([]byte(oldConfig), []byte("jobs:\n\t- name: build\n"))
+		Expect(err).To(MatchError(ContainSubstring("parsing new config")))
+	})
+})
@@ -0,0 +1,13 @@
+package pipelinediff_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPipelinediff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pipelinediff Suite")
+}
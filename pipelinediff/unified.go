@@ -0,0 +1,238 @@
+package pipelinediff
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// maxLCSCells limits the size of the table used to find the longest common
+// subsequence of the lines which differ, past which they are all shown as
+// removed and then added, rather than using as much memory as the product of
+// their lengths.
+const maxLCSCells = 1 << 22
+
+type op struct {
+	kind byte
+	line string
+}
+
+// Unified returns a unified diff from the old config to the new one, each in
+// canonical form so that only differences in their values are shown, with the
+// number of lines of context around each change and the files labelled with
+// the given names. It is empty if the configs do not differ.
+func Unified(oldName string, old []byte, newName string, new []byte, context int) (string, error) {
+	oldLines, err := canonicalLines(old)
+	if err != nil {
+		return "", fmt.Errorf("parsing old config: %v", err)
+	}
+
+	newLines, err := canonicalLines(new)
+	if err != nil {
+		return "", fmt.Errorf("parsing new config: %v", err)
+	}
+
+	ops := diffLines(oldLines, newLines)
+
+	var b strings.Builder
+	for _, h := range hunks(ops, context) {
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+		for _, o := range ops[h.start:h.end] {
+			fmt.Fprintf(&b, "%c%s\n", o.kind, o.line)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// canonicalLines returns the lines of the config as YAML with its keys sorted.
+func canonicalLines(config []byte) ([]string, error) {
+	v, err := parse(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == nil {
+		return nil, nil
+	}
+
+	canonical, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimSuffix(string(canonical), "\n"), "\n"), nil
+}
+
+// diffLines returns the edits turning the old lines into the new ones, with
+// the lines they have in common.
+func diffLines(old []string, new []string) []op {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(old)+len(new))
+	for _, l := range old[:prefix] {
+		ops = append(ops, op{' ', l})
+	}
+
+	ops = append(ops, diffMiddle(old[prefix:len(old)-suffix], new[prefix:len(new)-suffix])...)
+
+	for _, l := range old[len(old)-suffix:] {
+		ops = append(ops, op{' ', l})
+	}
+
+	return ops
+}
+
+func diffMiddle(old []string, new []string) []op {
+	var ops []op
+
+	if len(old)*len(new) > maxLCSCells {
+		for _, l := range old {
+			ops = append(ops, op{'-', l})
+		}
+		for _, l := range new {
+			ops = append(ops, op{'+', l})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of old[i:]
+	// and new[j:].
+	lcs := make([][]int32, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			ops = append(ops, op{' ', old[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', old[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		ops = append(ops, op{'-', old[i]})
+	}
+	for ; j < len(new); j++ {
+		ops = append(ops, op{'+', new[j]})
+	}
+
+	return ops
+}
+
+type hunk struct {
+	start, end         int
+	oldStart, oldCount int
+	newStart, newCount int
+}
+
+// hunks groups the edits with the lines of context around them, merging
+// those whose context overlaps.
+func hunks(ops []op, context int) []hunk {
+	var result []hunk
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend the hunk while the next edit is within twice the context.
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*context {
+				break
+			}
+		}
+
+		i = end
+		end += context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		result = append(result, newHunk(ops, start, end))
+	}
+
+	return result
+}
+
+func newHunk(ops []op, start int, end int) hunk {
+	h := hunk{start: start, end: end}
+
+	for _, o := range ops[:start] {
+		if o.kind != '+' {
+			h.oldStart++
+		}
+		if o.kind != '-' {
+			h.newStart++
+		}
+	}
+
+	for _, o := range ops[start:end] {
+		if o.kind != '+' {
+			h.oldCount++
+		}
+		if o.kind != '-' {
+			h.newCount++
+		}
+	}
+
+	// Ranges start at the first line of the hunk, or at the line before an
+	// empty one.
+	if h.oldCount > 0 {
+		h.oldStart++
+	}
+	if h.newCount > 0 {
+		h.newStart++
+	}
+
+	return h
+}
+
+func hunkRange(start int, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,54 @@
+package pipelinediff_test
+
+import (
+	"strings"
+
+	"github.com/concourse/concourse-pipeline-resource/pipelinediff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Unified", func() {
+	It("returns a unified diff of the configs in canonical form", func() {
+		diff, err := pipelinediff.Unified("current", []byte(oldConfig), "new", []byte(newConfig), 1)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(Equal(`--- current
++++ new
+@@ -4,3 +4,4 @@
+   - get: repo
+-  - file: repo/ci/unit.yml
++    trigger: true
++  - file: repo/ci/test.yml
+     task: unit
+@@ -11,3 +12,3 @@
+   type: git
+-- name: image
+-  type: registry-image
++- name: version
++  type: semver
+`))
+	})
+
+	It("merges changes whose context overlaps into one hunk", func() {
+		diff, err := pipelinediff.Unified("current", []byte(oldConfig), "new", []byte(newConfig), 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(HavePrefix("--- current\n+++ new\n@@ -2,12 +2,13 @@\n - name: build\n"))
+		Expect(strings.Count(diff, "@@ -")).To(Equal(1))
+	})
+
+	It("returns the whole config as added when there is no old one", func() {
+		diff, err := pipelinediff.Unified("current", nil, "new", []byte("jobs: []\n"), 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(Equal("--- current\n+++ new\n@@ -0,0 +1 @@\n+jobs: []\n"))
+	})
+
+	It("returns nothing when the configs differ only in formatting", func() {
+		diff, err := pipelinediff.Unified("current", []byte("jobs: []\nresources: []\n"), "new", []byte("resources: []\n\njobs: []\n"), 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(diff).To(BeEmpty())
+	})
+})
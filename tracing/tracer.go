@@ -0,0 +1,280 @@
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ServiceName identifies the resource in exported traces.
+const ServiceName = "concourse-pipeline-resource"
+
+// Kinds of span, as defined by OTLP.
+const (
+	KindInternal = 1
+	KindClient   = 3
+)
+
+const (
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+// traceparentRegexp matches a W3C traceparent, as set in the TRACEPARENT
+// environment variable of steps by Concourse when it is itself traced.
+var traceparentRegexp = regexp.MustCompile(`^00-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// Tracer records spans for a single run of the resource and exports them over
+// OTLP/HTTP as JSON. Every span is a child of the root span of the run, which
+// is in turn a child of the traceparent it was created with, if any. A nil
+// Tracer records nothing.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+	version  string
+
+	traceID      string
+	parentSpanID string
+	root         *Span
+
+	mutex sync.Mutex
+	spans []*Span
+}
+
+// Span is an operation timed by the Tracer. A nil Span records nothing.
+type Span struct {
+	tracer *Tracer
+
+	id           string
+	parentSpanID string
+	name         string
+	kind         int
+	start        time.Time
+	end          time.Time
+	attributes   map[string]string
+	err          error
+}
+
+// NewTracer returns a tracer exporting to the endpoint with the headers, whose
+// root span is named after the run of the resource, e.g. check. The trace is
+// continued from the traceparent if it is valid.
+func NewTracer(name string, endpoint string, headers map[string]string, traceparent string, version string) *Tracer {
+	t := &Tracer{
+		endpoint: endpoint,
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		version:  version,
+	}
+
+	if m := traceparentRegexp.FindStringSubmatch(traceparent); m != nil {
+		t.traceID = m[1]
+		t.parentSpanID = m[2]
+	} else {
+		t.traceID = randomID(16)
+	}
+
+	t.root = &Span{
+		tracer:       t,
+		id:           randomID(8),
+		parentSpanID: t.parentSpanID,
+		name:         name,
+		kind:         KindInternal,
+		start:        time.Now(),
+	}
+
+	return t
+}
+
+// Start starts a span of the given kind as a child of the root span.
+func (t *Tracer) Start(name string, kind int, attributes map[string]string) *Span {
+	if t == nil {
+		return nil
+	}
+
+	s := &Span{
+		tracer:       t,
+		id:           randomID(8),
+		parentSpanID: t.root.id,
+		name:         name,
+		kind:         kind,
+		start:        time.Now(),
+		attributes:   attributes,
+	}
+
+	return s
+}
+
+// SetAttribute adds the attribute to the span.
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+	s.attributes[key] = value
+}
+
+// End ends the span, marking it as failed if err is not nil.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.end = time.Now()
+	s.err = err
+
+	s.tracer.mutex.Lock()
+	defer s.tracer.mutex.Unlock()
+
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Traceparent returns the W3C traceparent identifying the span, to propagate
+// it to the services it calls.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("00-%s-%s-01", s.tracer.traceID, s.id)
+}
+
+// Export ends the root span, marking it as failed if err is not nil, and
+// sends every span ended so far to the endpoint.
+func (t *Tracer) Export(err error) error {
+	if t == nil {
+		return nil
+	}
+
+	t.root.End(err)
+
+	t.mutex.Lock()
+	body, marshalErr := json.Marshal(t.request())
+	t.mutex.Unlock()
+	if marshalErr != nil {
+		// Untested as the request always marshals
+		return marshalErr
+	}
+
+	req, reqErr := http.NewRequest("POST", t.endpoint, bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, doErr := t.client.Do(req)
+	if doErr != nil {
+		return doErr
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("exporting traces to %s failed: %s - %s", t.endpoint, resp.Status, string(b))
+	}
+
+	return nil
+}
+
+// request returns the OTLP export request holding the spans.
+func (t *Tracer) request() map[string]interface{} {
+	spans := make([]map[string]interface{}, 0, len(t.spans))
+	for _, s := range t.spans {
+		spans = append(spans, s.otlp())
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{
+			{
+				"resource": map[string]interface{}{
+					"attributes": attributes(map[string]string{
+						"service.name":    ServiceName,
+						"service.version": t.version,
+					}),
+				},
+				"scopeSpans": []map[string]interface{}{
+					{
+						"scope": map[string]interface{}{
+							"name":    ServiceName,
+							"version": t.version,
+						},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (s *Span) otlp() map[string]interface{} {
+	span := map[string]interface{}{
+		"traceId":           s.tracer.traceID,
+		"spanId":            s.id,
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        attributes(s.attributes),
+		"status":            map[string]interface{}{"code": statusCodeOK},
+	}
+
+	if s.parentSpanID != "" {
+		span["parentSpanId"] = s.parentSpanID
+	}
+
+	if s.err != nil {
+		span["status"] = map[string]interface{}{
+			"code":    statusCodeError,
+			"message": s.err.Error(),
+		}
+	}
+
+	return span
+}
+
+func attributes(attrs map[string]string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(attrs))
+	for _, k := range sortedKeys(attrs) {
+		result = append(result, map[string]interface{}{
+			"key":   k,
+			"value": map[string]string{"stringValue": attrs[k]},
+		})
+	}
+
+	return result
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	// crypto/rand only fails if the system has no source of randomness
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,133 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/concourse/concourse-pipeline-resource/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type exportRequest struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []span `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type span struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+	Status       struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+var _ = Describe("Tracer", func() {
+	var (
+		server   *httptest.Server
+		requests []*http.Request
+		bodies   [][]byte
+		status   int
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		bodies = nil
+		status = http.StatusOK
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, b)
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	exportedSpans := func() []span {
+		Expect(bodies).To(HaveLen(1))
+
+		var req exportRequest
+		Expect(json.Unmarshal(bodies[0], &req)).To(Succeed())
+		Expect(req.ResourceSpans).To(HaveLen(1))
+		Expect(req.ResourceSpans[0].ScopeSpans).To(HaveLen(1))
+
+		return req.ResourceSpans[0].ScopeSpans[0].Spans
+	}
+
+	It("exports each span as a child of the root span, along with the root", func() {
+		tracer := tracing.NewTracer("check", server.URL+"/v1/traces", map[string]string{"x-api-key": "some-key"}, "", "1.2.3")
+
+		s := tracer.Start("fly pipelines", tracing.KindInternal, nil)
+		s.End(nil)
+		tracer.Start("GET /teams", tracing.KindClient, nil).End(errors.New("some error"))
+
+		Expect(tracer.Export(nil)).To(Succeed())
+
+		Expect(requests[0].URL.Path).To(Equal("/v1/traces"))
+		Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(requests[0].Header.Get("x-api-key")).To(Equal("some-key"))
+
+		spans := exportedSpans()
+		Expect(spans).To(HaveLen(3))
+
+		root := spans[2]
+		Expect(root.Name).To(Equal("check"))
+		Expect(root.ParentSpanID).To(BeEmpty())
+		Expect(root.TraceID).To(HaveLen(32))
+
+		Expect(spans[0].Name).To(Equal("fly pipelines"))
+		Expect(spans[0].ParentSpanID).To(Equal(root.SpanID))
+		Expect(spans[0].TraceID).To(Equal(root.TraceID))
+		Expect(spans[0].Status.Code).To(Equal(1))
+		Expect(s.Traceparent()).To(Equal("00-" + root.TraceID + "-" + spans[0].SpanID + "-01"))
+
+		Expect(spans[1].Kind).To(Equal(tracing.KindClient))
+		Expect(spans[1].Status.Code).To(Equal(2))
+		Expect(spans[1].Status.Message).To(Equal("some error"))
+	})
+
+	It("continues the trace of the traceparent", func() {
+		traceparent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		tracer := tracing.NewTracer("in", server.URL, nil, traceparent, "1.2.3")
+
+		Expect(tracer.Export(errors.New("some error"))).To(Succeed())
+
+		spans := exportedSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].TraceID).To(Equal("0af7651916cd43dd8448eb211c80319c"))
+		Expect(spans[0].ParentSpanID).To(Equal("b7ad6b7169203331"))
+		Expect(spans[0].Status.Code).To(Equal(2))
+	})
+
+	It("returns an error if the collector rejects the spans", func() {
+		status = http.StatusUnauthorized
+		tracer := tracing.NewTracer("out", server.URL, nil, "", "1.2.3")
+
+		Expect(tracer.Export(nil)).To(MatchError(ContainSubstring("401 Unauthorized")))
+	})
+
+	It("records nothing when nil", func() {
+		var tracer *tracing.Tracer
+
+		s := tracer.Start("fly pipelines", tracing.KindInternal, nil)
+		s.SetAttribute("some", "attribute")
+		s.End(nil)
+
+		Expect(s.Traceparent()).To(BeEmpty())
+		Expect(tracer.Export(nil)).To(Succeed())
+	})
+})
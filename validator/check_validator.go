@@ -1,15 +1,12 @@
 package validator
 
 import (
-	"fmt"
-
 	"github.com/concourse/concourse-pipeline-resource/concourse"
 )
 
 func ValidateCheck(input concourse.CheckRequest) error {
-	if input.Source.Target == "" {
-		return fmt.Errorf("%s must be provided in source", "target")
-	}
-
-	return ValidateTeams(input.Source.Teams)
+	var errs Errors
+	validateSource(&errs, input.Source)
+	validateTargets(&errs, input.Source)
+	return errs.err()
 }
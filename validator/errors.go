@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Errors are the problems found when validating a request. All of them are
+// reported at once, so that the configuration can be fixed in one go.
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d problems found:\n  - %s", len(e), strings.Join(messages, "\n  - "))
+}
+
+func (e *Errors) add(format string, a ...interface{}) {
+	*e = append(*e, fmt.Errorf(format, a...))
+}
+
+// err returns the errors, or nil if there are none.
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
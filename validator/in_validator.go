@@ -1,15 +1,45 @@
 package validator
 
 import (
-	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
 )
 
 func ValidateIn(input concourse.InRequest) error {
-	if input.Source.Target == "" {
-		return fmt.Errorf("%s must be provided in source", "target")
+	var errs Errors
+	validateSource(&errs, input.Source)
+	validateTargets(&errs, input.Source)
+	validateStepFile(&errs, "log_file", input.Params.LogFile, "pipeline-resource.log")
+
+	switch input.Params.Graph {
+	case "", concourse.GraphFormatDOT, concourse.GraphFormatSVG:
+	default:
+		errs.add("%s must be one of %s or %s: %s", "graph", concourse.GraphFormatDOT, concourse.GraphFormatSVG, input.Params.Graph)
+	}
+
+	return errs.err()
+}
+
+// validateStepFile adds a problem to errs unless the path of the file given
+// by the param is empty or inside the directory of the step.
+func validateStepFile(errs *Errors, param string, path string, example string) {
+	if path == "" {
+		return
+	}
+
+	cleaned := filepath.Clean(path)
+	if filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		errs.add("%s must be a path inside the directory of the step, e.g. %s, got: '%s'", param, example, path)
 	}
+}
 
-	return ValidateTeams(input.Source.Teams)
+// ValidateLogFile returns an error unless the path given by the log_file param
+// is empty or inside the directory of the step, so that the file can be
+// opened before the rest of the input is validated.
+func ValidateLogFile(path string) error {
+	var errs Errors
+	validateStepFile(&errs, "log_file", path, "pipeline-resource.log")
+	return errs.err()
 }
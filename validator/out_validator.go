@@ -4,21 +4,23 @@ import (
 	"fmt"
 
 	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/lint"
 )
 
 func ValidateOut(input concourse.OutRequest) error {
-	err := ValidateTeams(input.Source.Teams)
-	if err != nil {
-		return err
-	}
-
-	sourceTeamNames := []string{}
-	for _, team := range input.Source.Teams {
-		sourceTeamNames = append(sourceTeamNames, team.Name)
-	}
-
-	if input.Source.Target == "" {
-		return fmt.Errorf("%s must be provided in source", "target")
+	var errs Errors
+	validateSource(&errs, input.Source)
+	validateTargets(&errs, input.Source)
+	validateStepFile(&errs, "log_file", input.Params.LogFile, "pipeline-resource.log")
+	validateStepFile(&errs, "audit_file", input.Params.AuditFile, "audit.json")
+	validateLint(&errs, input.Source.Lint)
+
+	targetTeamNames := make(map[string][]string)
+	for _, target := range input.Source.AllTargets() {
+		targetTeamNames[target.Name] = []string{}
+		for _, team := range target.Teams {
+			targetTeamNames[target.Name] = append(targetTeamNames[target.Name], team.Name)
+		}
 	}
 
 	var pipelinesFilePresent bool
@@ -33,7 +35,7 @@ func ValidateOut(input concourse.OutRequest) error {
 	}
 
 	if !(pipelinesPresent || pipelinesFilePresent) {
-		return fmt.Errorf(
+		errs.add(
 			"pipelines must be provided via either %s or %s",
 			"pipelines",
 			"pipelines_file",
@@ -41,7 +43,7 @@ func ValidateOut(input concourse.OutRequest) error {
 	}
 
 	if pipelinesPresent && pipelinesFilePresent {
-		return fmt.Errorf(
+		errs.add(
 			"pipelines must be provided via one of either %s or %s",
 			"pipelines",
 			"pipelines_file",
@@ -49,43 +51,65 @@ func ValidateOut(input concourse.OutRequest) error {
 	}
 
 	for i, p := range input.Params.Pipelines {
+		pipelinePath := fmt.Sprintf("pipelines[%d]", i)
+
 		if p.Name == "" {
-			return fmt.Errorf("%s must be provided for pipeline[%d]", "name", i)
+			errs.add("%s.%s must be provided, e.g. my-pipeline", pipelinePath, "name")
 		}
 
 		if p.ConfigFile == "" {
-			return fmt.Errorf("%s must be provided for pipeline[%d]", "config_file", i)
+			errs.add("%s.%s must be provided, e.g. repo/pipeline.yml", pipelinePath, "config_file")
 		}
 
 		if p.TeamName == "" {
-			return fmt.Errorf("%s must be provided for pipeline[%d]", "team", i)
+			errs.add("%s.%s must be provided, e.g. main", pipelinePath, "team")
 		}
 
-		if !stringContains(sourceTeamNames, p.TeamName) {
-			return fmt.Errorf("team name '%s' not found in source team names: %v", p.TeamName, sourceTeamNames)
+		sourceTeamNames, found := targetTeamNames[p.Target]
+		if !found {
+			errs.add("%s.%s name '%s' not found in source targets", pipelinePath, "target", p.Target)
+		} else if p.TeamName != "" && !stringContains(sourceTeamNames, p.TeamName) {
+			errs.add("%s.%s name '%s' not found in source team names: %v", pipelinePath, "team", p.TeamName, sourceTeamNames)
 		}
 
 		// vars files can be nil as it is optional.
 		if p.VarsFiles != nil {
 			// However, if it is provided it must be non-empty
 			if len(p.VarsFiles) == 0 {
-				return fmt.Errorf("%s must be non-empty if provided for pipeline[%d]", "vars_files", i)
+				errs.add("%s.%s must be non-empty if provided", pipelinePath, "vars_files")
 			}
 
 			for j, v := range p.VarsFiles {
 				if len(v) == 0 {
-					return fmt.Errorf(
-						"%s must be non-empty for pipeline[%d].vars_files[%d]",
-						"vars file",
-						i,
-						j,
-					)
+					errs.add("%s.%s[%d] must be non-empty", pipelinePath, "vars_files", j)
 				}
 			}
 		}
 	}
 
-	return nil
+	return errs.err()
+}
+
+// validateLint adds the problems with the lint rules, if any, to errs.
+func validateLint(errs *Errors, l *concourse.Lint) {
+	if l == nil {
+		return
+	}
+
+	switch l.Mode {
+	case "", concourse.LintModeWarn, concourse.LintModeEnforce:
+	default:
+		errs.add("%s must be one of %s or %s: %s", "lint.mode", concourse.LintModeWarn, concourse.LintModeEnforce, l.Mode)
+	}
+
+	if l.MaxJobs < 0 {
+		errs.add("%s must not be negative: %d", "lint.max_jobs", l.MaxJobs)
+	}
+
+	_, err := lint.Rules(*l)
+	if err != nil {
+		errs.add("%v", err)
+	}
 }
 
 func stringContains(slice []string, str string) bool {
@@ -57,7 +57,7 @@ var _ = Describe("ValidateOut", func() {
 			err := validator.ValidateOut(outRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err.Error()).To(MatchRegexp(".*name.*provided.*team.*0"))
+			Expect(err.Error()).To(MatchRegexp(`teams\[0\]\.name.*provided`))
 		})
 	})
 
@@ -148,7 +148,7 @@ var _ = Describe("ValidateOut", func() {
 			err := validator.ValidateOut(outRequest)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err.Error()).To(MatchRegexp(".*vars file.*non-empty"))
+			Expect(err.Error()).To(MatchRegexp(`vars_files\[0\].*non-empty`))
 		})
 	})
 
@@ -164,4 +164,343 @@ var _ = Describe("ValidateOut", func() {
 			Expect(err.Error()).To(MatchRegexp(".*name.*not found.*source.*"))
 		})
 	})
+
+	Context("when request timeout is not a duration", func() {
+		BeforeEach(func() {
+			outRequest.Source.RequestTimeout = "forever"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*request_timeout.*duration"))
+		})
+	})
+
+	Context("when the checksum algorithm is not supported", func() {
+		BeforeEach(func() {
+			outRequest.Source.Checksum = "crc32"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("checksum must be one of")))
+		})
+	})
+
+	Context("when the log level is not supported", func() {
+		BeforeEach(func() {
+			outRequest.Source.LogLevel = "verbose"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("log_level must be one of debug, info, warn or error: verbose"))
+		})
+	})
+
+	Context("when the log format is not supported", func() {
+		BeforeEach(func() {
+			outRequest.Source.LogFormat = "xml"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("log_format must be one of text or json: xml"))
+		})
+	})
+
+	Context("when the tracing endpoint is not a URL", func() {
+		BeforeEach(func() {
+			outRequest.Source.Tracing = &concourse.Tracing{Endpoint: "otel-collector"}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("tracing.endpoint must be a URL, e.g. http://otel-collector:4318"))
+		})
+	})
+
+	Context("when only one of the AWS keys is given", func() {
+		BeforeEach(func() {
+			outRequest.Source.AWS = &concourse.AWS{Region: "eu-west-1", AccessKeyID: "AKID"}
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("aws.access_key_id and aws.secret_access_key must be provided together"))
+		})
+	})
+
+	Context("when the lint rules are not valid", func() {
+		BeforeEach(func() {
+			outRequest.Source.Lint = &concourse.Lint{Mode: "block", JobNamePattern: "(", MaxJobs: -1}
+		})
+
+		It("returns an error listing each problem", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("3 problems found")))
+			Expect(err).To(MatchError(ContainSubstring("lint.mode must be one of warn or enforce: block")))
+			Expect(err).To(MatchError(ContainSubstring("lint.max_jobs must not be negative: -1")))
+			Expect(err).To(MatchError(ContainSubstring("lint.job_name_pattern must be a regular expression")))
+		})
+	})
+
+	Context("when the log file is outside the sources directory", func() {
+		BeforeEach(func() {
+			outRequest.Params.LogFile = "../out.log"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("log_file must be a path inside the directory of the step, e.g. pipeline-resource.log, got: '../out.log'"))
+		})
+	})
+
+	Context("when the log file is inside the sources directory", func() {
+		BeforeEach(func() {
+			outRequest.Params.LogFile = "logs/out.log"
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).To(Succeed())
+		})
+	})
+
+	Context("when the audit file is outside the sources directory", func() {
+		BeforeEach(func() {
+			outRequest.Params.AuditFile = "/tmp/audit.json"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("audit_file must be a path inside the directory of the step, e.g. audit.json, got: '/tmp/audit.json'"))
+		})
+	})
+
+	Context("when the version scheme is not supported", func() {
+		BeforeEach(func() {
+			outRequest.Source.VersionScheme = "build_number"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("version_scheme must be one of checksum or config_version: build_number"))
+		})
+	})
+
+	Context("when the version mode is not supported", func() {
+		BeforeEach(func() {
+			outRequest.Source.VersionMode = "list"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("version_mode must be one of map, digest or pipeline: list"))
+		})
+	})
+
+	Context("when the CA certificate is not PEM-encoded", func() {
+		BeforeEach(func() {
+			outRequest.Source.CACert = "some-ca-cert"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("ca_cert must be a PEM-encoded certificate")))
+		})
+	})
+
+	Context("when a client certificate is provided without its key", func() {
+		BeforeEach(func() {
+			outRequest.Source.ClientCert = "some-client-cert"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("client_cert and client_key must be provided together"))
+		})
+	})
+
+	Context("when connect timeout is not a duration", func() {
+		BeforeEach(func() {
+			outRequest.Source.ConnectTimeout = "forever"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("connect_timeout must be a duration")))
+		})
+	})
+
+	Context("when the sanitizer is disabled without debug", func() {
+		BeforeEach(func() {
+			outRequest.Source.DisableSanitizerForDebug = true
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError("disable_sanitizer_for_debug may only be provided in source along with debug"))
+		})
+
+		Context("when debug is set", func() {
+			BeforeEach(func() {
+				outRequest.Source.Debug = true
+			})
+
+			It("returns without error", func() {
+				Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+			})
+		})
+	})
+
+	Context("when a proxy is not a URL", func() {
+		BeforeEach(func() {
+			outRequest.Source.HTTPSProxy = "proxy.example.com"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(ContainSubstring("https_proxy must be a URL")))
+		})
+	})
+
+	Context("when requests per second is negative", func() {
+		BeforeEach(func() {
+			outRequest.Source.RequestsPerSecond = -1
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*requests_per_second.*negative"))
+		})
+	})
+
+	Context("when multiple targets are provided", func() {
+		BeforeEach(func() {
+			outRequest.Source.Targets = []concourse.Target{
+				{
+					Name:   "eu",
+					Target: "some eu target",
+					Teams:  outRequest.Source.Teams,
+				},
+				{
+					Name:   "us",
+					Target: "some us target",
+					Teams:  outRequest.Source.Teams,
+				},
+			}
+			outRequest.Source.Teams = nil
+
+			outRequest.Params.Pipelines[0].Target = "us"
+		})
+
+		It("returns without error", func() {
+			Expect(validator.ValidateOut(outRequest)).Should(Succeed())
+		})
+
+		Context("when teams are also provided in source", func() {
+			BeforeEach(func() {
+				outRequest.Source.Teams = outRequest.Source.Targets[0].Teams
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(MatchError("only one of teams or targets may be provided in source"))
+			})
+		})
+
+		Context("when target names are not unique", func() {
+			BeforeEach(func() {
+				outRequest.Source.Targets[1].Name = "eu"
+				outRequest.Params.Pipelines[0].Target = "eu"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(MatchError("targets[1].name 'eu' must be unique"))
+			})
+		})
+
+		Context("when a target has no teams", func() {
+			BeforeEach(func() {
+				outRequest.Source.Targets[0].Teams = nil
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(MatchError("targets[0].teams must be provided in source"))
+			})
+		})
+
+		Context("when the target of a pipeline is not provided", func() {
+			BeforeEach(func() {
+				outRequest.Params.Pipelines[0].Target = "ap"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateOut(outRequest)
+				Expect(err).To(MatchError("pipelines[0].target name 'ap' not found in source targets"))
+			})
+		})
+	})
+
+	Context("when top-level credentials are provided along with teams", func() {
+		BeforeEach(func() {
+			outRequest.Source.Username = "some username"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp("top-level.*username.*without teams or targets"))
+		})
+	})
+
+	Context("when there are several problems", func() {
+		BeforeEach(func() {
+			outRequest.Source.Target = ""
+			outRequest.Source.Teams[1].Password = ""
+			outRequest.Params.Pipelines[0].ConfigFile = ""
+		})
+
+		It("reports all of them", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(MatchError(
+				"3 problems found:\n" +
+					"  - target must be provided in source, e.g. https://ci.example.com\n" +
+					"  - teams[1].password must be provided for team: other team\n" +
+					"  - pipelines[0].config_file must be provided, e.g. repo/pipeline.yml",
+			))
+		})
+	})
+
+	Context("when team pattern is not a regular expression", func() {
+		BeforeEach(func() {
+			outRequest.Source.TeamPattern = "product-("
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateOut(outRequest)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*team_pattern.*regular expression"))
+		})
+	})
+})
+
+var _ = Describe("ValidateLogFile", func() {
+	It("returns an error if the log file is outside the directory of the step", func() {
+		Expect(validator.ValidateLogFile("../out.log")).To(MatchError(ContainSubstring("log_file must be a path inside the directory of the step")))
+		Expect(validator.ValidateLogFile("/tmp/out.log")).To(HaveOccurred())
+	})
+
+	It("returns without error if the log file is inside it or not given", func() {
+		Expect(validator.ValidateLogFile("logs/out.log")).To(Succeed())
+		Expect(validator.ValidateLogFile("")).To(Succeed())
+	})
 })
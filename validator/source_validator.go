@@ -0,0 +1,186 @@
+package validator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/concourse/concourse-pipeline-resource/concourse"
+	"github.com/concourse/concourse-pipeline-resource/logger"
+)
+
+// validateSource adds the problems with the source options common to check,
+// in and out to errs.
+func validateSource(errs *Errors, source concourse.Source) {
+	_, err := source.RequestTimeoutDuration()
+	if err != nil {
+		errs.add("%v", err)
+	}
+
+	_, err = source.ConnectTimeoutDuration()
+	if err != nil {
+		errs.add("%v", err)
+	}
+
+	_, err = source.IdleTimeoutDuration()
+	if err != nil {
+		errs.add("%v", err)
+	}
+
+	_, err = source.RetryPolicy()
+	if err != nil {
+		errs.add("%v", err)
+	}
+
+	_, err = source.PipelineChecksum(nil)
+	if err != nil {
+		errs.add("%v", err)
+	}
+
+	if source.CACert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(source.CACert)) {
+		errs.add("%s must be a PEM-encoded certificate, starting with -----BEGIN CERTIFICATE-----", "ca_cert")
+	}
+
+	if (source.ClientCert == "") != (source.ClientKey == "") {
+		errs.add("%s and %s must be provided together", "client_cert", "client_key")
+	} else if source.ClientCert != "" {
+		_, err = tls.X509KeyPair([]byte(source.ClientCert), []byte(source.ClientKey))
+		if err != nil {
+			errs.add("%s and %s must be a PEM-encoded certificate and its private key: %v", "client_cert", "client_key", err)
+		}
+	}
+
+	validateInsecure(errs, "insecure", source.Insecure)
+	validateTeamPattern(errs, "team_pattern", source.TeamPattern)
+
+	for i, target := range source.Targets {
+		validateInsecure(errs, fmt.Sprintf("targets[%d].insecure", i), target.Insecure)
+		validateTeamPattern(errs, fmt.Sprintf("targets[%d].team_pattern", i), target.TeamPattern)
+	}
+
+	validateURL(errs, "api_url", source.APIURL)
+	for i, target := range source.Targets {
+		validateURL(errs, fmt.Sprintf("targets[%d].api_url", i), target.APIURL)
+	}
+
+	validateProxy(errs, "http_proxy", source.HTTPProxy)
+	validateProxy(errs, "https_proxy", source.HTTPSProxy)
+
+	if source.Tracing != nil && source.Tracing.Endpoint != "" && !isURL(source.Tracing.Endpoint) {
+		errs.add("%s must be a URL, e.g. http://otel-collector:4318", "tracing.endpoint")
+	}
+
+	if source.AWS != nil && (source.AWS.AccessKeyID == "") != (source.AWS.SecretAccessKey == "") {
+		errs.add("%s and %s must be provided together", "aws.access_key_id", "aws.secret_access_key")
+	}
+
+	switch source.LogFormat {
+	case "", concourse.LogFormatText, concourse.LogFormatJSON:
+	default:
+		errs.add("%s must be one of %s or %s: %s", "log_format", concourse.LogFormatText, concourse.LogFormatJSON, source.LogFormat)
+	}
+
+	if source.LogLevel != "" {
+		_, err := logger.ParseLevel(source.LogLevel)
+		if err != nil {
+			errs.add("%s %v", "log_level", err)
+		}
+	}
+
+	switch source.VersionScheme {
+	case "", concourse.VersionSchemeChecksum, concourse.VersionSchemeConfigVersion:
+	default:
+		errs.add("%s must be one of %s or %s: %s", "version_scheme", concourse.VersionSchemeChecksum, concourse.VersionSchemeConfigVersion, source.VersionScheme)
+	}
+
+	switch source.VersionMode {
+	case "", concourse.VersionModeMap, concourse.VersionModeDigest, concourse.VersionModePipeline:
+	default:
+		errs.add("%s must be one of %s, %s or %s: %s", "version_mode", concourse.VersionModeMap, concourse.VersionModeDigest, concourse.VersionModePipeline, source.VersionMode)
+	}
+
+	if source.DisableSanitizerForDebug && !source.Debug {
+		errs.add("%s may only be provided in source along with %s", "disable_sanitizer_for_debug", "debug")
+	}
+
+	if source.RequestsPerSecond < 0 {
+		errs.add("%s must not be negative", "requests_per_second")
+	}
+}
+
+func validateInsecure(errs *Errors, path string, insecure concourse.Bool) {
+	_, err := insecure.Parse()
+	if err != nil {
+		errs.add("%s must be a boolean: %v", path, err)
+	}
+}
+
+func validateProxy(errs *Errors, field string, proxy string) {
+	if proxy != "" && !isURL(proxy) {
+		errs.add("%s must be a URL, e.g. http://proxy.example.com:3128", field)
+	}
+}
+
+func validateURL(errs *Errors, field string, value string) {
+	if value != "" && !isURL(value) {
+		errs.add("%s must be a URL, e.g. https://ci.example.com", field)
+	}
+}
+
+func isURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func validateTeamPattern(errs *Errors, path string, pattern string) {
+	_, err := regexp.Compile(pattern)
+	if err != nil {
+		errs.add("%s must be a valid regular expression, e.g. ^product-: %v", path, err)
+	}
+}
+
+// validateTargets adds the problems with either the target and teams of
+// source, or its targets, to errs.
+func validateTargets(errs *Errors, source concourse.Source) {
+	if source.Username != "" || source.Password != "" || source.ClientID != "" || source.ClientSecret != "" || source.Token != "" {
+		errs.add(
+			"top-level %s may only be provided in source without %s or %s; give the credentials of each team instead",
+			"username/password, client_id/client_secret and token",
+			"teams",
+			"targets",
+		)
+	}
+
+	if len(source.Targets) == 0 {
+		if source.Target == "" {
+			errs.add("%s must be provided in source, e.g. https://ci.example.com", "target")
+		}
+
+		validateTeams(errs, "teams", source.Teams)
+		return
+	}
+
+	if len(source.Teams) > 0 {
+		errs.add("only one of %s or %s may be provided in source", "teams", "targets")
+	}
+
+	names := make(map[string]bool)
+	for i, target := range source.Targets {
+		targetPath := fmt.Sprintf("targets[%d]", i)
+
+		if target.Name == "" {
+			errs.add("%s.%s must be provided, e.g. eu", targetPath, "name")
+		} else if names[target.Name] {
+			errs.add("%s.%s '%s' must be unique", targetPath, "name", target.Name)
+		}
+		names[target.Name] = true
+
+		if target.Target == "" {
+			errs.add("%s.%s must be provided, e.g. https://ci.example.com", targetPath, "target")
+		}
+
+		validateTeams(errs, targetPath+".teams", target.Teams)
+	}
+}
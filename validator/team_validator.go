@@ -7,23 +7,64 @@ import (
 )
 
 func ValidateTeams(teams []concourse.Team) error {
+	var errs Errors
+	validateTeams(&errs, "teams", teams)
+	return errs.err()
+}
+
+// validateTeams adds the problems with the teams to errs. Problems are
+// reported with the path of the field in source, e.g. teams[0].password.
+func validateTeams(errs *Errors, path string, teams []concourse.Team) {
 	if teams == nil || len(teams) == 0 {
-		return fmt.Errorf("%s must be provided in source", "teams")
+		errs.add("%s must be provided in source", path)
+		return
 	}
 
 	for i, team := range teams {
+		teamPath := fmt.Sprintf("%s[%d]", path, i)
+
 		if team.Name == "" {
-			return fmt.Errorf("%s must be provided for team: %d", "name", i)
+			errs.add("%s.%s must be provided, e.g. main", teamPath, "name")
 		}
 
 		if team.Username == "" && team.Password != "" {
-			return fmt.Errorf("%s must be provided for team: %s", "username", team.Name)
+			errs.add("%s.%s must be provided for team: %s", teamPath, "username", team.Name)
 		}
 
 		if team.Password == "" && team.Username != "" {
-			return fmt.Errorf("%s must be provided for team: %s", "password", team.Name)
+			errs.add("%s.%s must be provided for team: %s", teamPath, "password", team.Name)
+		}
+
+		if team.ClientID == "" && team.ClientSecret != "" {
+			errs.add("%s.%s must be provided for team: %s", teamPath, "client_id", team.Name)
 		}
-	}
 
-	return nil
+		if team.ClientSecret == "" && team.ClientID != "" {
+			errs.add("%s.%s must be provided for team: %s", teamPath, "client_secret", team.Name)
+		}
+
+		_, err := team.Insecure.Parse()
+		if err != nil {
+			errs.add("%s.%s must be a boolean for team: %s: %v", teamPath, "insecure", team.Name, err)
+		}
+
+		if team.Token != "" && (team.Username != "" || team.ClientID != "") {
+			errs.add(
+				"%s: only one of %s, %s or %s may be provided for team: %s",
+				teamPath,
+				"username/password",
+				"client_id/client_secret",
+				"token",
+				team.Name,
+			)
+		} else if team.ClientID != "" && team.Username != "" {
+			errs.add(
+				"%s: only one of %s or %s may be provided for team: %s",
+				teamPath,
+				"username/password",
+				"client_id/client_secret",
+				team.Name,
+			)
+		}
+	}
 }
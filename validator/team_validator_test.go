@@ -50,7 +50,7 @@ var _ = Describe("ValidateTeams", func() {
 			err := validator.ValidateTeams(teams)
 			Expect(err).To(HaveOccurred())
 
-			Expect(err.Error()).To(MatchRegexp(".*name.*provided.*team.*0"))
+			Expect(err.Error()).To(MatchRegexp(`teams\[0\]\.name.*provided`))
 		})
 	})
 
@@ -80,6 +80,100 @@ var _ = Describe("ValidateTeams", func() {
 		})
 	})
 
+	Context("when client credentials are provided", func() {
+		BeforeEach(func() {
+			teams[0].Username = ""
+			teams[0].Password = ""
+			teams[0].ClientID = "some client id"
+			teams[0].ClientSecret = "some client secret"
+		})
+
+		It("does not throw an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		Context("when no client secret is provided", func() {
+			BeforeEach(func() {
+				teams[0].ClientSecret = ""
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*client_secret.*provided.*team.*%s", "some team"))
+			})
+		})
+
+		Context("when no client id is provided", func() {
+			BeforeEach(func() {
+				teams[0].ClientID = ""
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp(".*client_id.*provided.*team.*%s", "some team"))
+			})
+		})
+
+		Context("when username and password are also provided", func() {
+			BeforeEach(func() {
+				teams[0].Username = "some username"
+				teams[0].Password = "some password"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp("only one of.*team.*%s", "some team"))
+			})
+		})
+	})
+
+	Context("when a token is provided", func() {
+		BeforeEach(func() {
+			teams[0].Username = ""
+			teams[0].Password = ""
+			teams[0].Token = "some token"
+		})
+
+		It("does not throw an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when username and password are also provided", func() {
+			BeforeEach(func() {
+				teams[0].Username = "some username"
+				teams[0].Password = "some password"
+			})
+
+			It("returns an error", func() {
+				err := validator.ValidateTeams(teams)
+				Expect(err).To(HaveOccurred())
+
+				Expect(err.Error()).To(MatchRegexp("only one of.*token.*team.*%s", "some team"))
+			})
+		})
+	})
+
+	Context("when insecure is not a boolean", func() {
+		BeforeEach(func() {
+			teams[0].Insecure = "sometimes"
+		})
+
+		It("returns an error", func() {
+			err := validator.ValidateTeams(teams)
+			Expect(err).To(HaveOccurred())
+
+			Expect(err.Error()).To(MatchRegexp(".*insecure.*boolean.*team.*%s", "some team"))
+		})
+	})
+
 	Context("when there are no teams", func() {
 		It("returns an error", func() {
 			err := validator.ValidateTeams([]concourse.Team{})